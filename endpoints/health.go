@@ -7,14 +7,15 @@ import (
 	"context"
 	"encoding/json"
 	"net/http"
+	"time"
 
 	"github.com/rs/zerolog/log"
 	"spectrocloud.com/spectromate/internal"
 )
 
 // NewHandlerContext returns a new CounterRoute with a database connection.
-func NewHealthHandlerContext(ctx context.Context, version string) *HealthRoute {
-	return &HealthRoute{ctx, version}
+func NewHealthHandlerContext(ctx context.Context, version string, c internal.Cache, backendCheck *internal.CachedCheck, workers *internal.WorkerTracker) *HealthRoute {
+	return &HealthRoute{ctx, version, c, backendCheck, workers}
 }
 
 func (health *HealthRoute) HealthHTTPHandler(writer http.ResponseWriter, request *http.Request) {
@@ -44,7 +45,115 @@ func (health *HealthRoute) HealthHTTPHandler(writer http.ResponseWriter, request
 	}
 }
 
+// ReadyHTTPHandler reports whether the server is able to answer questions.
+// A 503 status code is returned if the cache is unavailable or the worker backlog is full.
+func (health *HealthRoute) ReadyHTTPHandler(writer http.ResponseWriter, request *http.Request) {
+	log.Debug().Msg("Readiness check request received.")
+	writer.Header().Set("Content-Type", "application/json")
+	writer.Header().Set("Access-Control-Allow-Origin", "*")
+	writer.Header().Set("User-Agent", internal.GetUserAgentString(&health.Version))
+
+	if request.Method != http.MethodGet {
+		log.Debug().Msg("Invalid method.")
+		http.Error(writer, "Invalid method.", http.StatusMethodNotAllowed)
+		return
+	}
+
+	report := health.readinessReport(request.Context())
+
+	payload, err := json.Marshal(report)
+	if err != nil {
+		log.Error().Err(err).Msg("Error marshalling the readiness report.")
+		http.Error(writer, "Error creating the readiness report.", http.StatusInternalServerError)
+		return
+	}
+
+	if report.Status != HealthStatusNotReady {
+		writer.WriteHeader(http.StatusOK)
+	} else {
+		log.Warn().RawJSON("report", payload).Msg("The server is not ready.")
+		writer.WriteHeader(http.StatusServiceUnavailable)
+	}
+
+	_, err = writer.Write(payload)
+	if err != nil {
+		log.Error().Err(err).Msg("Error writing response to the readiness endpoint.")
+	}
+}
+
 // getHandler returns a health check response.
 func (health *HealthRoute) getHandler(r *http.Request) ([]byte, error) {
 	return json.Marshal(map[string]string{"status": "OK"})
 }
+
+// readinessReport checks every dependency and returns the combined report.
+func (health *HealthRoute) readinessReport(ctx context.Context) HealthReport {
+	report := HealthReport{
+		Status:       HealthStatusReady,
+		Version:      health.Version,
+		Components:   map[string]ComponentHealth{},
+		DegradedAsks: internal.DegradationCounts(),
+	}
+
+	if health.cache != nil {
+		start := time.Now()
+		err := health.cache.Ping()
+		report.Components["cache"] = newComponentHealth(err, time.Since(start))
+	}
+
+	if health.backendCheck != nil {
+		result := health.backendCheck.Run(ctx)
+		component := newComponentHealth(result.Err, result.Latency)
+		component.CheckedAt = result.CheckedAt.UTC().Format(time.RFC3339)
+		if internal.MendableCircuitOpen() {
+			component.Status = HealthStatusDown
+			component.Error = internal.ErrCircuitOpen.Error()
+		}
+		report.Components["answer_backend"] = component
+	}
+
+	if health.workers != nil {
+		component := ComponentHealth{
+			Status: HealthStatusUp,
+			Active: health.workers.Active(),
+			Limit:  health.workers.Limit(),
+		}
+		if health.workers.Saturated() {
+			component.Status = HealthStatusDown
+			component.Error = "worker backlog limit reached"
+		}
+		report.Components["workers"] = component
+	}
+
+	// An unreachable answer backend only degrades the server. Taking every replica out of rotation
+	// would prevent users from receiving the degraded reply, so only the cache and workers are critical.
+	for name, component := range report.Components {
+		if component.Status == HealthStatusUp {
+			continue
+		}
+		if name == "answer_backend" {
+			if report.Status == HealthStatusReady {
+				report.Status = HealthStatusDegraded
+			}
+			continue
+		}
+		report.Status = HealthStatusNotReady
+	}
+
+	return report
+}
+
+// newComponentHealth returns the health of a component from the check error and latency.
+func newComponentHealth(err error, latency time.Duration) ComponentHealth {
+	component := ComponentHealth{
+		Status:    HealthStatusUp,
+		LatencyMs: float64(latency.Microseconds()) / 1000,
+	}
+
+	if err != nil {
+		component.Status = HealthStatusDown
+		component.Error = err.Error()
+	}
+
+	return component
+}
@@ -0,0 +1,97 @@
+// Copyright (c) Spectro Cloud
+// SPDX-License-Identifier: Apache-2.0
+
+package endpoints
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"spectrocloud.com/spectromate/internal"
+	"spectrocloud.com/spectromate/mock"
+)
+
+func readinessResponse(t *testing.T, route *HealthRoute) (int, HealthReport) {
+	t.Helper()
+	recorder := httptest.NewRecorder()
+	route.ReadyHTTPHandler(recorder, httptest.NewRequest(http.MethodGet, "/api/v1/health/ready", nil))
+
+	var report HealthReport
+	require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &report))
+	return recorder.Code, report
+}
+
+func TestReadyHTTPHandler(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	cache := mock.NewMockCache(ctrl)
+	backendErr := error(nil)
+	backendCheck := internal.NewCachedCheck(0, func(ctx context.Context) error { return backendErr })
+	workers := internal.NewWorkerTracker(10)
+	route := NewHealthHandlerContext(context.Background(), "1.0.0", cache, backendCheck, workers)
+
+	// All dependencies are available
+	cache.EXPECT().Ping().Return(nil)
+	code, report := readinessResponse(t, route)
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, HealthStatusReady, report.Status)
+	assert.Equal(t, "1.0.0", report.Version)
+	assert.Equal(t, HealthStatusUp, report.Components["cache"].Status)
+	assert.Equal(t, HealthStatusUp, report.Components["answer_backend"].Status)
+	assert.Equal(t, HealthStatusUp, report.Components["workers"].Status)
+	assert.Equal(t, int64(10), report.Components["workers"].Limit)
+
+	// Degraded asks are reported without changing the status
+	internal.RecordDegradation(internal.DegradationCacheWrite)
+	cache.EXPECT().Ping().Return(nil)
+	code, report = readinessResponse(t, route)
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, HealthStatusReady, report.Status)
+	assert.Equal(t, internal.DegradationCount(internal.DegradationCacheWrite), report.DegradedAsks[internal.DegradationCacheWrite])
+
+	// The answer backend is unreachable
+	backendErr = errors.New("no such host")
+	cache.EXPECT().Ping().Return(nil)
+	code, report = readinessResponse(t, route)
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, HealthStatusDegraded, report.Status)
+	assert.Equal(t, "no such host", report.Components["answer_backend"].Error)
+
+	// The cache is unavailable
+	backendErr = nil
+	cache.EXPECT().Ping().Return(errors.New("connection refused"))
+	code, report = readinessResponse(t, route)
+	assert.Equal(t, http.StatusServiceUnavailable, code)
+	assert.Equal(t, HealthStatusNotReady, report.Status)
+	assert.Equal(t, HealthStatusDown, report.Components["cache"].Status)
+	assert.Equal(t, "connection refused", report.Components["cache"].Error)
+}
+
+func TestReadyHTTPHandlerWorkerBacklog(t *testing.T) {
+	workers := internal.NewWorkerTracker(1)
+	release := make(chan struct{})
+	workers.Go(func() { <-release })
+	defer close(release)
+
+	route := NewHealthHandlerContext(context.Background(), "1.0.0", nil, nil, workers)
+
+	code, report := readinessResponse(t, route)
+	assert.Equal(t, http.StatusServiceUnavailable, code)
+	assert.Equal(t, HealthStatusDown, report.Components["workers"].Status)
+	assert.Equal(t, int64(1), report.Components["workers"].Active)
+}
+
+func TestReadyHTTPHandlerMethod(t *testing.T) {
+	route := NewHealthHandlerContext(context.Background(), "1.0.0", nil, nil, nil)
+	recorder := httptest.NewRecorder()
+	route.ReadyHTTPHandler(recorder, httptest.NewRequest(http.MethodPost, "/api/v1/health/ready", nil))
+	assert.Equal(t, http.StatusMethodNotAllowed, recorder.Code)
+}
@@ -6,15 +6,32 @@ package endpoints
 import (
 	"context"
 	"net/http"
+	"time"
 
 	"github.com/rs/zerolog/log"
 	"spectrocloud.com/spectromate/internal"
 	"spectrocloud.com/spectromate/slackActions"
 )
 
-// NewHandlerContext returns a new CounterRoute with a database connection.
-func NewActionsHandlerContext(ctx context.Context, signingSecret, mendableApiKey, version string) *ActionsRoute {
-	return &ActionsRoute{ctx, signingSecret, mendableApiKey, &internal.SlackActionEvent{}, version}
+// NewActionsHandlerContext returns a new ActionsRoute with the dependencies of the answer actions.
+// The Slack API opens dialogs and posts escalations, and is nil without a bot token. The command runner runs the
+// commands of answer actions, such as asking a question again.
+func NewActionsHandlerContext(ctx context.Context, deps Dependencies, runCommand slackActions.CommandRunner) *ActionsRoute {
+	return &ActionsRoute{
+		ctx:            ctx,
+		signingSecret:  deps.SigningSecret,
+		mendableApiKey: deps.MendableAPIKey,
+		cache:          deps.Cache,
+		ActionsEvent:   &internal.SlackActionEvent{},
+		Version:        deps.Version,
+		workers:        deps.Workers,
+		access:         deps.Access,
+		audit:          deps.Audit,
+		slackAPI:       deps.SlackAPI,
+		runCommand:     runCommand,
+		escalation:     deps.Escalation,
+		backends:       deps.Backends,
+	}
 }
 
 func (actions *ActionsRoute) ActionsHTTPHandler(writer http.ResponseWriter, request *http.Request) {
@@ -69,16 +86,103 @@ func (actions *ActionsRoute) ActionsHTTPHandler(writer http.ResponseWriter, requ
 func (actions *ActionsRoute) getHandler(routeRequest *ActionsRoute, reqeust *http.Request, action *internal.SlackActionEvent) ([]byte, error) {
 	var returnPayload []byte
 
-	slackRequestInfo := slackActions.NewSlackActionFeedback(routeRequest.ctx, action, routeRequest.mendableApiKey, actions.Version)
+	start := time.Now()
+	audit := func(outcome string, err error) {
+		actions.audit.Record(actions.ctx, internal.NewActionAuditRecord(action, outcome, start, err))
+	}
+
+	if len(action.Actions) == 0 && action.Type != internal.SlackInteractionViewSubmission {
+		log.Debug().Msg("The action event contains no actions.")
+		return returnPayload, nil
+	}
+
+	// The access policy is checked before any action runs.
+	// Interactive actions ignore the HTTP response, so the denial is sent to the response URL.
+	subject := internal.SubjectFromAction(action)
+	if allowed, reason := actions.access.Check(subject); !allowed {
+		internal.LogAccessDenied(subject, "action", actionName(action), reason)
+		audit(internal.AuditOutcomeDenied, nil)
+		// Dialogs are closed with an empty response and have no response URL.
+		if action.ResponseURL == "" {
+			return returnPayload, nil
+		}
+		actions.workers.Go(func() {
+			err := internal.ReplyWithMessage(actions.ctx, action.ResponseURL, internal.DefaultAccessDeniedMessage, true)
+			if err != nil {
+				internal.LogError(err)
+				log.Info().Err(err).Msg("Error when attempting to return the access denied message back to Slack.")
+			}
+		})
+		return returnPayload, nil
+	}
+
+	slackRequestInfo := slackActions.NewSlackActionFeedback(routeRequest.ctx, action, actions.channelConfig(routeRequest.ctx, action), slackActions.ActionDependencies{
+		MendableAPIKey: routeRequest.mendableApiKey,
+		Backends:       actions.backends,
+		Version:        actions.Version,
+		Cache:          actions.cache,
+		Escalation:     actions.escalation,
+	})
+
+	// The follow-up dialog is closed by the empty response, and the question is answered in the background.
+	if action.Type == internal.SlackInteractionViewSubmission {
+		if action.View.CallbackID != internal.FollowUpViewCallbackID {
+			log.Debug().Str("callback_id", action.View.CallbackID).Msg("Unknown dialog.")
+			return returnPayload, nil
+		}
+		log.Debug().Msg("Follow-up question submitted.")
+		actions.workers.Go(func() {
+			audit(auditOutcome(slackActions.FollowUpSubmissionHandler(slackRequestInfo, actions.runCommand)))
+		})
+		return returnPayload, nil
+	}
 
 	switch action.Actions[0].ActionID {
 
 	case internal.ActionsAskModelPositiveFeedbackID:
 		log.Debug().Msg("Positive feedback action triggered.")
-		go slackActions.ModelFeedbackHandler(slackRequestInfo, internal.PositiveFeedbackScore)
+		actions.workers.Go(func() {
+			audit(auditOutcome(slackActions.ModelFeedbackHandler(slackRequestInfo, internal.PositiveFeedbackScore)))
+		})
 	case internal.ActionsAskModelNegativeFeedbackID:
 		log.Debug().Msg("Negative feedback action triggered.")
-		go slackActions.ModelFeedbackHandler(slackRequestInfo, internal.NegativeFeedbackScore)
+		actions.workers.Go(func() {
+			audit(auditOutcome(slackActions.ModelFeedbackHandler(slackRequestInfo, internal.NegativeFeedbackScore)))
+		})
+	case internal.ActionsAskModelRemoveFeedbackID:
+		log.Debug().Msg("Remove feedback action triggered.")
+		actions.workers.Go(func() {
+			audit(auditOutcome(slackActions.ModelFeedbackHandler(slackRequestInfo, internal.NoFeedbackScore)))
+		})
+	case internal.ActionsAskShareID:
+		log.Debug().Msg("Share answer action triggered.")
+		actions.workers.Go(func() {
+			audit(auditOutcome(slackActions.ShareAnswerHandler(slackRequestInfo)))
+		})
+	case internal.ActionsAskFollowUpID:
+		log.Debug().Msg("Follow-up action triggered.")
+		actions.workers.Go(func() {
+			audit(auditOutcome(slackActions.FollowUpHandler(slackRequestInfo, actions.slackAPI)))
+		})
+	case internal.ActionsAskRegenerateID:
+		log.Debug().Msg("Regenerate answer action triggered.")
+		actions.workers.Go(func() {
+			audit(auditOutcome(slackActions.RegenerateAnswerHandler(slackRequestInfo, actions.runCommand)))
+		})
+	case internal.ActionsAskShowRestID:
+		log.Debug().Msg("Show rest of the answer action triggered.")
+		actions.workers.Go(func() {
+			audit(auditOutcome(slackActions.ShowRestHandler(slackRequestInfo)))
+		})
+	case internal.ActionsAskEscalateID:
+		log.Debug().Msg("Escalate action triggered.")
+		actions.workers.Go(func() {
+			audit(auditOutcome(slackActions.EscalateHandler(slackRequestInfo, actions.slackAPI)))
+		})
+	case internal.ActionsAskOpenDocsID:
+		// Slack opens the link in the browser, the action is only recorded.
+		log.Debug().Msg("Open in docs action triggered.")
+		audit(internal.AuditOutcomeSuccess, nil)
 	default:
 		log.Debug().Msg("Unknown action.")
 	}
@@ -86,3 +190,34 @@ func (actions *ActionsRoute) getHandler(routeRequest *ActionsRoute, reqeust *htt
 	return returnPayload, nil
 
 }
+
+// actionName returns the ID of the action, or the callback ID of the submitted dialog.
+func actionName(action *internal.SlackActionEvent) string {
+	if len(action.Actions) > 0 {
+		return action.Actions[0].ActionID
+	}
+	return action.View.CallbackID
+}
+
+// auditOutcome returns the audit outcome of an action and the error, if any.
+func auditOutcome(err error) (string, error) {
+	if err != nil {
+		return internal.AuditOutcomeError, err
+	}
+	return internal.AuditOutcomeSuccess, nil
+}
+
+// channelConfig returns the settings of the channel of the action, or the default settings if they can't be read.
+func (actions *ActionsRoute) channelConfig(ctx context.Context, action *internal.SlackActionEvent) internal.ChannelConfig {
+	if actions.cache == nil || action.Channel.ID == "" {
+		return internal.ChannelConfig{}
+	}
+
+	config, err := internal.GetChannelConfig(ctx, actions.cache, action.Team.ID, action.Channel.ID)
+	if err != nil {
+		log.Warn().Err(err).Str("channel_id", action.Channel.ID).Msg("Unable to read the channel settings. Using the default settings.")
+		return internal.ChannelConfig{}
+	}
+
+	return config
+}
@@ -0,0 +1,105 @@
+// Copyright (c) Spectro Cloud
+// SPDX-License-Identifier: Apache-2.0
+
+package endpoints
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+
+	"github.com/rs/zerolog/log"
+	"spectrocloud.com/spectromate/internal"
+)
+
+// NewEventsHandlerContext returns a new EventsRoute. The events endpoint sends the replies
+// in the threads of escalated questions to the users who escalated them.
+func NewEventsHandlerContext(ctx context.Context, deps Dependencies) *EventsRoute {
+	return &EventsRoute{
+		ctx:           ctx,
+		signingSecret: deps.SigningSecret,
+		cache:         deps.Cache,
+		Version:       deps.Version,
+		slackAPI:      deps.SlackAPI,
+		escalation:    deps.Escalation,
+		workers:       deps.Workers,
+	}
+}
+
+// EventsHTTPHandler handles the requests of the Slack Events API.
+// Slack expects a response within 3 seconds, so the events are handled in the background.
+func (events *EventsRoute) EventsHTTPHandler(writer http.ResponseWriter, request *http.Request) {
+	writer.Header().Set("Content-Type", "application/json")
+	writer.Header().Set("User-Agent", internal.GetUserAgentString(&events.Version))
+
+	if request.Method != http.MethodPost {
+		log.Debug().Msg("invalid request method for /slack/events.")
+		http.Error(writer, "invalid request method", http.StatusMethodNotAllowed)
+		return
+	}
+
+	// Validate the request signature came from the Spectro Cloud Slack app.
+	err := internal.SourceValidation(request.Context(), request, events.signingSecret)
+	if err != nil {
+		log.Warn().Err(err).Msg("Error validating Slack request signature.")
+		http.Error(writer, "Forbidden", http.StatusForbidden)
+		return
+	}
+
+	body, err := io.ReadAll(request.Body)
+	if err != nil {
+		log.Debug().Err(err).Msg("error reading the Slack event.")
+		http.Error(writer, "invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	var event internal.SlackEventRequest
+	if err := json.Unmarshal(body, &event); err != nil {
+		log.Debug().Err(err).Msg("error decoding the Slack event.")
+		http.Error(writer, "invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	switch event.Type {
+	case internal.SlackEventURLVerification:
+		log.Debug().Msg("Slack events URL verification received.")
+		err = json.NewEncoder(writer).Encode(map[string]string{"challenge": event.Challenge})
+		if err != nil {
+			log.Error().Err(err).Msg("error writing response to the events endpoint.")
+		}
+		return
+	case internal.SlackEventCallback:
+		// Slack retries events that weren't acknowledged in time. The first delivery is already being handled.
+		if request.Header.Get("X-Slack-Retry-Num") == "" {
+			events.handleEvent(event.Event)
+		}
+	default:
+		log.Debug().Str("type", event.Type).Msg("Unknown Slack event type.")
+	}
+
+	writer.WriteHeader(http.StatusOK)
+}
+
+// handleEvent sends the replies in the threads of the support channel to the users who escalated the questions.
+// Other events are ignored.
+func (events *EventsRoute) handleEvent(event internal.SlackMessageEvent) {
+	if !events.escalation.Enabled() || events.slackAPI == nil {
+		return
+	}
+	if event.Channel != events.escalation.Channel || !event.IsThreadReply() {
+		return
+	}
+
+	events.workers.Go(func() {
+		escalated, err := internal.ForwardEscalationReply(events.ctx, events.cache, events.slackAPI, event)
+		if err != nil {
+			internal.LogError(err)
+			log.Warn().Err(err).Str("thread_ts", event.ThreadTS).Msg("Unable to send the reply to the user who escalated the question.")
+			return
+		}
+		if escalated {
+			log.Debug().Str("thread_ts", event.ThreadTS).Str("user_id", event.User).Msg("Sent the reply to the user who escalated the question.")
+		}
+	})
+}
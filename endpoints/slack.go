@@ -5,17 +5,51 @@ package endpoints
 
 import (
 	"context"
-	"errors"
+	"fmt"
 	"net/http"
-	"strings"
+	"time"
 
 	"github.com/rs/zerolog/log"
 	"spectrocloud.com/spectromate/internal"
 	"spectrocloud.com/spectromate/slackCmds"
 )
 
-func NewSlackHandlerContext(ctx context.Context, signingSecret, mendableApiKey string, c internal.Cache, version string) *SlackRoute {
-	return &SlackRoute{ctx, signingSecret, mendableApiKey, &internal.SlackEvent{}, c, version}
+// NewSlackHandlerContext returns a new SlackRoute with the dependencies of the slash commands.
+func NewSlackHandlerContext(ctx context.Context, deps Dependencies) *SlackRoute {
+	return &SlackRoute{
+		ctx:            ctx,
+		signingSecret:  deps.SigningSecret,
+		mendableApiKey: deps.MendableAPIKey,
+		SlackEvent:     &internal.SlackEvent{},
+		cache:          deps.Cache,
+		Version:        deps.Version,
+		conversation:   deps.Conversation,
+		scopes:         deps.Scopes,
+		backends:       deps.Backends,
+		rateLimiter:    deps.RateLimiter,
+		workers:        deps.Workers,
+		commands:       deps.Commands,
+		access:         deps.Access,
+		audit:          deps.Audit,
+		redactor:       deps.Redactor,
+		escalation:     deps.Escalation,
+		confidence:     deps.Confidence,
+	}
+}
+
+// commandDependencies returns the dependencies of the commands run by the route.
+func (slack *SlackRoute) commandDependencies() slackCmds.CommandDependencies {
+	return slackCmds.CommandDependencies{
+		MendableAPIKey: slack.mendableApiKey,
+		Backends:       slack.backends,
+		Cache:          slack.cache,
+		Version:        slack.Version,
+		Conversation:   slack.conversation,
+		Scopes:         slack.scopes,
+		Redactor:       slack.redactor,
+		Escalation:     slack.escalation,
+		Confidence:     slack.confidence,
+	}
 }
 
 func (slack *SlackRoute) SlackHTTPHandler(writer http.ResponseWriter, request *http.Request) {
@@ -76,140 +110,206 @@ func (slack *SlackRoute) SlackHTTPHandler(writer http.ResponseWriter, request *h
 }
 
 // getHandler is the main handler for the Slack endpoint.
-// It determines which command was sent by the user and calls the appropriate function.
-// An initial response is sent back to Slack with a 200 status code to avoid the 3 second timeout.
-// A Go routine is used to call the command function so the response can be sent back to Slack
-// The Go routine is used so the response can be sent back to Slack without waiting for the command function to finish.
+// It determines which command was sent by the user and calls the command handler from the registry.
+// Async commands are acknowledged with a 200 status code to avoid the 3 second timeout.
+// A Go routine is used to call the command handler so the response can be sent back to Slack
+// without waiting for the command handler to finish.
+// Every command is recorded in the audit log once its outcome is known.
 func (slack *SlackRoute) getHandler(writer http.ResponseWriter, r *http.Request) ([]byte, error) {
 
-	var (
-		returnPayload []byte
-		userCmd       string
-	)
+	start := time.Now()
+	cmd, args := resolveCommand(slack.commands, slack.SlackEvent.Text)
+	log.Debug().Msgf("Determined Command: %s", cmd.Name)
 
-	parts := strings.Split(slack.SlackEvent.Text, " ")
+	event := slack.SlackEvent
+	audit := func(outcome string, err error) {
+		slack.audit.Record(slack.ctx, internal.NewCommandAuditRecord(event, cmd.Name, outcome, start, err))
+	}
 
-	// Check if there are any parts
-	if len(parts) > 0 {
-		// Trim any leading or trailing whitespace from the first part
-		userCmd = strings.Split(slack.SlackEvent.Text, " ")[0]
-	} else {
-		log.Debug().Msg("No parts found in the Slack event text.")
-		userCmd = "help"
+	// The access policy is checked before any command runs, including help.
+	subject := internal.SubjectFromEvent(slack.SlackEvent)
+	if allowed, reason := slack.access.Check(subject); !allowed {
+		internal.LogAccessDenied(subject, "command", cmd.Name, reason)
+		audit(internal.AuditOutcomeDenied, nil)
+		return internal.MessagePayload(internal.DefaultAccessDeniedMessage, true)
 	}
 
-	// Get the command from the Slack event.
-	err := checkAfterKeyword(slack.SlackEvent.Text, userCmd)
-	if err != nil {
-		userCmd = "help"
+	channel := slack.channelConfig(r.Context(), slack.SlackEvent)
+	if !cmd.Unrestricted && !channel.AllowsCommand(cmd.Name) {
+		log.Debug().Str("channel_id", slack.SlackEvent.ChannelID).Str("command", cmd.Name).Msg("Command is disabled in the channel.")
+		audit(internal.AuditOutcomeDisabled, nil)
+		return internal.MessagePayload(fmt.Sprintf(internal.DefaultCommandDisabledMessage, cmd.Name), true)
 	}
 
-	// Convert the command to a SlackCommands type.
-	cmd, err := determineCommand(userCmd)
-	if err != nil {
-		log.Debug().Msg("Error converting string to SlackCommands type.")
+	if cmd.RateLimited {
+		if limitPayload, limited := slack.checkRateLimit(r.Context(), slack.SlackEvent); limited {
+			audit(internal.AuditOutcomeRateLimited, nil)
+			return limitPayload, nil
+		}
 	}
 
-	switch cmd {
-	case Help:
-		// The Help command does not need to be run in a Go routine.
-		// It's a simple command that just returns a string.
-		// The response is sent back to Slack immediately.
-		returnPayload, err = slackCmds.HelpCmd()
+	slackRequestInfo := slackCmds.NewSlackCommandRequest(slack.ctx, slack.SlackEvent, channel, args, slack.commandDependencies())
+
+	if !cmd.Async {
+		returnPayload, err := cmd.Handler(slackRequestInfo)
 		if err != nil {
 			internal.LogError(err)
 			log.Info().Err(err).Msg(internal.SlackDefaultUserErrorMessage)
+			audit(internal.AuditOutcomeError, err)
 			return nil, err
 		}
-	case Ask:
-		slackRequestInfo := slackCmds.NewSlackAskRequest(
-			slack.ctx,
-			slack.SlackEvent,
-			slack.mendableApiKey,
-			slack.cache,
-			slack.Version,
-		)
-
-		reply200Payload, err := internal.ReplyStatus200(slack.SlackEvent.ResponseURL, writer, false)
+		audit(internal.AuditOutcomeSuccess, nil)
+		return returnPayload, nil
+	}
+
+	// Reply back to slack with a 200 status code to avoid the 3 second timeout.
+	reply200Payload, err := internal.ReplyStatus200(slack.SlackEvent.ResponseURL, writer, cmd.IsPrivate(args, channel))
+	if err != nil {
+		log.Info().Err(err).Msg("failed to reply to slack with status 200.")
+		audit(internal.AuditOutcomeError, err)
+		return nil, err
+	}
+
+	// Start Go routine to call the command function.
+	slack.workers.Go(func() {
+		_, err := cmd.Handler(slackRequestInfo)
 		if err != nil {
-			log.Info().Err(err).Msg("failed to reply to slack with status 200.")
-			return nil, err
+			internal.LogError(err)
+			log.Info().Err(err).Str("command", cmd.Name).Msg("Error running the command.")
+			audit(internal.AuditOutcomeError, err)
+			return
 		}
-		// Reply back to slack with a 200 status code to avoid the 3 second timeout.
-		returnPayload = reply200Payload
-		// Start Go routine to call the command function.
-		go slackCmds.AskCmd(slackRequestInfo, false)
-	case PAsk:
-		slackRequestInfo := slackCmds.NewSlackAskRequest(
-			slack.ctx,
-			slack.SlackEvent,
-			slack.mendableApiKey,
-			slack.cache,
-			slack.Version,
-		)
-		// Reply back to slack with a 200 status code to avoid the 3 second timeout.
-		reply200Payload, err := internal.ReplyStatus200(slack.SlackEvent.ResponseURL, writer, true)
-		if err != nil {
-			log.Info().Err(err).Msg("failed to reply to slack with status 200.")
-			return nil, err
+		audit(internal.AuditOutcomeSuccess, nil)
+	})
+
+	return reply200Payload, nil
+}
+
+// RunCommand runs a command on behalf of an answer action, such as asking a question again.
+// The text of the event is the command and its arguments. The channel settings and rate limits apply as if the
+// user typed the command. The access policy is checked by the actions route before the action runs.
+// Actions have no HTTP response to reply with, so every reply is sent to the response URL of the event.
+func (slack *SlackRoute) RunCommand(ctx context.Context, event *internal.SlackEvent) error {
+
+	start := time.Now()
+	cmd, args := resolveCommand(slack.commands, event.Text)
+	log.Debug().Str("command", cmd.Name).Str("user_id", event.UserID).Msg("Running a command for an answer action.")
+
+	audit := func(outcome string, err error) {
+		slack.audit.Record(slack.ctx, internal.NewCommandAuditRecord(event, cmd.Name, outcome, start, err))
+	}
+
+	channel := slack.channelConfig(ctx, event)
+	if !cmd.Unrestricted && !channel.AllowsCommand(cmd.Name) {
+		log.Debug().Str("channel_id", event.ChannelID).Str("command", cmd.Name).Msg("Command is disabled in the channel.")
+		audit(internal.AuditOutcomeDisabled, nil)
+		return internal.ReplyWithMessage(ctx, event.ResponseURL, fmt.Sprintf(internal.DefaultCommandDisabledMessage, cmd.Name), true)
+	}
+
+	if cmd.RateLimited {
+		if limitPayload, limited := slack.checkRateLimit(ctx, event); limited {
+			audit(internal.AuditOutcomeRateLimited, nil)
+			return internal.ReplyWithAnswer(ctx, event.ResponseURL, limitPayload, true)
+		}
+	}
+
+	slackRequestInfo := slackCmds.NewSlackCommandRequest(slack.ctx, event, channel, args, slack.commandDependencies())
+
+	// Let the user know the command is running, like the acknowledgement of the slash command.
+	if cmd.Async {
+		isPrivate := cmd.IsPrivate(args, channel)
+		waitPayload, err := internal.ReplyStatus200(event.ResponseURL, nil, isPrivate)
+		if err == nil {
+			err = internal.ReplyWithAnswer(ctx, event.ResponseURL, waitPayload, isPrivate)
 		}
-		returnPayload = reply200Payload
-		// Start Go routine to call the command function.
-		go slackCmds.AskCmd(slackRequestInfo, true)
-	default:
-		returnPayload, err = slackCmds.HelpCmd()
 		if err != nil {
-			log.Info().Err(err).Msg(internal.SlackDefaultUserErrorMessage)
-			return nil, err
+			log.Info().Err(err).Msg("failed to send the wait message to slack.")
 		}
 	}
 
-	return returnPayload, err
+	returnPayload, err := cmd.Handler(slackRequestInfo)
+	if err == nil && !cmd.Async {
+		err = internal.ReplyWithAnswer(ctx, event.ResponseURL, returnPayload, true)
+	}
+	if err != nil {
+		internal.LogError(err)
+		log.Info().Err(err).Str("command", cmd.Name).Msg("Error running the command.")
+		audit(internal.AuditOutcomeError, err)
+		return err
+	}
+
+	audit(internal.AuditOutcomeSuccess, nil)
+	return nil
+}
+
+// channelConfig returns the settings of the channel the command was sent from.
+// The default settings are used if the settings can't be read so a cache outage doesn't block all commands.
+func (slack *SlackRoute) channelConfig(ctx context.Context, event *internal.SlackEvent) internal.ChannelConfig {
+	if slack.cache == nil {
+		return internal.ChannelConfig{}
+	}
+
+	config, err := internal.GetChannelConfig(ctx, slack.cache, event.TeamID, event.ChannelID)
+	if err != nil {
+		log.Warn().Err(err).Str("channel_id", event.ChannelID).Msg("Unable to read the channel settings. Using the default settings.")
+		return internal.ChannelConfig{}
+	}
+
+	return config
 }
 
-// determineCommand converts a string to a SlackCommands type.
-// An error is returned if the string does not match a SlackCommands type.
-func determineCommand(input string) (SlackCommands, error) {
+// checkRateLimit checks if the user is allowed to ask another question.
+// If the user is rate limited, an ephemeral payload with the time until the next allowed question is returned.
+// Requests are allowed if the rate limiter is unavailable so a cache outage doesn't block all questions.
+func (slack *SlackRoute) checkRateLimit(ctx context.Context, event *internal.SlackEvent) ([]byte, bool) {
+	if slack.rateLimiter == nil {
+		return nil, false
+	}
+
+	allowed, wait, err := slack.rateLimiter.Allow(ctx, event)
+	if err != nil {
+		internal.LogError(err)
+		log.Warn().Err(err).Msg("Unable to check the rate limit. Allowing the request.")
+		return nil, false
+	}
 
-	if strings.TrimSpace(input) == "" {
-		return -1, errors.New("empty command")
+	if allowed {
+		return nil, false
 	}
 
-	cmd, err := SlackCommandsFromString(input)
+	payload, err := internal.MessagePayload(fmt.Sprintf(internal.DefaultRateLimitedMessage, internal.FormatDuration(wait)), true)
 	if err != nil {
 		internal.LogError(err)
-		log.Info().Err(err).Msg("Error converting string to SlackCommands type.")
-		return cmd, err
+		log.Error().Err(err).Msg("Error creating the rate limit message payload.")
+		return nil, false
 	}
-	log.Debug().Msgf("Determined Command: %s", cmd)
-	return cmd, nil
+
+	log.Info().Str("user_id", event.UserID).Str("channel_id", event.ChannelID).Dur("wait", wait).Msg("User is rate limited.")
+	return payload, true
 }
 
-// checkAfterKeyword checks if there is anything after the keyword.
-// An error is returned if there is nothing after the keyword.
-func checkAfterKeyword(input string, keyword string) error {
-	// Split the input string by whitespace using strings.Fields
-	parts := strings.Fields(input)
-
-	// Find the index of the keyword in the parts
-	keywordIndex := -1
-	for i, part := range parts {
-		if part == keyword {
-			keywordIndex = i
-			break
-		}
+// resolveCommand returns the command typed by the user and its arguments.
+// The text is split into tokens so extra whitespace, tabs and new lines are ignored and quoted strings are kept together.
+// The help command is returned if the command is unknown or if a command requiring input has no positional arguments.
+func resolveCommand(commands *slackCmds.Registry, text string) (*slackCmds.Command, slackCmds.CommandArgs) {
+	help, _ := commands.Lookup("help")
+
+	tokens := slackCmds.Tokenize(text)
+	if len(tokens) == 0 {
+		log.Debug().Msg("No parts found in the Slack event text.")
+		return help, slackCmds.CommandArgs{}
 	}
 
-	// Check if there is anything after the keyword
-	if keywordIndex < len(parts)-1 {
-		// Check if anything after the keyword is empty
-		if strings.TrimSpace(parts[keywordIndex+1]) == "" {
-			return errors.New("nothing after keyword")
-		}
-	} else {
-		return errors.New("nothing after keyword")
+	cmd, ok := commands.Lookup(tokens[0])
+	if !ok {
+		log.Info().Msgf("unknown command: %s", tokens[0])
+		return help, slackCmds.CommandArgs{}
 	}
 
-	// No error
-	return nil
+	args := slackCmds.ParseArgs(tokens[1:], cmd.Flags)
+	if cmd.RequiresInput && len(args.Positional) == 0 {
+		return help, slackCmds.CommandArgs{}
+	}
+
+	return cmd, args
 }
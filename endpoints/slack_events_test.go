@@ -0,0 +1,111 @@
+// Copyright (c) Spectro Cloud
+// SPDX-License-Identifier: Apache-2.0
+
+package endpoints
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"spectrocloud.com/spectromate/internal"
+	"spectrocloud.com/spectromate/mock"
+)
+
+// signedRequest returns a request signed with the signing secret like Slack signs its requests.
+func signedRequest(secret, body string) *http.Request {
+	timestamp := "1700000000"
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(fmt.Sprintf("v0:%s:%s", timestamp, body)))
+
+	request := httptest.NewRequest(http.MethodPost, "/api/v1/slack/events", strings.NewReader(body))
+	request.Header.Set("X-Slack-Request-Timestamp", timestamp)
+	request.Header.Set("X-Slack-Signature", "v0="+hex.EncodeToString(mac.Sum(nil)))
+	return request
+}
+
+func TestEventsURLVerification(t *testing.T) {
+	events := NewEventsHandlerContext(context.Background(), Dependencies{SigningSecret: "secret", Version: "test", Workers: internal.NewWorkerTracker(0)})
+
+	recorder := httptest.NewRecorder()
+	events.EventsHTTPHandler(recorder, signedRequest("secret", `{"type": "url_verification", "challenge": "abc123"}`))
+	if recorder.Code != http.StatusOK {
+		t.Fatalf("unexpected status code: %d", recorder.Code)
+	}
+
+	var response map[string]string
+	if err := json.Unmarshal(recorder.Body.Bytes(), &response); err != nil || response["challenge"] != "abc123" {
+		t.Errorf("unexpected challenge response: %s", recorder.Body.String())
+	}
+
+	// Requests not signed by Slack are rejected.
+	recorder = httptest.NewRecorder()
+	events.EventsHTTPHandler(recorder, signedRequest("other", `{"type": "url_verification", "challenge": "abc123"}`))
+	if recorder.Code != http.StatusForbidden {
+		t.Errorf("expected an invalid signature to be rejected, got status code %d", recorder.Code)
+	}
+}
+
+func TestEventsEscalationReply(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	posted := make(chan string, 1)
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body map[string]interface{}
+		_ = json.NewDecoder(r.Body).Decode(&body)
+		posted <- fmt.Sprint(body["channel"])
+		_, _ = w.Write([]byte(`{"ok": true, "ts": "3.0"}`))
+	}))
+	defer ts.Close()
+
+	cache := mock.NewMockCache(ctrl)
+	cache.EXPECT().GetHashMap(gomock.Any(), "docs_bot:escalation:thread:CSUPPORT:1.0").Return(true, map[string]string{
+		"user_id": "U123", "support_channel": "CSUPPORT", "thread_ts": "1.0",
+	}, nil)
+
+	workers := internal.NewWorkerTracker(0)
+	events := NewEventsHandlerContext(context.Background(), Dependencies{
+		SigningSecret: "secret",
+		Cache:         cache,
+		Version:       "test",
+		SlackAPI:      internal.NewSlackAPI("xoxb-test", ts.URL),
+		Escalation:    internal.EscalationConfig{Channel: "CSUPPORT"},
+		Workers:       workers,
+	})
+
+	send := func(event string, retry bool) int {
+		request := signedRequest("secret", `{"type": "event_callback", "event": `+event+`}`)
+		if retry {
+			request.Header.Set("X-Slack-Retry-Num", "1")
+		}
+		recorder := httptest.NewRecorder()
+		events.EventsHTTPHandler(recorder, request)
+		return recorder.Code
+	}
+
+	reply := `{"type": "message", "channel": "CSUPPORT", "user": "U456", "text": "Try this.", "ts": "2.0", "thread_ts": "1.0"}`
+	if code := send(reply, false); code != http.StatusOK {
+		t.Fatalf("unexpected status code: %d", code)
+	}
+	if channel := <-posted; channel != "U123" {
+		t.Errorf("expected the reply to be sent to the user who escalated the question, got %s", channel)
+	}
+
+	// Retries, bot messages, and messages in other channels are ignored. The cache is only read once.
+	send(reply, true)
+	send(`{"type": "message", "channel": "CSUPPORT", "bot_id": "B123", "text": "Hi", "ts": "4.0", "thread_ts": "1.0"}`, false)
+	send(`{"type": "message", "channel": "COTHER", "user": "U456", "text": "Hi", "ts": "5.0", "thread_ts": "1.0"}`, false)
+	for deadline := time.Now().Add(time.Second); workers.Active() > 0 && time.Now().Before(deadline); {
+		time.Sleep(10 * time.Millisecond)
+	}
+}
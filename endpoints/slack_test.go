@@ -3,65 +3,202 @@
 
 package endpoints
 
-import "testing"
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
 
-func TestCheckAfterKeyword(t *testing.T) {
-	// Test with input that has text after the second word
-	input1 := "/docs ask How do I change order?"
-	keyword1 := "ask"
-	err1 := checkAfterKeyword(input1, keyword1)
-	if err1 != nil {
-		t.Errorf("checkAfterKeyword(%q, %q) returned an unexpected error: %v", input1, keyword1, err1)
+	"github.com/golang/mock/gomock"
+	"spectrocloud.com/spectromate/internal"
+	"spectrocloud.com/spectromate/mock"
+	"spectrocloud.com/spectromate/slackCmds"
+)
+
+func TestResolveCommand(t *testing.T) {
+	commands := slackCmds.NewDefaultRegistry(nil, nil)
+
+	tests := []struct {
+		name     string
+		text     string
+		expected string
+	}{
+		{"ask with a question", "ask How do I change order?", "ask"},
+		{"private ask with a question", "pask How do I change order?", "pask"},
+		{"case insensitive", "ASK How do I change order?", "ask"},
+		{"ask without a question", "ask", "help"},
+		{"ask with only whitespace", "ask     ", "help"},
+		{"ask with multiple spaces", "ask     How do I change order?", "ask"},
+		{"ask with tabs and new lines", "ask\tHow do I\nchange order?\r\n", "ask"},
+		{"ask with only flags", "ask --private --new", "help"},
+		{"help", "help", "help"},
+		{"unknown command", "unknown How do I change order?", "help"},
+		{"empty text", "", "help"},
 	}
 
-	// Test with input that has no text after the second word
-	input2 := "/docs ask"
-	keyword2 := "ask"
-	err2 := checkAfterKeyword(input2, keyword2)
-	if err2 == nil {
-		t.Errorf("checkAfterKeyword(%q, %q) did not return an error as expected", input2, keyword2)
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			cmd, _ := resolveCommand(commands, tc.text)
+			if cmd.Name != tc.expected {
+				t.Errorf("resolveCommand(%q) returned an unexpected command: got %s, want %s", tc.text, cmd.Name, tc.expected)
+			}
+		})
 	}
+}
 
-	// Test with input that has only whitespace characters after the second word
-	input3 := "/docs ask      "
-	keyword3 := "ask"
-	err3 := checkAfterKeyword(input3, keyword3)
-	if err3 == nil {
-		t.Errorf("checkAfterKeyword(%q, %q) did not return an error as expected", input3, keyword3)
+func TestResolveCommandArgs(t *testing.T) {
+	commands := slackCmds.NewDefaultRegistry(nil, nil)
+
+	cmd, args := resolveCommand(commands, "  ask   --private --product=palette How do I   change \"edge hosts\"?\r\n")
+	if cmd.Name != "ask" {
+		t.Fatalf("resolveCommand returned an unexpected command: got %s, want ask", cmd.Name)
+	}
+	if !cmd.IsPrivate(args, internal.ChannelConfig{}) {
+		t.Errorf("expected the --private flag to make the command private")
+	}
+	if args.Value("product") != "palette" {
+		t.Errorf("unexpected product flag: got %q, want palette", args.Value("product"))
+	}
+	if args.Text() != "How do I change \"edge hosts\"?" {
+		t.Errorf("unexpected query: got %q", args.Text())
 	}
 
-	// Test with input that has multiple consecutive whitespace characters after the second word
-	input4 := "/docs ask     How do I change order?"
-	keyword4 := "ask"
-	err4 := checkAfterKeyword(input4, keyword4)
-	if err4 != nil {
-		t.Errorf("checkAfterKeyword(%q, %q) returned an unexpected error: %v", input4, keyword4, err4)
+	cmd, args = resolveCommand(commands, "help --private")
+	if cmd.Name != "help" || cmd.IsPrivate(args, internal.ChannelConfig{}) {
+		t.Errorf("expected the help command to ignore the unknown --private flag")
 	}
 }
 
-func TestDetermineCommand(t *testing.T) {
-	// Test with a valid input command
-	input1 := "ask"
-	expected1 := Ask
-	actual1, err1 := determineCommand(input1)
-	if err1 != nil {
-		t.Errorf("determineCommand(%q) returned an unexpected error: %v", input1, err1)
+func TestGetHandlerChannelCommands(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	cache := mock.NewMockCache(ctrl)
+	cache.EXPECT().GetHashMap(gomock.Any(), "docs_bot:channel_config:T123:C123").Return(true, map[string]string{
+		"commands": "help",
+	}, nil).AnyTimes()
+
+	tests := []struct {
+		text     string
+		disabled bool
+	}{
+		{"ask How do I change order?", true},
+		{"pask How do I change order?", true},
+		{"help", false},
+		{"config", false},
 	}
-	if actual1 != expected1 {
-		t.Errorf("determineCommand(%q) returned an unexpected SlackCommands value: got %v, want %v", input1, actual1, expected1)
+
+	for _, tc := range tests {
+		t.Run(tc.text, func(t *testing.T) {
+			slack := &SlackRoute{
+				ctx:        context.Background(),
+				SlackEvent: &internal.SlackEvent{TeamID: "T123", ChannelID: "C123", UserID: "U123", Text: tc.text},
+				cache:      cache,
+				commands:   slackCmds.NewDefaultRegistry(nil, nil),
+			}
+
+			payload, err := slack.getHandler(httptest.NewRecorder(), httptest.NewRequest("POST", "/api/v1/slack", nil))
+			if err != nil {
+				t.Fatalf("getHandler returned an unexpected error: %v", err)
+			}
+
+			var message internal.SlackPayload
+			if err := json.Unmarshal(payload, &message); err != nil {
+				t.Fatalf("getHandler returned an invalid payload: %v", err)
+			}
+
+			disabled := strings.Contains(message.Blocks[0].Text.Text, "disabled in this channel")
+			if disabled != tc.disabled {
+				t.Errorf("unexpected result for %q: got disabled %t, want %t", tc.text, disabled, tc.disabled)
+			}
+		})
+	}
+}
+
+func TestGetHandlerAccessDenied(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	// The cache must not be used when the access policy denies the command.
+	cache := mock.NewMockCache(ctrl)
+	var auditLog bytes.Buffer
+
+	slack := &SlackRoute{
+		ctx:        context.Background(),
+		SlackEvent: &internal.SlackEvent{TeamID: "T123", ChannelID: "C123", UserID: "U123", Text: "ask How do I change order?"},
+		cache:      cache,
+		commands:   slackCmds.NewDefaultRegistry(nil, nil),
+		access:     internal.NewAccessPolicy(internal.AccessList{}, internal.AccessList{Users: internal.ParseIDList("U123")}),
+		audit:      internal.NewAuditLogger(internal.NewWriterSink(&auditLog), internal.AuditTextOmit, nil),
 	}
 
-	// Test with an invalid input command
-	input2 := "unknown"
-	_, err2 := determineCommand(input2)
-	if err2 == nil {
-		t.Errorf("determineCommand(%q) did not return an error as expected", input2)
+	payload, err := slack.getHandler(httptest.NewRecorder(), httptest.NewRequest("POST", "/api/v1/slack", nil))
+	if err != nil {
+		t.Fatalf("getHandler returned an unexpected error: %v", err)
 	}
 
-	// Test with an empty input command
-	input3 := ""
-	_, err3 := determineCommand(input3)
-	if err3 == nil {
-		t.Errorf("determineCommand(%q) did not return an error as expected", input3)
+	var message internal.SlackPayload
+	if err := json.Unmarshal(payload, &message); err != nil {
+		t.Fatalf("getHandler returned an invalid payload: %v", err)
+	}
+
+	if message.ResponseType != "ephemeral" || message.Blocks[0].Text.Text != internal.DefaultAccessDeniedMessage {
+		t.Errorf("getHandler returned an unexpected message: %+v", message)
+	}
+
+	var record internal.AuditRecord
+	if err := json.Unmarshal(auditLog.Bytes(), &record); err != nil {
+		t.Fatalf("the denied command was not recorded in the audit log: %v", err)
+	}
+	if record.Outcome != internal.AuditOutcomeDenied || record.Name != "ask" || record.Text != "" {
+		t.Errorf("unexpected audit record: %+v", record)
+	}
+}
+
+func TestRunCommand(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	cache := mock.NewMockCache(ctrl)
+	cache.EXPECT().GetHashMap(gomock.Any(), "docs_bot:channel_config:T123:C123").Return(true, map[string]string{
+		"commands": "help",
+	}, nil).AnyTimes()
+
+	var replies []internal.SlackPayload
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var message internal.SlackPayload
+		if err := json.NewDecoder(r.Body).Decode(&message); err != nil {
+			t.Errorf("the reply is not valid JSON: %v", err)
+		}
+		replies = append(replies, message)
+	}))
+	defer ts.Close()
+
+	slack := &SlackRoute{
+		ctx:      context.Background(),
+		cache:    cache,
+		commands: slackCmds.NewDefaultRegistry(nil, nil),
+	}
+
+	// Answer actions can't run commands disabled in the channel.
+	err := slack.RunCommand(context.Background(), &internal.SlackEvent{TeamID: "T123", ChannelID: "C123", UserID: "U123", Text: "ask --private How do I change order?", ResponseURL: ts.URL})
+	if err != nil {
+		t.Fatalf("RunCommand returned an unexpected error: %v", err)
+	}
+	if len(replies) != 1 || !strings.Contains(replies[0].Blocks[0].Text.Text, "disabled in this channel") {
+		t.Fatalf("unexpected replies: %+v", replies)
+	}
+
+	// The payload of synchronous commands is sent to the response URL.
+	replies = nil
+	err = slack.RunCommand(context.Background(), &internal.SlackEvent{TeamID: "T123", ChannelID: "C123", UserID: "U123", Text: "help", ResponseURL: ts.URL})
+	if err != nil {
+		t.Fatalf("RunCommand returned an unexpected error: %v", err)
+	}
+	if len(replies) != 1 || replies[0].ResponseType != "ephemeral" {
+		t.Errorf("unexpected replies: %+v", replies)
 	}
 }
@@ -5,14 +5,67 @@ package endpoints
 
 import (
 	"context"
-	"fmt"
 
 	"spectrocloud.com/spectromate/internal"
+	"spectrocloud.com/spectromate/slackActions"
+	"spectrocloud.com/spectromate/slackCmds"
 )
 
 type HealthRoute struct {
-	ctx     context.Context
-	Version string
+	ctx          context.Context
+	Version      string
+	cache        internal.Cache
+	backendCheck *internal.CachedCheck
+	workers      *internal.WorkerTracker
+}
+
+const (
+	HealthStatusReady    string = "ready"
+	HealthStatusDegraded string = "degraded"
+	HealthStatusNotReady string = "not_ready"
+	HealthStatusUp       string = "up"
+	HealthStatusDown     string = "down"
+)
+
+// HealthReport is the response of the readiness endpoint.
+type HealthReport struct {
+	Status     string                     `json:"status"`
+	Version    string                     `json:"version"`
+	Components map[string]ComponentHealth `json:"components"`
+	// DegradedAsks is the number of questions answered in a degraded mode since the server started, keyed by the reason.
+	DegradedAsks map[string]int64 `json:"degraded_asks,omitempty"`
+}
+
+// ComponentHealth is the health of a single dependency.
+type ComponentHealth struct {
+	Status    string  `json:"status"`
+	LatencyMs float64 `json:"latency_ms,omitempty"`
+	CheckedAt string  `json:"checked_at,omitempty"`
+	Active    int64   `json:"active,omitempty"`
+	Limit     int64   `json:"limit,omitempty"`
+	Error     string  `json:"error,omitempty"`
+}
+
+// Dependencies are the settings and services shared by the Slack routes.
+// Each route only keeps the dependencies it uses.
+type Dependencies struct {
+	SigningSecret  string
+	MendableAPIKey string
+	Cache          internal.Cache
+	Version        string
+	Conversation   internal.ConversationConfig
+	Scopes         internal.DocsScopeConfig
+	Backends       internal.AnswerBackends
+	RateLimiter    *internal.RateLimiter
+	Workers        *internal.WorkerTracker
+	Commands       *slackCmds.Registry
+	Access         *internal.AccessPolicy
+	Audit          *internal.AuditLogger
+	Redactor       *internal.Redactor
+	// SlackAPI is nil without a bot token.
+	SlackAPI   *internal.SlackAPI
+	Escalation internal.EscalationConfig
+	Confidence internal.ConfidenceGate
 }
 
 type SlackRoute struct {
@@ -22,48 +75,41 @@ type SlackRoute struct {
 	SlackEvent     *internal.SlackEvent
 	cache          internal.Cache
 	Version        string
+	conversation   internal.ConversationConfig
+	scopes         internal.DocsScopeConfig
+	backends       internal.AnswerBackends
+	rateLimiter    *internal.RateLimiter
+	workers        *internal.WorkerTracker
+	commands       *slackCmds.Registry
+	access         *internal.AccessPolicy
+	audit          *internal.AuditLogger
+	redactor       *internal.Redactor
+	escalation     internal.EscalationConfig
+	confidence     internal.ConfidenceGate
 }
 
 type ActionsRoute struct {
 	ctx            context.Context
 	signingSecret  string
 	mendableApiKey string
+	cache          internal.Cache
 	ActionsEvent   *internal.SlackActionEvent
 	Version        string
+	workers        *internal.WorkerTracker
+	access         *internal.AccessPolicy
+	audit          *internal.AuditLogger
+	slackAPI       *internal.SlackAPI
+	runCommand     slackActions.CommandRunner
+	escalation     internal.EscalationConfig
+	backends       internal.AnswerBackends
 }
 
-type SlackCommands int
-
-const (
-	Help SlackCommands = iota
-	Ask
-	PAsk
-)
-
-// String converts a SlackCommands type to a string.
-func (s SlackCommands) String() string {
-	switch s {
-	case Help:
-		return "help"
-	case Ask:
-		return "ask"
-	case PAsk:
-		return "pask"
-	default:
-		return "unknown"
-	}
-}
-
-// SlackCommandsFromString converts a string to a SlackCommands type.
-func SlackCommandsFromString(s string) (SlackCommands, error) {
-	switch s {
-	case "help":
-		return Help, nil
-	case "ask":
-		return Ask, nil
-	case "pask":
-		return PAsk, nil
-	default:
-		return -1, fmt.Errorf("unknown command: %s", s)
-	}
+type EventsRoute struct {
+	ctx           context.Context
+	signingSecret string
+	cache         internal.Cache
+	Version       string
+	slackAPI      *internal.SlackAPI
+	escalation    internal.EscalationConfig
+	workers       *internal.WorkerTracker
 }
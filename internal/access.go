@@ -0,0 +1,118 @@
+// Copyright (c) Spectro Cloud
+// SPDX-License-Identifier: Apache-2.0
+
+package internal
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/rs/zerolog/log"
+)
+
+// AccessList is a set of Slack IDs for each kind of subject.
+// An empty set doesn't match any ID.
+type AccessList struct {
+	Users       map[string]bool
+	Channels    map[string]bool
+	Teams       map[string]bool
+	Enterprises map[string]bool
+}
+
+// ParseIDList parses a comma-separated list of Slack IDs.
+func ParseIDList(s string) map[string]bool {
+	ids := map[string]bool{}
+	for _, id := range strings.Split(s, ",") {
+		if id = strings.TrimSpace(id); id != "" {
+			ids[id] = true
+		}
+	}
+	return ids
+}
+
+// AccessSubject identifies who sent a command or action and from where.
+type AccessSubject struct {
+	UserID       string
+	ChannelID    string
+	TeamID       string
+	EnterpriseID string
+}
+
+// SubjectFromEvent returns the access subject of a slash command.
+func SubjectFromEvent(event *SlackEvent) AccessSubject {
+	return AccessSubject{event.UserID, event.ChannelID, event.TeamID, event.EnterpriseID}
+}
+
+// SubjectFromAction returns the access subject of an interactive action.
+func SubjectFromAction(event *SlackActionEvent) AccessSubject {
+	subject := AccessSubject{
+		UserID:    event.User.ID,
+		ChannelID: event.Channel.ID,
+		TeamID:    event.Team.ID,
+	}
+	if event.Enterprise != nil {
+		subject.EnterpriseID = event.Enterprise.ID
+	}
+	return subject
+}
+
+// AccessPolicy decides who can use SpectroMate.
+// A subject is denied if any of its IDs is in the deny list. Otherwise, for every kind of subject
+// with a non-empty allow list, the ID must be in the allow list. Everyone is allowed by default.
+type AccessPolicy struct {
+	allow AccessList
+	deny  AccessList
+}
+
+// NewAccessPolicy returns a new AccessPolicy.
+func NewAccessPolicy(allow, deny AccessList) *AccessPolicy {
+	return &AccessPolicy{allow, deny}
+}
+
+// Check returns true if the subject is allowed. The reason explains a denial.
+func (p *AccessPolicy) Check(subject AccessSubject) (bool, string) {
+	if p == nil {
+		return true, ""
+	}
+
+	checks := []struct {
+		kind  string
+		id    string
+		allow map[string]bool
+		deny  map[string]bool
+	}{
+		{"user", subject.UserID, p.allow.Users, p.deny.Users},
+		{"channel", subject.ChannelID, p.allow.Channels, p.deny.Channels},
+		{"team", subject.TeamID, p.allow.Teams, p.deny.Teams},
+		{"enterprise", subject.EnterpriseID, p.allow.Enterprises, p.deny.Enterprises},
+	}
+
+	for _, c := range checks {
+		if c.id != "" && c.deny[c.id] {
+			return false, fmt.Sprintf("%s %s is in the deny list", c.kind, c.id)
+		}
+	}
+
+	for _, c := range checks {
+		if len(c.allow) > 0 && !c.allow[c.id] {
+			return false, fmt.Sprintf("%s %q is not in the allow list", c.kind, c.id)
+		}
+	}
+
+	return true, ""
+}
+
+// LogAccessDenied writes an audit log entry for a denied command or action.
+func LogAccessDenied(subject AccessSubject, kind, name, reason string) {
+	log.Warn().
+		Bool("audit", true).
+		Str("event", "access_denied").
+		Str("kind", kind).
+		Str("name", name).
+		Str("user_id", subject.UserID).
+		Str("channel_id", subject.ChannelID).
+		Str("team_id", subject.TeamID).
+		Str("enterprise_id", subject.EnterpriseID).
+		Str("reason", reason).
+		Msg("Access denied.")
+}
@@ -0,0 +1,69 @@
+// Copyright (c) Spectro Cloud
+// SPDX-License-Identifier: Apache-2.0
+
+package internal
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseIDList(t *testing.T) {
+	got := ParseIDList(" U123, ,C456,U123 ")
+	want := map[string]bool{"U123": true, "C456": true}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("ParseIDList returned an unexpected result: got %v, want %v", got, want)
+	}
+
+	if len(ParseIDList("")) != 0 {
+		t.Errorf("expected an empty list for an empty string")
+	}
+}
+
+func TestAccessPolicyCheck(t *testing.T) {
+	subject := AccessSubject{UserID: "U123", ChannelID: "C123", TeamID: "T123"}
+
+	tests := []struct {
+		name    string
+		policy  *AccessPolicy
+		allowed bool
+	}{
+		{"nil policy", nil, true},
+		{"empty policy", NewAccessPolicy(AccessList{}, AccessList{}), true},
+		{"denied user", NewAccessPolicy(AccessList{}, AccessList{Users: ParseIDList("U123")}), false},
+		{"denied team", NewAccessPolicy(AccessList{}, AccessList{Teams: ParseIDList("T123")}), false},
+		{"deny wins over allow", NewAccessPolicy(AccessList{Users: ParseIDList("U123")}, AccessList{Channels: ParseIDList("C123")}), false},
+		{"allowed channel", NewAccessPolicy(AccessList{Channels: ParseIDList("C123,C456")}, AccessList{}), true},
+		{"channel not in allow list", NewAccessPolicy(AccessList{Channels: ParseIDList("C456")}, AccessList{}), false},
+		{"missing enterprise with an enterprise allow list", NewAccessPolicy(AccessList{Enterprises: ParseIDList("E123")}, AccessList{}), false},
+		{"other user denied", NewAccessPolicy(AccessList{}, AccessList{Users: ParseIDList("U456")}), true},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			allowed, reason := tc.policy.Check(subject)
+			if allowed != tc.allowed {
+				t.Errorf("unexpected result: got %t, want %t (%s)", allowed, tc.allowed, reason)
+			}
+			if !allowed && reason == "" {
+				t.Errorf("expected a reason for the denial")
+			}
+		})
+	}
+}
+
+func TestSubjectFromAction(t *testing.T) {
+	event := &SlackActionEvent{}
+	event.User.ID = "U123"
+	event.Channel.ID = "C123"
+	event.Team.ID = "T123"
+
+	if got := SubjectFromAction(event); got.EnterpriseID != "" || got.UserID != "U123" {
+		t.Errorf("SubjectFromAction returned an unexpected subject: %+v", got)
+	}
+
+	event.Enterprise = &Enterprise{ID: "E123"}
+	if got := SubjectFromAction(event); got.EnterpriseID != "E123" {
+		t.Errorf("SubjectFromAction returned an unexpected enterprise: got %s, want E123", got.EnterpriseID)
+	}
+}
@@ -0,0 +1,261 @@
+// Copyright (c) Spectro Cloud
+// SPDX-License-Identifier: Apache-2.0
+
+package internal
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"spectrocloud.com/spectromate/internal/blockkit"
+)
+
+// Block IDs of the answer message. Actions look up the content of the answer by block ID,
+// so the layout of the message can change without breaking the rating and other answer actions.
+const (
+	AnswerBlockIDHeader       string = "answer_header"
+	AnswerBlockIDQuestion     string = "answer_question"
+	AnswerBlockIDSources      string = "answer_sources"
+	AnswerBlockIDConfidence   string = "answer_confidence"
+	AnswerBlockIDRating       string = "answer_rating"
+	AnswerBlockIDRatingCounts string = "answer_rating_counts"
+	AnswerBlockIDActions      string = "answer_actions"
+	AnswerBlockIDMoreActions  string = "answer_more_actions"
+	AnswerBlockIDByline       string = "answer_byline"
+	AnswerBlockIDNote         string = "answer_note"
+	// answerBlockIDLowConfidencePrefix is followed by the low confidence mode of the answer.
+	answerBlockIDLowConfidencePrefix string = "answer_low_confidence:"
+	// answerBlockIDTextPrefix is followed by the index of the section, because long answers span several sections.
+	answerBlockIDTextPrefix string = "answer_text:"
+)
+
+// answerQuestionPrefix is displayed before the question of an answer.
+const answerQuestionPrefix string = ":question: "
+
+// AnswerTextBlockID returns the block ID of the answer section with the index.
+func AnswerTextBlockID(index int) string {
+	return answerBlockIDTextPrefix + strconv.Itoa(index)
+}
+
+// AnswerState is the content of an answer message, stored by message ID so the answer actions
+// can rebuild the message without parsing it.
+type AnswerState struct {
+	MessageID  string
+	Title      string
+	Question   string
+	Answer     string
+	Links      string
+	Confidence string
+	Private    bool
+	UserID     string
+	ChannelID  string
+	CreatedAt  time.Time
+	// Product and Version are the docs scope of the question, if any.
+	Product string
+	Version string
+	// DocsURL is the page of the docs opened by the "Open in docs" button.
+	DocsURL string
+	// ConversationID is the conversation of the answer. Follow-up questions and regenerated answers continue it.
+	ConversationID string
+	// Backend is the name of the answer backend that answered the question. Ratings are sent to the same backend,
+	// because the message ID is only known by that backend.
+	Backend string
+	// LowConfidence is how the answer is displayed if its confidence level is below the threshold, or empty.
+	LowConfidence LowConfidenceMode
+	// Escalate adds the button escalating the question to the support channel. It isn't stored, because it depends
+	// on the settings and the ratings of the answer.
+	Escalate bool
+}
+
+// NotFound returns true if the backend couldn't answer the question.
+func (s AnswerState) NotFound() bool {
+	return strings.Contains(s.Answer, DefaultNotFoundResponse)
+}
+
+// Uncertain returns true if the backend couldn't answer the question, or answered with a low confidence level.
+func (s AnswerState) Uncertain() bool {
+	return s.NotFound() || s.LowConfidence != ""
+}
+
+// FormatAnswerQuestion returns the question as displayed in the answer.
+func FormatAnswerQuestion(question string) string {
+	return answerQuestionPrefix + question
+}
+
+// Query returns the question of the answer without the formatting added by FormatAnswerQuestion.
+func (s AnswerState) Query() string {
+	return strings.TrimSpace(strings.TrimPrefix(s.Question, answerQuestionPrefix))
+}
+
+// answerStateKey returns the cache key of the answer state.
+func answerStateKey(messageID string) string {
+	return fmt.Sprintf("docs_bot:answer:%s", messageID)
+}
+
+// StoreAnswerState stores the answer state. The state expires after the TTL.
+func StoreAnswerState(ctx context.Context, cache Cache, state AnswerState, ttl time.Duration) error {
+	if state.MessageID == "" {
+		return fmt.Errorf("the answer has no message ID")
+	}
+
+	item := map[string]interface{}{
+		"message_id":      state.MessageID,
+		"title":           state.Title,
+		"question":        state.Question,
+		"answer":          state.Answer,
+		"links":           state.Links,
+		"confidence":      state.Confidence,
+		"private":         strconv.FormatBool(state.Private),
+		"user_id":         state.UserID,
+		"channel_id":      state.ChannelID,
+		"created_at":      state.CreatedAt.UTC().Format(time.RFC3339),
+		"product":         state.Product,
+		"version":         state.Version,
+		"docs_url":        state.DocsURL,
+		"low_confidence":  string(state.LowConfidence),
+		"backend":         state.Backend,
+		"conversation_id": state.ConversationID,
+	}
+
+	key := answerStateKey(state.MessageID)
+	if err := cache.StoreHashMap(ctx, key, item); err != nil {
+		return err
+	}
+
+	return cache.ExpireKey(ctx, key, ttl)
+}
+
+// GetAnswerState returns the state of the answer with the message ID.
+// The returned bool is false if the state doesn't exist or expired.
+func GetAnswerState(ctx context.Context, cache Cache, messageID string) (AnswerState, bool, error) {
+	if cache == nil || messageID == "" {
+		return AnswerState{}, false, nil
+	}
+
+	found, values, err := cache.GetHashMap(ctx, answerStateKey(messageID))
+	if err != nil || !found {
+		return AnswerState{}, false, err
+	}
+
+	state := AnswerState{
+		MessageID:  values["message_id"],
+		Title:      values["title"],
+		Question:   values["question"],
+		Answer:     values["answer"],
+		Links:      values["links"],
+		Confidence: values["confidence"],
+		Private:    values["private"] == "true",
+		UserID:     values["user_id"],
+		ChannelID:  values["channel_id"],
+		Product:    values["product"],
+		Version:    values["version"],
+		DocsURL:    values["docs_url"],
+		Backend:    values["backend"],
+
+		ConversationID: values["conversation_id"],
+		// The mode is stored so the answer is displayed the same way after the threshold changes.
+		LowConfidence: LowConfidenceMode(values["low_confidence"]),
+	}
+
+	if createdAt, err := time.Parse(time.RFC3339, values["created_at"]); err == nil {
+		state.CreatedAt = createdAt
+	}
+
+	return state, true, nil
+}
+
+// AnswerStateFromBlocks rebuilds the answer state from the block IDs of the answer message.
+// It's used when the state isn't in the cache anymore. The returned bool is false if the message
+// has no question or answer block, such as messages sent before the blocks had IDs.
+// The docs scope, backend and conversation of the question aren't part of the message, so they're empty.
+func AnswerStateFromBlocks(messageID string, blocks []SlackActionsBlock) (AnswerState, bool) {
+	state := AnswerState{MessageID: messageID}
+
+	var answer []string
+	for _, block := range blocks {
+		switch {
+		case block.BlockID == AnswerBlockIDHeader:
+			state.Title = block.Text.Text
+		case block.BlockID == AnswerBlockIDQuestion:
+			state.Question = block.Text.Text
+		case block.BlockID == AnswerBlockIDSources:
+			state.Links = block.Text.Text
+		case strings.HasPrefix(block.BlockID, answerBlockIDTextPrefix):
+			answer = append(answer, block.Text.Text)
+		case strings.HasPrefix(block.BlockID, answerBlockIDLowConfidencePrefix):
+			state.LowConfidence = LowConfidenceMode(strings.TrimPrefix(block.BlockID, answerBlockIDLowConfidencePrefix))
+		case block.BlockID == AnswerBlockIDMoreActions:
+			for _, element := range block.Elements {
+				switch element.ActionID {
+				case ActionsAskShareID:
+					// Only private answers can be shared.
+					state.Private = true
+				case ActionsAskOpenDocsID:
+					state.DocsURL = element.URL
+				}
+			}
+		}
+	}
+	state.Answer = strings.Join(answer, "\n\n")
+
+	// Withheld answers only have the sources in the message.
+	return state, state.Question != "" && (state.Answer != "" || state.LowConfidence == LowConfidenceWithhold)
+}
+
+// ShowRestValue returns the value of the button showing the rest of the answer with the message ID,
+// starting at the section with the index.
+func ShowRestValue(messageID string, index int) string {
+	return messageID + ":" + strconv.Itoa(index)
+}
+
+// ParseShowRestValue returns the message ID and section index of the value of the button showing the rest of an answer.
+func ParseShowRestValue(value string) (string, int, error) {
+	messageID, raw, found := strings.Cut(value, ":")
+	index, err := strconv.Atoi(raw)
+	if !found || messageID == "" || err != nil || index < 0 {
+		return "", 0, fmt.Errorf("invalid show rest value %q", value)
+	}
+	return messageID, index, nil
+}
+
+// AnswerByline returns the context block displayed below the question, such as the user who shared the answer.
+func AnswerByline(text string) blockkit.Block {
+	return blockkit.Context(blockkit.Markdown(text)).WithBlockID(AnswerBlockIDByline)
+}
+
+// AnswerBylineFromBlocks returns the text of the byline of the answer message, or an empty string if it has none.
+func AnswerBylineFromBlocks(blocks []SlackActionsBlock) string {
+	for _, block := range blocks {
+		if block.BlockID == AnswerBlockIDByline && len(block.Elements) > 0 {
+			return block.Elements[0].Text.Text
+		}
+	}
+	return ""
+}
+
+// AnswerMoreActions returns the buttons to share a private answer in the channel, ask a follow-up question,
+// ask the question again and open the docs of the answer. The button escalating the question is added if the
+// answer offers escalation.
+func AnswerMoreActions(state AnswerState) blockkit.Block {
+	docsURL := state.DocsURL
+	if docsURL == "" || len(docsURL) > blockkit.MaxURLLength {
+		docsURL = PublicDocumentationURL
+	}
+
+	actions := blockkit.Actions().WithBlockID(AnswerBlockIDMoreActions)
+	if state.Private {
+		actions.Elements = append(actions.Elements, blockkit.Button("Share to channel", ActionsAskShareID, state.MessageID).WithStyle(blockkit.StylePrimary))
+	}
+	actions.Elements = append(actions.Elements,
+		blockkit.Button("Ask follow-up", ActionsAskFollowUpID, state.MessageID),
+		blockkit.Button("Regenerate", ActionsAskRegenerateID, state.MessageID),
+		blockkit.Button("Open in docs", ActionsAskOpenDocsID, state.MessageID).WithURL(docsURL),
+	)
+	if state.Escalate {
+		actions.Elements = append(actions.Elements, blockkit.Button("Escalate", ActionsAskEscalateID, state.MessageID).WithStyle(blockkit.StyleDanger))
+	}
+	return actions
+}
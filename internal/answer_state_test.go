@@ -0,0 +1,157 @@
+// Copyright (c) Spectro Cloud
+// SPDX-License-Identifier: Apache-2.0
+
+package internal
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"spectrocloud.com/spectromate/internal/blockkit"
+	"spectrocloud.com/spectromate/mock"
+)
+
+func TestAnswerStateRoundTrip(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	cache := mock.NewMockCache(ctrl)
+	ctx := context.Background()
+
+	state := AnswerState{
+		MessageID:  "123",
+		Title:      "Docs Answer",
+		Question:   ":question: How do I install Palette?",
+		Answer:     "Use the *Palette CLI*.",
+		Links:      "https://docs.spectrocloud.com",
+		Confidence: "87",
+		Private:    true,
+		UserID:     "U123",
+		ChannelID:  "C123",
+		CreatedAt:  time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC),
+		Product:    "palette",
+		Version:    "4.2",
+		DocsURL:    "https://docs.spectrocloud.com/clusters",
+		Backend:    "edge",
+
+		ConversationID: "456",
+
+		LowConfidence: LowConfidenceCaveat,
+	}
+
+	var stored map[string]interface{}
+	cache.EXPECT().StoreHashMap(ctx, "docs_bot:answer:123", gomock.Any()).DoAndReturn(
+		func(_ context.Context, _ string, item map[string]interface{}) error {
+			stored = item
+			return nil
+		})
+	cache.EXPECT().ExpireKey(ctx, "docs_bot:answer:123", DefaultAnswerStateExpirationPeriod).Return(nil)
+	require.NoError(t, StoreAnswerState(ctx, cache, state, DefaultAnswerStateExpirationPeriod))
+
+	values := map[string]string{}
+	for k, v := range stored {
+		values[k] = v.(string)
+	}
+	cache.EXPECT().GetHashMap(ctx, "docs_bot:answer:123").Return(true, values, nil)
+
+	loaded, found, err := GetAnswerState(ctx, cache, "123")
+	require.NoError(t, err)
+	assert.True(t, found)
+	assert.Equal(t, state, loaded)
+
+	cache.EXPECT().GetHashMap(ctx, "docs_bot:answer:456").Return(false, nil, nil)
+	_, found, err = GetAnswerState(ctx, cache, "456")
+	require.NoError(t, err)
+	assert.False(t, found)
+
+	assert.Error(t, StoreAnswerState(ctx, cache, AnswerState{}, DefaultAnswerStateExpirationPeriod))
+}
+
+func TestAnswerStateFromBlocks(t *testing.T) {
+	text := func(id, text string) SlackActionsBlock {
+		return SlackActionsBlock{Type: "section", BlockID: id, Text: ActionsText{Type: "mrkdwn", Text: text}}
+	}
+
+	// The order and number of blocks don't matter, only the block IDs.
+	blocks := []SlackActionsBlock{
+		text(AnswerBlockIDSources, "https://docs.spectrocloud.com"),
+		{Type: "divider", BlockID: "a1"},
+		{Type: "header", BlockID: AnswerBlockIDHeader, Text: ActionsText{Type: "plain_text", Text: "Docs Answer · Palette"}},
+		text(AnswerBlockIDQuestion, ":question: How?"),
+		text(AnswerTextBlockID(0), "First part."),
+		text(AnswerTextBlockID(1), "Second part."),
+		text(AnswerBlockIDNote, "_The answer continues in the next message._"),
+		{Type: "context", BlockID: AnswerBlockIDByline, Elements: []Action{{Type: "mrkdwn", Text: ActionsText{Text: "Shared by <@U123>."}}}},
+		{Type: "actions", BlockID: AnswerBlockIDMoreActions, Elements: []Action{
+			{ActionID: ActionsAskShareID, Type: "button", Value: "123"},
+			{ActionID: ActionsAskOpenDocsID, Type: "button", Value: "123", URL: "https://docs.spectrocloud.com/clusters"},
+		}},
+	}
+
+	state, found := AnswerStateFromBlocks("123", blocks)
+	assert.True(t, found)
+	assert.Equal(t, AnswerState{
+		MessageID: "123",
+		Title:     "Docs Answer · Palette",
+		Question:  ":question: How?",
+		Answer:    "First part.\n\nSecond part.",
+		Links:     "https://docs.spectrocloud.com",
+		Private:   true,
+		DocsURL:   "https://docs.spectrocloud.com/clusters",
+	}, state)
+	assert.Equal(t, "How?", state.Query())
+	assert.Equal(t, "Shared by <@U123>.", AnswerBylineFromBlocks(blocks))
+	assert.Empty(t, AnswerBylineFromBlocks(blocks[:3]))
+
+	// Messages sent before the blocks had IDs can't be read.
+	_, found = AnswerStateFromBlocks("123", []SlackActionsBlock{{Type: "section", BlockID: "x1", Text: ActionsText{Text: "text"}}})
+	assert.False(t, found)
+}
+
+func TestAnswerMoreActions(t *testing.T) {
+	actionIDs := func(block blockkit.Block) []string {
+		var ids []string
+		for _, element := range block.(*blockkit.ActionsBlock).Elements {
+			ids = append(ids, element.(*blockkit.ButtonElement).ActionID)
+		}
+		return ids
+	}
+
+	private := AnswerMoreActions(AnswerState{MessageID: "123", Private: true, DocsURL: "https://docs.spectrocloud.com/clusters"})
+	require.NoError(t, private.Validate())
+	assert.Equal(t, []string{ActionsAskShareID, ActionsAskFollowUpID, ActionsAskRegenerateID, ActionsAskOpenDocsID}, actionIDs(private))
+	assert.Equal(t, "https://docs.spectrocloud.com/clusters", private.(*blockkit.ActionsBlock).Elements[3].(*blockkit.ButtonElement).URL)
+
+	// Public answers can't be shared again, and the docs site is opened if the answer has no sources.
+	public := AnswerMoreActions(AnswerState{MessageID: "123"})
+	assert.Equal(t, []string{ActionsAskFollowUpID, ActionsAskRegenerateID, ActionsAskOpenDocsID}, actionIDs(public))
+	assert.Equal(t, PublicDocumentationURL, public.(*blockkit.ActionsBlock).Elements[2].(*blockkit.ButtonElement).URL)
+}
+
+func TestShowRestValue(t *testing.T) {
+	messageID, index, err := ParseShowRestValue(ShowRestValue("123", 48))
+	require.NoError(t, err)
+	assert.Equal(t, "123", messageID)
+	assert.Equal(t, 48, index)
+
+	for _, invalid := range []string{"", "123", "123:", ":48", "123:-1", "123:abc"} {
+		_, _, err = ParseShowRestValue(invalid)
+		assert.Error(t, err, "Expected %q to be invalid", invalid)
+	}
+}
+
+func TestAnswerMoreActionsEscalate(t *testing.T) {
+	state := AnswerState{MessageID: "123", Answer: DefaultNotFoundResponse, Escalate: true}
+	assert.True(t, state.NotFound())
+
+	block := AnswerMoreActions(state).(*blockkit.ActionsBlock)
+	last := block.Elements[len(block.Elements)-1].(*blockkit.ButtonElement)
+	assert.Equal(t, ActionsAskEscalateID, last.ActionID)
+	assert.Equal(t, "123", last.Value)
+
+	assert.False(t, AnswerState{Answer: "Use the Palette CLI."}.NotFound())
+}
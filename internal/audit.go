@@ -0,0 +1,262 @@
+// Copyright (c) Spectro Cloud
+// SPDX-License-Identifier: Apache-2.0
+
+package internal
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	// AuditOutcomeSuccess is recorded when a command or action completed.
+	AuditOutcomeSuccess string = "success"
+	// AuditOutcomeError is recorded when a command or action failed.
+	AuditOutcomeError string = "error"
+	// AuditOutcomeDenied is recorded when the access policy denied a command or action.
+	AuditOutcomeDenied string = "denied"
+	// AuditOutcomeDisabled is recorded when a command is disabled in the channel.
+	AuditOutcomeDisabled string = "disabled"
+	// AuditOutcomeRateLimited is recorded when a command was rejected by the rate limiter.
+	AuditOutcomeRateLimited string = "rate_limited"
+)
+
+// AuditTextMode controls how the text of a command is written to the audit log.
+type AuditTextMode string
+
+const (
+	// AuditTextFull writes the text as typed by the user.
+	AuditTextFull AuditTextMode = "full"
+	// AuditTextRedact writes the text with the sensitive information replaced by the redactor.
+	AuditTextRedact AuditTextMode = "redact"
+	// AuditTextHash writes a SHA-256 hash of the text so identical questions can be correlated without storing them.
+	AuditTextHash AuditTextMode = "hash"
+	// AuditTextOmit doesn't write the text.
+	AuditTextOmit AuditTextMode = "omit"
+)
+
+// ParseAuditTextMode returns the text mode matching the value.
+func ParseAuditTextMode(value string) (AuditTextMode, error) {
+	switch mode := AuditTextMode(strings.ToLower(strings.TrimSpace(value))); mode {
+	case AuditTextFull, AuditTextRedact, AuditTextHash, AuditTextOmit:
+		return mode, nil
+	default:
+		return "", fmt.Errorf("invalid audit text mode %q: must be %s, %s, %s or %s", value, AuditTextFull, AuditTextRedact, AuditTextHash, AuditTextOmit)
+	}
+}
+
+// AuditRecord describes a single command or action.
+type AuditRecord struct {
+	Timestamp    time.Time `json:"timestamp"`
+	Kind         string    `json:"kind"`
+	Name         string    `json:"name"`
+	TeamID       string    `json:"team_id"`
+	EnterpriseID string    `json:"enterprise_id,omitempty"`
+	ChannelID    string    `json:"channel_id"`
+	UserID       string    `json:"user_id"`
+	Text         string    `json:"text,omitempty"`
+	Value        string    `json:"value,omitempty"`
+	Outcome      string    `json:"outcome"`
+	LatencyMs    int64     `json:"latency_ms"`
+	Error        string    `json:"error,omitempty"`
+}
+
+// NewCommandAuditRecord returns the audit record of a slash command.
+func NewCommandAuditRecord(event *SlackEvent, name, outcome string, start time.Time, err error) AuditRecord {
+	return newAuditRecord("command", name, SubjectFromEvent(event), event.Text, "", outcome, start, err)
+}
+
+// NewActionAuditRecord returns the audit record of an interactive action.
+// The value of the action, such as the ID of the rated answer, is included.
+func NewActionAuditRecord(event *SlackActionEvent, outcome string, start time.Time, err error) AuditRecord {
+	var name, value string
+	if len(event.Actions) > 0 {
+		name, value = event.Actions[0].ActionID, event.Actions[0].Value
+	} else {
+		// Dialog submissions have no actions and are recorded by the callback ID of the dialog.
+		name = event.View.CallbackID
+	}
+	return newAuditRecord("action", name, SubjectFromAction(event), "", value, outcome, start, err)
+}
+
+func newAuditRecord(kind, name string, subject AccessSubject, text, value, outcome string, start time.Time, err error) AuditRecord {
+	record := AuditRecord{
+		Timestamp:    start.UTC(),
+		Kind:         kind,
+		Name:         name,
+		TeamID:       subject.TeamID,
+		EnterpriseID: subject.EnterpriseID,
+		ChannelID:    subject.ChannelID,
+		UserID:       subject.UserID,
+		Text:         text,
+		Value:        value,
+		Outcome:      outcome,
+		LatencyMs:    time.Since(start).Milliseconds(),
+	}
+	if err != nil {
+		record.Error = err.Error()
+	}
+	return record
+}
+
+// AuditSink stores audit records. Sinks are append-only and must be safe for concurrent use.
+type AuditSink interface {
+	Write(ctx context.Context, record AuditRecord) error
+}
+
+// WriterSink writes audit records as JSON lines.
+type WriterSink struct {
+	mu sync.Mutex
+	w  io.Writer
+}
+
+// NewWriterSink returns a new WriterSink.
+func NewWriterSink(w io.Writer) *WriterSink {
+	return &WriterSink{w: w}
+}
+
+// Write writes the record as a single JSON line.
+func (s *WriterSink) Write(ctx context.Context, record AuditRecord) error {
+	line, err := json.Marshal(record)
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	_, err = s.w.Write(append(line, '\n'))
+	return err
+}
+
+// RedisStreamSink appends audit records to a Redis stream.
+type RedisStreamSink struct {
+	cache  Cache
+	stream string
+	maxLen int64
+}
+
+// NewRedisStreamSink returns a new RedisStreamSink. The stream is trimmed to approximately maxLen entries.
+func NewRedisStreamSink(cache Cache, stream string, maxLen int64) *RedisStreamSink {
+	return &RedisStreamSink{cache, stream, maxLen}
+}
+
+// Write appends the record to the stream. Every field of the record is a field of the stream entry.
+func (s *RedisStreamSink) Write(ctx context.Context, record AuditRecord) error {
+	values := map[string]interface{}{
+		"timestamp":  record.Timestamp.Format(time.RFC3339Nano),
+		"kind":       record.Kind,
+		"name":       record.Name,
+		"team_id":    record.TeamID,
+		"channel_id": record.ChannelID,
+		"user_id":    record.UserID,
+		"outcome":    record.Outcome,
+		"latency_ms": strconv.FormatInt(record.LatencyMs, 10),
+	}
+	for key, value := range map[string]string{
+		"enterprise_id": record.EnterpriseID,
+		"text":          record.Text,
+		"value":         record.Value,
+		"error":         record.Error,
+	} {
+		if value != "" {
+			values[key] = value
+		}
+	}
+
+	return s.cache.AppendStream(ctx, s.stream, s.maxLen, values)
+}
+
+// NewAuditSink returns the sink described by the configuration value.
+// Supported values are "stdout", "file:<path>" and "redis" or "redis:<stream>".
+// Nil is returned if the value is empty or "none", which disables the audit log.
+func NewAuditSink(value string, cache Cache) (AuditSink, error) {
+	kind, arg, _ := strings.Cut(strings.TrimSpace(value), ":")
+
+	switch strings.ToLower(kind) {
+	case "", "none":
+		return nil, nil
+	case "stdout":
+		return NewWriterSink(os.Stdout), nil
+	case "file":
+		if arg == "" {
+			return nil, fmt.Errorf("invalid audit sink %q: the file path is missing", value)
+		}
+		file, err := os.OpenFile(arg, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+		if err != nil {
+			return nil, fmt.Errorf("unable to open the audit log file: %w", err)
+		}
+		return NewWriterSink(file), nil
+	case "redis":
+		if arg == "" {
+			arg = DefaultAuditStream
+		}
+		return NewRedisStreamSink(cache, arg, DefaultAuditStreamMaxLen), nil
+	default:
+		return nil, fmt.Errorf("invalid audit sink %q: must be stdout, file:<path> or redis[:<stream>]", value)
+	}
+}
+
+// AuditLogger writes audit records to a sink after applying the text mode.
+// A nil AuditLogger doesn't record anything.
+type AuditLogger struct {
+	sink     AuditSink
+	textMode AuditTextMode
+	redactor *Redactor
+}
+
+// NewAuditLogger returns a new AuditLogger. Nil is returned if the sink is nil.
+// The redactor is used by the redact text mode.
+func NewAuditLogger(sink AuditSink, textMode AuditTextMode, redactor *Redactor) *AuditLogger {
+	if sink == nil {
+		return nil
+	}
+	return &AuditLogger{sink, textMode, redactor}
+}
+
+// Record writes the record to the sink. Failures are logged but never interrupt the command.
+func (a *AuditLogger) Record(ctx context.Context, record AuditRecord) {
+	if a == nil {
+		return
+	}
+
+	record.Text = a.redactText(record.Text)
+
+	ctx, cancel := context.WithTimeout(ctx, DefaultAuditWriteTimeout)
+	defer cancel()
+
+	if err := a.sink.Write(ctx, record); err != nil {
+		log.Warn().Err(err).Str("kind", record.Kind).Str("name", record.Name).Msg("Unable to write the audit record.")
+	}
+}
+
+// redactText applies the text mode to the text of a command.
+func (a *AuditLogger) redactText(text string) string {
+	if text == "" {
+		return text
+	}
+
+	switch a.textMode {
+	case AuditTextFull:
+		return text
+	case AuditTextRedact:
+		redacted, _ := a.redactor.Redact(text)
+		return redacted
+	case AuditTextHash:
+		sum := sha256.Sum256([]byte(text))
+		return "sha256:" + hex.EncodeToString(sum[:])
+	default:
+		return ""
+	}
+}
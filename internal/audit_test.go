@@ -0,0 +1,152 @@
+// Copyright (c) Spectro Cloud
+// SPDX-License-Identifier: Apache-2.0
+
+package internal
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"spectrocloud.com/spectromate/mock"
+)
+
+func TestAuditLoggerTextMode(t *testing.T) {
+	event := &SlackEvent{TeamID: "T123", ChannelID: "C123", UserID: "U123", Text: "ask How do I change order? Ping me at jane@example.com"}
+	redactor, err := NewRedactor(nil, nil)
+	if err != nil {
+		t.Fatalf("NewRedactor returned an unexpected error: %v", err)
+	}
+
+	tests := []struct {
+		mode     AuditTextMode
+		expected string
+	}{
+		{AuditTextFull, "ask How do I change order? Ping me at jane@example.com"},
+		{AuditTextRedact, "ask How do I change order? Ping me at [REDACTED:email]"},
+		{AuditTextHash, "sha256:"},
+		{AuditTextOmit, ""},
+	}
+
+	for _, tc := range tests {
+		t.Run(string(tc.mode), func(t *testing.T) {
+			var buf bytes.Buffer
+			logger := NewAuditLogger(NewWriterSink(&buf), tc.mode, redactor)
+			logger.Record(context.Background(), NewCommandAuditRecord(event, "ask", AuditOutcomeSuccess, time.Now(), nil))
+
+			var record AuditRecord
+			if err := json.Unmarshal(buf.Bytes(), &record); err != nil {
+				t.Fatalf("the audit record is not valid JSON: %v", err)
+			}
+
+			if !strings.HasPrefix(record.Text, tc.expected) || (tc.expected == "" && record.Text != "") {
+				t.Errorf("unexpected text: got %q, want %q", record.Text, tc.expected)
+			}
+			if record.UserID != "U123" || record.Name != "ask" || record.Kind != "command" || record.Outcome != AuditOutcomeSuccess {
+				t.Errorf("unexpected audit record: %+v", record)
+			}
+		})
+	}
+}
+
+func TestWriterSinkJSONLines(t *testing.T) {
+	var buf bytes.Buffer
+	sink := NewWriterSink(&buf)
+
+	event := &SlackActionEvent{Actions: []Action{{ActionID: "rate", Value: "42"}}}
+	event.User.ID = "U123"
+
+	for i := 0; i < 2; i++ {
+		if err := sink.Write(context.Background(), NewActionAuditRecord(event, AuditOutcomeError, time.Now(), errors.New("boom"))); err != nil {
+			t.Fatalf("Write returned an unexpected error: %v", err)
+		}
+	}
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("expected 2 lines, got %d", len(lines))
+	}
+
+	var record AuditRecord
+	if err := json.Unmarshal([]byte(lines[1]), &record); err != nil {
+		t.Fatalf("the audit record is not valid JSON: %v", err)
+	}
+	if record.Kind != "action" || record.Name != "rate" || record.Value != "42" || record.Error != "boom" {
+		t.Errorf("unexpected audit record: %+v", record)
+	}
+}
+
+func TestRedisStreamSink(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	cache := mock.NewMockCache(ctrl)
+	cache.EXPECT().AppendStream(gomock.Any(), "audit", int64(10), gomock.Any()).DoAndReturn(
+		func(ctx context.Context, stream string, maxLen int64, values map[string]interface{}) error {
+			if values["user_id"] != "U123" || values["outcome"] != AuditOutcomeDenied {
+				t.Errorf("unexpected stream values: %v", values)
+			}
+			if _, ok := values["error"]; ok {
+				t.Errorf("expected empty fields to be omitted: %v", values)
+			}
+			return nil
+		})
+
+	record := NewCommandAuditRecord(&SlackEvent{UserID: "U123"}, "help", AuditOutcomeDenied, time.Now(), nil)
+	if err := NewRedisStreamSink(cache, "audit", 10).Write(context.Background(), record); err != nil {
+		t.Errorf("Write returned an unexpected error: %v", err)
+	}
+}
+
+func TestNewAuditSink(t *testing.T) {
+	tests := []struct {
+		value   string
+		enabled bool
+		wantErr bool
+	}{
+		{"", false, false},
+		{"none", false, false},
+		{"stdout", true, false},
+		{"redis", true, false},
+		{"redis:custom", true, false},
+		{"file:" + filepath.Join(t.TempDir(), "audit.log"), true, false},
+		{"file:", false, true},
+		{"syslog", false, true},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.value, func(t *testing.T) {
+			sink, err := NewAuditSink(tc.value, nil)
+			if (err != nil) != tc.wantErr {
+				t.Fatalf("NewAuditSink(%q) returned an unexpected error: %v", tc.value, err)
+			}
+			if (sink != nil) != tc.enabled {
+				t.Errorf("NewAuditSink(%q) returned an unexpected sink: %v", tc.value, sink)
+			}
+		})
+	}
+}
+
+func TestParseAuditTextMode(t *testing.T) {
+	if mode, err := ParseAuditTextMode(" Hash "); err != nil || mode != AuditTextHash {
+		t.Errorf("ParseAuditTextMode returned an unexpected result: %s, %v", mode, err)
+	}
+	if _, err := ParseAuditTextMode("partial"); err == nil {
+		t.Errorf("expected an error for an unknown mode")
+	}
+}
+
+func TestNilAuditLogger(t *testing.T) {
+	var logger *AuditLogger
+	logger.Record(context.Background(), AuditRecord{})
+
+	if NewAuditLogger(nil, AuditTextFull, nil) != nil {
+		t.Errorf("expected a nil logger without a sink")
+	}
+}
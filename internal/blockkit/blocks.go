@@ -0,0 +1,298 @@
+// Copyright (c) Spectro Cloud
+// SPDX-License-Identifier: Apache-2.0
+
+package blockkit
+
+// Block is a layout block of a message.
+type Block interface {
+	BlockType() string
+	Validate() error
+}
+
+// validateBlockID returns an error if the block ID is too long. Block IDs are optional.
+func validateBlockID(id string) error {
+	return checkLength("block ID", id, MaxBlockIDLength)
+}
+
+// HeaderBlock displays plain text in a large, bold font.
+type HeaderBlock struct {
+	Type    string `json:"type"`
+	Text    *Text  `json:"text"`
+	BlockID string `json:"block_id,omitempty"`
+}
+
+// Header returns a new header block.
+func Header(text string) *HeaderBlock {
+	return &HeaderBlock{Type: "header", Text: PlainText(text)}
+}
+
+// WithBlockID sets the ID of the block, which is sent to the actions endpoint with the message.
+func (b *HeaderBlock) WithBlockID(id string) *HeaderBlock {
+	b.BlockID = id
+	return b
+}
+
+func (b *HeaderBlock) BlockType() string { return b.Type }
+
+// Validate returns an error if the header exceeds a Slack limit.
+func (b *HeaderBlock) Validate() error {
+	if err := validateText("header text", b.Text, MaxHeaderTextLength, true); err != nil {
+		return err
+	}
+	return validateBlockID(b.BlockID)
+}
+
+// DividerBlock is a horizontal line.
+type DividerBlock struct {
+	Type    string `json:"type"`
+	BlockID string `json:"block_id,omitempty"`
+}
+
+// Divider returns a new divider block.
+func Divider() *DividerBlock {
+	return &DividerBlock{Type: "divider"}
+}
+
+func (b *DividerBlock) BlockType() string { return b.Type }
+
+// Validate returns an error if the divider exceeds a Slack limit.
+func (b *DividerBlock) Validate() error {
+	return validateBlockID(b.BlockID)
+}
+
+// SectionBlock displays text, fields in two columns, or both, with an optional accessory element.
+type SectionBlock struct {
+	Type      string  `json:"type"`
+	Text      *Text   `json:"text,omitempty"`
+	Fields    []*Text `json:"fields,omitempty"`
+	Accessory Element `json:"accessory,omitempty"`
+	BlockID   string  `json:"block_id,omitempty"`
+}
+
+// Section returns a new section block with the text. Use Fields for a section without text.
+func Section(text *Text) *SectionBlock {
+	return &SectionBlock{Type: "section", Text: text}
+}
+
+// Fields returns a new section block with the fields and no text.
+func Fields(fields ...*Text) *SectionBlock {
+	return &SectionBlock{Type: "section", Fields: fields}
+}
+
+// WithFields adds fields to the section.
+func (b *SectionBlock) WithFields(fields ...*Text) *SectionBlock {
+	b.Fields = append(b.Fields, fields...)
+	return b
+}
+
+// WithAccessory sets the element displayed next to the text of the section.
+func (b *SectionBlock) WithAccessory(element Element) *SectionBlock {
+	b.Accessory = element
+	return b
+}
+
+// WithBlockID sets the ID of the block, which is sent to the actions endpoint with the message.
+func (b *SectionBlock) WithBlockID(id string) *SectionBlock {
+	b.BlockID = id
+	return b
+}
+
+func (b *SectionBlock) BlockType() string { return b.Type }
+
+// Validate returns an error if the section exceeds a Slack limit.
+func (b *SectionBlock) Validate() error {
+	if b.Text == nil && len(b.Fields) == 0 {
+		return invalid("section has no text or fields")
+	}
+	if b.Text != nil {
+		if err := validateText("section text", b.Text, MaxSectionTextLength, false); err != nil {
+			return err
+		}
+	}
+	if len(b.Fields) > MaxSectionFields {
+		return invalid("section has %d fields, the limit is %d", len(b.Fields), MaxSectionFields)
+	}
+	for _, field := range b.Fields {
+		if err := validateText("section field", field, MaxFieldTextLength, false); err != nil {
+			return err
+		}
+	}
+	if b.Accessory != nil {
+		if err := b.Accessory.Validate(); err != nil {
+			return err
+		}
+	}
+	return validateBlockID(b.BlockID)
+}
+
+// ContextBlock displays small text and images.
+type ContextBlock struct {
+	Type     string    `json:"type"`
+	Elements []Element `json:"elements"`
+	BlockID  string    `json:"block_id,omitempty"`
+}
+
+// Context returns a new context block. The elements must be text objects or image elements.
+func Context(elements ...Element) *ContextBlock {
+	return &ContextBlock{Type: "context", Elements: elements}
+}
+
+// WithBlockID sets the ID of the block, which is sent to the actions endpoint with the message.
+func (b *ContextBlock) WithBlockID(id string) *ContextBlock {
+	b.BlockID = id
+	return b
+}
+
+func (b *ContextBlock) BlockType() string { return b.Type }
+
+// Validate returns an error if the context exceeds a Slack limit or has an element other than text or image.
+func (b *ContextBlock) Validate() error {
+	if len(b.Elements) == 0 || len(b.Elements) > MaxContextElements {
+		return invalid("context has %d elements, it must have between 1 and %d", len(b.Elements), MaxContextElements)
+	}
+	for _, element := range b.Elements {
+		switch element.(type) {
+		case *Text, *ImageElement:
+		default:
+			return invalid("context can't have a %s element", element.ElementType())
+		}
+		if err := element.Validate(); err != nil {
+			return err
+		}
+	}
+	return validateBlockID(b.BlockID)
+}
+
+// ActionsBlock displays interactive elements, such as buttons and menus.
+type ActionsBlock struct {
+	Type     string    `json:"type"`
+	Elements []Element `json:"elements"`
+	BlockID  string    `json:"block_id,omitempty"`
+}
+
+// Actions returns a new actions block.
+func Actions(elements ...Element) *ActionsBlock {
+	return &ActionsBlock{Type: "actions", Elements: elements}
+}
+
+// WithBlockID sets the ID of the block, which is sent to the actions endpoint with the message.
+func (b *ActionsBlock) WithBlockID(id string) *ActionsBlock {
+	b.BlockID = id
+	return b
+}
+
+func (b *ActionsBlock) BlockType() string { return b.Type }
+
+// Validate returns an error if the actions block exceeds a Slack limit or has an element that isn't interactive.
+func (b *ActionsBlock) Validate() error {
+	if len(b.Elements) == 0 || len(b.Elements) > MaxActionElements {
+		return invalid("actions block has %d elements, it must have between 1 and %d", len(b.Elements), MaxActionElements)
+	}
+	for _, element := range b.Elements {
+		switch element.(type) {
+		case *ButtonElement, *OverflowElement, *StaticSelectElement:
+		default:
+			return invalid("actions block can't have a %s element", element.ElementType())
+		}
+		if err := element.Validate(); err != nil {
+			return err
+		}
+	}
+	return validateBlockID(b.BlockID)
+}
+
+// ImageBlock displays a large image.
+type ImageBlock struct {
+	Type     string `json:"type"`
+	ImageURL string `json:"image_url"`
+	AltText  string `json:"alt_text"`
+	Title    *Text  `json:"title,omitempty"`
+	BlockID  string `json:"block_id,omitempty"`
+}
+
+// Image returns a new image block.
+func Image(url, altText string) *ImageBlock {
+	return &ImageBlock{Type: "image", ImageURL: url, AltText: altText}
+}
+
+// WithTitle sets the title displayed above the image.
+func (b *ImageBlock) WithTitle(title string) *ImageBlock {
+	b.Title = PlainText(title)
+	return b
+}
+
+func (b *ImageBlock) BlockType() string { return b.Type }
+
+// Validate returns an error if the image exceeds a Slack limit.
+func (b *ImageBlock) Validate() error {
+	if err := checkRequired("image URL", b.ImageURL, MaxURLLength); err != nil {
+		return err
+	}
+	if err := checkRequired("image alt text", b.AltText, MaxAltTextLength); err != nil {
+		return err
+	}
+	if b.Title != nil {
+		if err := validateText("image title", b.Title, MaxHeaderTextLength, true); err != nil {
+			return err
+		}
+	}
+	return validateBlockID(b.BlockID)
+}
+
+// InputBlock collects data from the user with a text field or a menu.
+type InputBlock struct {
+	Type     string  `json:"type"`
+	Label    *Text   `json:"label"`
+	Element  Element `json:"element"`
+	Hint     *Text   `json:"hint,omitempty"`
+	Optional bool    `json:"optional,omitempty"`
+	BlockID  string  `json:"block_id,omitempty"`
+}
+
+// Input returns a new input block.
+func Input(label string, element Element) *InputBlock {
+	return &InputBlock{Type: "input", Label: PlainText(label), Element: element}
+}
+
+// WithHint sets the text displayed below the input.
+func (b *InputBlock) WithHint(hint string) *InputBlock {
+	b.Hint = PlainText(hint)
+	return b
+}
+
+// WithOptional allows the input to be empty.
+func (b *InputBlock) WithOptional() *InputBlock {
+	b.Optional = true
+	return b
+}
+
+// WithBlockID sets the ID of the block, which is sent to the actions endpoint with the message.
+func (b *InputBlock) WithBlockID(id string) *InputBlock {
+	b.BlockID = id
+	return b
+}
+
+func (b *InputBlock) BlockType() string { return b.Type }
+
+// Validate returns an error if the input exceeds a Slack limit or its element can't collect input.
+func (b *InputBlock) Validate() error {
+	if err := validateText("input label", b.Label, MaxInputLabelLength, true); err != nil {
+		return err
+	}
+	if b.Hint != nil {
+		if err := validateText("input hint", b.Hint, MaxInputLabelLength, true); err != nil {
+			return err
+		}
+	}
+	switch b.Element.(type) {
+	case *PlainTextInputElement, *StaticSelectElement:
+	case nil:
+		return invalid("input element is required")
+	default:
+		return invalid("input can't have a %s element", b.Element.ElementType())
+	}
+	if err := b.Element.Validate(); err != nil {
+		return err
+	}
+	return validateBlockID(b.BlockID)
+}
@@ -0,0 +1,217 @@
+// Copyright (c) Spectro Cloud
+// SPDX-License-Identifier: Apache-2.0
+
+package blockkit
+
+// Element is an interactive or image element used in section, context, actions and input blocks.
+type Element interface {
+	ElementType() string
+	Validate() error
+}
+
+const (
+	// StylePrimary displays a green button.
+	StylePrimary string = "primary"
+	// StyleDanger displays a red button.
+	StyleDanger string = "danger"
+)
+
+// ButtonElement is a button. Clicking the button sends the action ID and value to the actions endpoint,
+// or opens the URL if one is set.
+type ButtonElement struct {
+	Type     string `json:"type"`
+	Text     *Text  `json:"text"`
+	ActionID string `json:"action_id"`
+	Value    string `json:"value,omitempty"`
+	Style    string `json:"style,omitempty"`
+	URL      string `json:"url,omitempty"`
+}
+
+// Button returns a new button.
+func Button(text, actionID, value string) *ButtonElement {
+	return &ButtonElement{Type: "button", Text: PlainText(text), ActionID: actionID, Value: value}
+}
+
+// WithStyle sets the style of the button, StylePrimary or StyleDanger.
+func (e *ButtonElement) WithStyle(style string) *ButtonElement {
+	e.Style = style
+	return e
+}
+
+// WithURL sets the URL opened when the button is clicked.
+func (e *ButtonElement) WithURL(url string) *ButtonElement {
+	e.URL = url
+	return e
+}
+
+func (e *ButtonElement) ElementType() string { return e.Type }
+
+// Validate returns an error if the button exceeds a Slack limit.
+func (e *ButtonElement) Validate() error {
+	if err := validateText("button text", e.Text, MaxButtonTextLength, true); err != nil {
+		return err
+	}
+	if err := checkRequired("button action ID", e.ActionID, MaxActionIDLength); err != nil {
+		return err
+	}
+	if err := checkLength("button value", e.Value, MaxButtonValueLength); err != nil {
+		return err
+	}
+	if e.Style != "" && e.Style != StylePrimary && e.Style != StyleDanger {
+		return invalid("unknown button style %q", e.Style)
+	}
+	return checkLength("button URL", e.URL, MaxURLLength)
+}
+
+// Option is an item of an overflow or static select menu.
+type Option struct {
+	Text        *Text  `json:"text"`
+	Value       string `json:"value"`
+	Description *Text  `json:"description,omitempty"`
+}
+
+// NewOption returns a new option.
+func NewOption(text, value string) *Option {
+	return &Option{Text: PlainText(text), Value: value}
+}
+
+// Validate returns an error if the option exceeds a Slack limit.
+func (o *Option) Validate() error {
+	if err := validateText("option text", o.Text, MaxOptionTextLength, true); err != nil {
+		return err
+	}
+	if err := checkRequired("option value", o.Value, MaxOptionValueLength); err != nil {
+		return err
+	}
+	if o.Description != nil {
+		return validateText("option description", o.Description, MaxOptionTextLength, true)
+	}
+	return nil
+}
+
+// validateOptions returns an error if the number of options is out of range or an option is invalid.
+func validateOptions(field string, options []*Option, limit int) error {
+	if len(options) == 0 || len(options) > limit {
+		return invalid("%s has %d options, it must have between 1 and %d", field, len(options), limit)
+	}
+	for _, option := range options {
+		if err := option.Validate(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// OverflowElement is a menu of up to five options displayed as a "…" button.
+type OverflowElement struct {
+	Type     string    `json:"type"`
+	ActionID string    `json:"action_id"`
+	Options  []*Option `json:"options"`
+}
+
+// Overflow returns a new overflow menu.
+func Overflow(actionID string, options ...*Option) *OverflowElement {
+	return &OverflowElement{Type: "overflow", ActionID: actionID, Options: options}
+}
+
+func (e *OverflowElement) ElementType() string { return e.Type }
+
+// Validate returns an error if the overflow menu exceeds a Slack limit.
+func (e *OverflowElement) Validate() error {
+	if err := checkRequired("overflow action ID", e.ActionID, MaxActionIDLength); err != nil {
+		return err
+	}
+	return validateOptions("overflow menu", e.Options, MaxOverflowOptions)
+}
+
+// StaticSelectElement is a drop-down menu with a fixed list of options.
+type StaticSelectElement struct {
+	Type          string    `json:"type"`
+	ActionID      string    `json:"action_id"`
+	Placeholder   *Text     `json:"placeholder,omitempty"`
+	Options       []*Option `json:"options"`
+	InitialOption *Option   `json:"initial_option,omitempty"`
+}
+
+// StaticSelect returns a new static select menu.
+func StaticSelect(actionID, placeholder string, options ...*Option) *StaticSelectElement {
+	e := &StaticSelectElement{Type: "static_select", ActionID: actionID, Options: options}
+	if placeholder != "" {
+		e.Placeholder = PlainText(placeholder)
+	}
+	return e
+}
+
+// WithInitialOption sets the option selected when the menu is displayed.
+func (e *StaticSelectElement) WithInitialOption(option *Option) *StaticSelectElement {
+	e.InitialOption = option
+	return e
+}
+
+func (e *StaticSelectElement) ElementType() string { return e.Type }
+
+// Validate returns an error if the menu exceeds a Slack limit.
+func (e *StaticSelectElement) Validate() error {
+	if err := checkRequired("select action ID", e.ActionID, MaxActionIDLength); err != nil {
+		return err
+	}
+	if e.Placeholder != nil {
+		if err := validateText("select placeholder", e.Placeholder, MaxPlaceholderLength, true); err != nil {
+			return err
+		}
+	}
+	return validateOptions("select menu", e.Options, MaxSelectOptions)
+}
+
+// PlainTextInputElement is a text field. It's only valid in input blocks.
+type PlainTextInputElement struct {
+	Type         string `json:"type"`
+	ActionID     string `json:"action_id"`
+	Placeholder  *Text  `json:"placeholder,omitempty"`
+	InitialValue string `json:"initial_value,omitempty"`
+	Multiline    bool   `json:"multiline,omitempty"`
+}
+
+// PlainTextInput returns a new text field.
+func PlainTextInput(actionID, placeholder string, multiline bool) *PlainTextInputElement {
+	e := &PlainTextInputElement{Type: "plain_text_input", ActionID: actionID, Multiline: multiline}
+	if placeholder != "" {
+		e.Placeholder = PlainText(placeholder)
+	}
+	return e
+}
+
+func (e *PlainTextInputElement) ElementType() string { return e.Type }
+
+// Validate returns an error if the text field exceeds a Slack limit.
+func (e *PlainTextInputElement) Validate() error {
+	if err := checkRequired("input action ID", e.ActionID, MaxActionIDLength); err != nil {
+		return err
+	}
+	if e.Placeholder != nil {
+		return validateText("input placeholder", e.Placeholder, MaxPlaceholderLength, true)
+	}
+	return nil
+}
+
+// ImageElement is a small image used in section accessories and context blocks.
+type ImageElement struct {
+	Type     string `json:"type"`
+	ImageURL string `json:"image_url"`
+	AltText  string `json:"alt_text"`
+}
+
+// Thumbnail returns a new image element, displayed small next to text.
+func Thumbnail(url, altText string) *ImageElement {
+	return &ImageElement{Type: "image", ImageURL: url, AltText: altText}
+}
+
+func (e *ImageElement) ElementType() string { return e.Type }
+
+// Validate returns an error if the image exceeds a Slack limit.
+func (e *ImageElement) Validate() error {
+	if err := checkRequired("image URL", e.ImageURL, MaxURLLength); err != nil {
+		return err
+	}
+	return checkRequired("image alt text", e.AltText, MaxAltTextLength)
+}
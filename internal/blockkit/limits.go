@@ -0,0 +1,85 @@
+// Copyright (c) Spectro Cloud
+// SPDX-License-Identifier: Apache-2.0
+
+package blockkit
+
+import (
+	"errors"
+	"fmt"
+	"unicode/utf8"
+)
+
+// Limits of Slack messages. Slack rejects messages exceeding any of the limits.
+// https://api.slack.com/reference/block-kit
+const (
+	// MaxBlocks is the maximum number of blocks in a message.
+	MaxBlocks int = 50
+	// MaxBlockIDLength is the maximum length of a block ID.
+	MaxBlockIDLength int = 255
+	// MaxHeaderTextLength is the maximum length of the text of a header block.
+	MaxHeaderTextLength int = 150
+	// MaxSectionTextLength is the maximum length of the text of a section block.
+	MaxSectionTextLength int = 3000
+	// MaxSectionFields is the maximum number of fields in a section block.
+	MaxSectionFields int = 10
+	// MaxFieldTextLength is the maximum length of the text of a section field.
+	MaxFieldTextLength int = 2000
+	// MaxContextElements is the maximum number of elements in a context block.
+	MaxContextElements int = 10
+	// MaxActionElements is the maximum number of elements in an actions block.
+	MaxActionElements int = 25
+	// MaxActionIDLength is the maximum length of the action ID of an interactive element.
+	MaxActionIDLength int = 255
+	// MaxButtonTextLength is the maximum length of the text of a button.
+	MaxButtonTextLength int = 75
+	// MaxButtonValueLength is the maximum length of the value of a button.
+	MaxButtonValueLength int = 2000
+	// MaxURLLength is the maximum length of a URL.
+	MaxURLLength int = 3000
+	// MaxOverflowOptions is the maximum number of options in an overflow menu.
+	MaxOverflowOptions int = 5
+	// MaxSelectOptions is the maximum number of options in a static select menu.
+	MaxSelectOptions int = 100
+	// MaxOptionTextLength is the maximum length of the text of an option.
+	MaxOptionTextLength int = 75
+	// MaxOptionValueLength is the maximum length of the value of an option.
+	MaxOptionValueLength int = 150
+	// MaxPlaceholderLength is the maximum length of the placeholder of a menu or input.
+	MaxPlaceholderLength int = 150
+	// MaxInputLabelLength is the maximum length of the label and hint of an input block.
+	MaxInputLabelLength int = 2000
+	// MaxAltTextLength is the maximum length of the alternative text of an image.
+	MaxAltTextLength int = 2000
+	// MaxViewBlocks is the maximum number of blocks in a modal.
+	MaxViewBlocks int = 100
+	// MaxViewTitleLength is the maximum length of the title and button texts of a modal.
+	MaxViewTitleLength int = 24
+	// MaxCallbackIDLength is the maximum length of the callback ID of a modal.
+	MaxCallbackIDLength int = 255
+	// MaxPrivateMetadataLength is the maximum length of the private metadata of a modal.
+	MaxPrivateMetadataLength int = 3000
+)
+
+// ErrInvalid is returned, wrapped, when a block or element exceeds a Slack limit or is missing a required field.
+var ErrInvalid = errors.New("invalid block kit payload")
+
+// invalid returns an error wrapping ErrInvalid.
+func invalid(format string, args ...interface{}) error {
+	return fmt.Errorf("%w: %s", ErrInvalid, fmt.Sprintf(format, args...))
+}
+
+// checkLength returns an error if the value is longer than the limit, in characters.
+func checkLength(field, value string, limit int) error {
+	if n := utf8.RuneCountInString(value); n > limit {
+		return invalid("%s is %d characters long, the limit is %d", field, n, limit)
+	}
+	return nil
+}
+
+// checkRequired returns an error if the value is empty or longer than the limit.
+func checkRequired(field, value string, limit int) error {
+	if value == "" {
+		return invalid("%s is required", field)
+	}
+	return checkLength(field, value, limit)
+}
@@ -0,0 +1,76 @@
+// Copyright (c) Spectro Cloud
+// SPDX-License-Identifier: Apache-2.0
+
+package blockkit
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+const (
+	// ResponseEphemeral displays the message only to the user who invoked the command.
+	ResponseEphemeral string = "ephemeral"
+	// ResponseInChannel displays the message to everyone in the channel.
+	ResponseInChannel string = "in_channel"
+)
+
+// Message is a message sent to a Slack response URL.
+type Message struct {
+	ResponseType    string  `json:"response_type,omitempty"`
+	ReplaceOriginal bool    `json:"replace_original,omitempty"`
+	DeleteOriginal  bool    `json:"delete_original,omitempty"`
+	Text            string  `json:"text,omitempty"`
+	Blocks          []Block `json:"blocks"`
+}
+
+// NewMessage returns a new message visible only to the user if private, or to the channel otherwise.
+func NewMessage(private bool, blocks ...Block) *Message {
+	responseType := ResponseInChannel
+	if private {
+		responseType = ResponseEphemeral
+	}
+	return &Message{ResponseType: responseType, Blocks: blocks}
+}
+
+// Add appends blocks to the message.
+func (m *Message) Add(blocks ...Block) *Message {
+	m.Blocks = append(m.Blocks, blocks...)
+	return m
+}
+
+// WithText sets the fallback text displayed in notifications.
+func (m *Message) WithText(text string) *Message {
+	m.Text = text
+	return m
+}
+
+// WithReplaceOriginal replaces the message the response URL belongs to instead of posting a new message.
+func (m *Message) WithReplaceOriginal() *Message {
+	m.ReplaceOriginal = true
+	return m
+}
+
+// Validate returns an error if the message or any of its blocks exceeds a Slack limit.
+func (m *Message) Validate() error {
+	if len(m.Blocks) > MaxBlocks {
+		return invalid("message has %d blocks, the limit is %d", len(m.Blocks), MaxBlocks)
+	}
+	if len(m.Blocks) == 0 && m.Text == "" && !m.DeleteOriginal {
+		return invalid("message has no blocks or text")
+	}
+	for i, block := range m.Blocks {
+		if err := block.Validate(); err != nil {
+			return fmt.Errorf("block %d (%s): %w", i, block.BlockType(), err)
+		}
+	}
+	return nil
+}
+
+// JSON validates the message and returns it encoded as JSON.
+func (m *Message) JSON() ([]byte, error) {
+	if err := m.Validate(); err != nil {
+		return nil, err
+	}
+	return json.Marshal(m)
+}
@@ -0,0 +1,114 @@
+// Copyright (c) Spectro Cloud
+// SPDX-License-Identifier: Apache-2.0
+
+package blockkit
+
+import (
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestMessageValidate(t *testing.T) {
+	section := Section(Markdown("text"))
+
+	tests := []struct {
+		name    string
+		message *Message
+		wantErr bool
+	}{
+		{"valid", NewMessage(false, Header("Docs Answer"), Divider(), section), false},
+		{"empty", NewMessage(false), true},
+		{"too many blocks", NewMessage(false, make([]Block, MaxBlocks+1)...), true},
+		{"long header", NewMessage(false, Header(strings.Repeat("a", MaxHeaderTextLength+1))), true},
+		{"markdown header", NewMessage(false, &HeaderBlock{Type: "header", Text: Markdown("title")}), true},
+		{"long section", NewMessage(false, Section(Markdown(strings.Repeat("a", MaxSectionTextLength+1)))), true},
+		{"empty section", NewMessage(false, Section(nil)), true},
+		{"long field", NewMessage(false, Fields(Markdown(strings.Repeat("a", MaxFieldTextLength+1)))), true},
+		{"too many fields", NewMessage(false, Fields(make([]*Text, MaxSectionFields+1)...)), true},
+		{"long block ID", NewMessage(false, section, Divider(), Section(Markdown("text")).WithBlockID(strings.Repeat("a", MaxBlockIDLength+1))), true},
+		{"buttons", NewMessage(false, Actions(Button(":thumbsup:", "up", "1").WithStyle(StylePrimary), Button(":thumbsdown:", "down", "1").WithStyle(StyleDanger))), false},
+		{"unknown button style", NewMessage(false, Actions(Button("OK", "ok", "1").WithStyle("blue"))), true},
+		{"long button text", NewMessage(false, Actions(Button(strings.Repeat("a", MaxButtonTextLength+1), "ok", "1"))), true},
+		{"button without action ID", NewMessage(false, Actions(Button("OK", "", "1"))), true},
+		{"empty actions", NewMessage(false, Actions()), true},
+		{"input in actions", NewMessage(false, Actions(PlainTextInput("text", "", false))), true},
+		{"overflow", NewMessage(false, Actions(Overflow("more", NewOption("Share", "share"), NewOption("Open", "open")))), false},
+		{"overflow with too many options", NewMessage(false, Actions(Overflow("more", tooManyOptions()...))), true},
+		{"select", NewMessage(false, Actions(StaticSelect("pick", "Pick one", NewOption("A", "a")))), false},
+		{"select without options", NewMessage(false, Actions(StaticSelect("pick", "Pick one"))), true},
+		{"context", NewMessage(false, Context(Markdown("small"), Thumbnail("https://example.com/a.png", "logo"))), false},
+		{"button in context", NewMessage(false, Context(Button("OK", "ok", "1"))), true},
+		{"image", NewMessage(false, Image("https://example.com/a.png", "diagram").WithTitle("Diagram")), false},
+		{"image without alt text", NewMessage(false, Image("https://example.com/a.png", "")), true},
+		{"input", NewMessage(false, Input("Comment", PlainTextInput("comment", "Tell us more", true)).WithOptional()), false},
+		{"input without element", NewMessage(false, Input("Comment", nil)), true},
+		{"rich text", NewMessage(false, RichText(
+			RichTextSection(RichTextText("Install ", nil), RichTextText("Palette", &TextStyle{Bold: true}), RichTextEmoji("rocket")),
+			RichTextList(ListBullet, RichTextSection(RichTextLink("https://docs.spectrocloud.com", "Docs"))),
+			RichTextPreformatted(RichTextText("kubectl get pods", nil)),
+			RichTextQuote(RichTextText("Note", nil)),
+		)), false},
+		{"rich text list with a quote item", NewMessage(false, RichText(RichTextList(ListOrdered, RichTextQuote(RichTextText("a", nil))))), true},
+		{"empty rich text", NewMessage(false, RichText()), true},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			err := tc.message.Validate()
+			if (err != nil) != tc.wantErr {
+				t.Fatalf("Validate returned an unexpected error: %v", err)
+			}
+			if err != nil && !errors.Is(err, ErrInvalid) {
+				t.Errorf("expected the error to wrap ErrInvalid, got %v", err)
+			}
+		})
+	}
+}
+
+func tooManyOptions() []*Option {
+	options := make([]*Option, MaxOverflowOptions+1)
+	for i := range options {
+		options[i] = NewOption("Option", "option")
+	}
+	return options
+}
+
+func TestMessageJSON(t *testing.T) {
+	message := NewMessage(true,
+		Header("Docs Answer"),
+		Section(Markdown("How do I install Palette?")).WithBlockID("question"),
+		Actions(Button(":thumbsup:", "positive", "123").WithStyle(StylePrimary)),
+	)
+
+	payload, err := message.JSON()
+	if err != nil {
+		t.Fatalf("JSON returned an unexpected error: %v", err)
+	}
+
+	var got map[string]interface{}
+	if err := json.Unmarshal(payload, &got); err != nil {
+		t.Fatalf("the payload isn't valid JSON: %v", err)
+	}
+	if got["response_type"] != ResponseEphemeral {
+		t.Errorf("expected an ephemeral message, got %v", got["response_type"])
+	}
+
+	blocks := got["blocks"].([]interface{})
+	if len(blocks) != 3 {
+		t.Fatalf("expected 3 blocks, got %d", len(blocks))
+	}
+	section := blocks[1].(map[string]interface{})
+	if section["type"] != "section" || section["block_id"] != "question" {
+		t.Errorf("unexpected section block: %v", section)
+	}
+	button := blocks[2].(map[string]interface{})["elements"].([]interface{})[0].(map[string]interface{})
+	if button["action_id"] != "positive" || button["value"] != "123" || button["style"] != StylePrimary {
+		t.Errorf("unexpected button: %v", button)
+	}
+
+	if _, err := NewMessage(false, Header("")).JSON(); err == nil {
+		t.Errorf("expected an error for an invalid message")
+	}
+}
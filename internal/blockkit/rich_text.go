@@ -0,0 +1,198 @@
+// Copyright (c) Spectro Cloud
+// SPDX-License-Identifier: Apache-2.0
+
+package blockkit
+
+// RichTextElement is a section, list, preformatted or quote element of a rich text block.
+type RichTextElement interface {
+	richTextType() string
+	validate() error
+}
+
+// RichTextInline is text, a link or an emoji inside a rich text element.
+type RichTextInline interface {
+	inlineType() string
+	validate() error
+}
+
+// RichTextBlock displays formatted text made of nested elements.
+type RichTextBlock struct {
+	Type     string            `json:"type"`
+	Elements []RichTextElement `json:"elements"`
+	BlockID  string            `json:"block_id,omitempty"`
+}
+
+// RichText returns a new rich text block.
+func RichText(elements ...RichTextElement) *RichTextBlock {
+	return &RichTextBlock{Type: "rich_text", Elements: elements}
+}
+
+// WithBlockID sets the ID of the block, which is sent to the actions endpoint with the message.
+func (b *RichTextBlock) WithBlockID(id string) *RichTextBlock {
+	b.BlockID = id
+	return b
+}
+
+func (b *RichTextBlock) BlockType() string { return b.Type }
+
+// Validate returns an error if the rich text block is empty or has an empty element.
+func (b *RichTextBlock) Validate() error {
+	if len(b.Elements) == 0 {
+		return invalid("rich text has no elements")
+	}
+	for _, element := range b.Elements {
+		if err := element.validate(); err != nil {
+			return err
+		}
+	}
+	return validateBlockID(b.BlockID)
+}
+
+// RichTextContainer is a paragraph, preformatted text or quote made of inline elements.
+type RichTextContainer struct {
+	Type     string           `json:"type"`
+	Elements []RichTextInline `json:"elements"`
+}
+
+// RichTextSection returns a paragraph.
+func RichTextSection(elements ...RichTextInline) *RichTextContainer {
+	return &RichTextContainer{Type: "rich_text_section", Elements: elements}
+}
+
+// RichTextPreformatted returns a code block.
+func RichTextPreformatted(elements ...RichTextInline) *RichTextContainer {
+	return &RichTextContainer{Type: "rich_text_preformatted", Elements: elements}
+}
+
+// RichTextQuote returns a quote.
+func RichTextQuote(elements ...RichTextInline) *RichTextContainer {
+	return &RichTextContainer{Type: "rich_text_quote", Elements: elements}
+}
+
+func (c *RichTextContainer) richTextType() string { return c.Type }
+
+func (c *RichTextContainer) validate() error {
+	if len(c.Elements) == 0 {
+		return invalid("%s has no elements", c.Type)
+	}
+	for _, element := range c.Elements {
+		if err := element.validate(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+const (
+	// ListBullet is the style of bulleted lists.
+	ListBullet string = "bullet"
+	// ListOrdered is the style of numbered lists.
+	ListOrdered string = "ordered"
+)
+
+// RichTextListElement is a bulleted or numbered list. Every item is a section.
+type RichTextListElement struct {
+	Type     string               `json:"type"`
+	Style    string               `json:"style"`
+	Indent   int                  `json:"indent,omitempty"`
+	Elements []*RichTextContainer `json:"elements"`
+}
+
+// RichTextList returns a list with the style ListBullet or ListOrdered.
+func RichTextList(style string, items ...*RichTextContainer) *RichTextListElement {
+	return &RichTextListElement{Type: "rich_text_list", Style: style, Elements: items}
+}
+
+// WithIndent sets the nesting level of the list.
+func (l *RichTextListElement) WithIndent(indent int) *RichTextListElement {
+	l.Indent = indent
+	return l
+}
+
+func (l *RichTextListElement) richTextType() string { return l.Type }
+
+func (l *RichTextListElement) validate() error {
+	if l.Style != ListBullet && l.Style != ListOrdered {
+		return invalid("unknown list style %q", l.Style)
+	}
+	if len(l.Elements) == 0 {
+		return invalid("list has no items")
+	}
+	for _, item := range l.Elements {
+		if item.Type != "rich_text_section" {
+			return invalid("list items must be sections, not %s", item.Type)
+		}
+		if err := item.validate(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// TextStyle is the style of inline rich text.
+type TextStyle struct {
+	Bold   bool `json:"bold,omitempty"`
+	Italic bool `json:"italic,omitempty"`
+	Strike bool `json:"strike,omitempty"`
+	Code   bool `json:"code,omitempty"`
+}
+
+// RichTextTextElement is inline text.
+type RichTextTextElement struct {
+	Type  string     `json:"type"`
+	Text  string     `json:"text"`
+	Style *TextStyle `json:"style,omitempty"`
+}
+
+// RichTextText returns inline text, with an optional style.
+func RichTextText(text string, style *TextStyle) *RichTextTextElement {
+	return &RichTextTextElement{Type: "text", Text: text, Style: style}
+}
+
+func (t *RichTextTextElement) inlineType() string { return t.Type }
+
+func (t *RichTextTextElement) validate() error {
+	if t.Text == "" {
+		return invalid("rich text is empty")
+	}
+	return nil
+}
+
+// RichTextLinkElement is an inline link.
+type RichTextLinkElement struct {
+	Type  string     `json:"type"`
+	URL   string     `json:"url"`
+	Text  string     `json:"text,omitempty"`
+	Style *TextStyle `json:"style,omitempty"`
+}
+
+// RichTextLink returns an inline link. The URL is displayed if the text is empty.
+func RichTextLink(url, text string) *RichTextLinkElement {
+	return &RichTextLinkElement{Type: "link", URL: url, Text: text}
+}
+
+func (l *RichTextLinkElement) inlineType() string { return l.Type }
+
+func (l *RichTextLinkElement) validate() error {
+	return checkRequired("link URL", l.URL, MaxURLLength)
+}
+
+// RichTextEmojiElement is an emoji, by its shortcode name without colons.
+type RichTextEmojiElement struct {
+	Type string `json:"type"`
+	Name string `json:"name"`
+}
+
+// RichTextEmoji returns an emoji.
+func RichTextEmoji(name string) *RichTextEmojiElement {
+	return &RichTextEmojiElement{Type: "emoji", Name: name}
+}
+
+func (e *RichTextEmojiElement) inlineType() string { return e.Type }
+
+func (e *RichTextEmojiElement) validate() error {
+	if e.Name == "" {
+		return invalid("emoji name is required")
+	}
+	return nil
+}
@@ -0,0 +1,58 @@
+// Copyright (c) Spectro Cloud
+// SPDX-License-Identifier: Apache-2.0
+
+package blockkit
+
+const (
+	// TextPlain is the type of plain text objects.
+	TextPlain string = "plain_text"
+	// TextMarkdown is the type of mrkdwn text objects.
+	TextMarkdown string = "mrkdwn"
+)
+
+// Text is a text object. Plain text is required by headers, buttons, options and labels.
+// Markdown text is rendered with the Slack mrkdwn syntax.
+type Text struct {
+	Type     string `json:"type"`
+	Text     string `json:"text"`
+	Emoji    bool   `json:"emoji,omitempty"`
+	Verbatim bool   `json:"verbatim,omitempty"`
+}
+
+// PlainText returns a plain text object. Emoji shortcodes, such as :thumbsup:, are rendered.
+func PlainText(text string) *Text {
+	return &Text{Type: TextPlain, Text: text, Emoji: true}
+}
+
+// Markdown returns a mrkdwn text object.
+func Markdown(text string) *Text {
+	return &Text{Type: TextMarkdown, Text: text}
+}
+
+// ElementType returns the type of the text object, so it can be used as a context element.
+func (t *Text) ElementType() string {
+	return t.Type
+}
+
+// Validate returns an error if the text object is empty.
+// The length is checked by the block or element using the text, because the limit depends on where it's used.
+func (t *Text) Validate() error {
+	if t == nil || t.Text == "" {
+		return invalid("text is required")
+	}
+	if t.Type != TextPlain && t.Type != TextMarkdown {
+		return invalid("unknown text type %q", t.Type)
+	}
+	return nil
+}
+
+// validateText returns an error if the text is missing, longer than the limit, or not plain text when required.
+func validateText(field string, t *Text, limit int, plainOnly bool) error {
+	if err := t.Validate(); err != nil {
+		return invalid("%s is required", field)
+	}
+	if plainOnly && t.Type != TextPlain {
+		return invalid("%s must be plain text", field)
+	}
+	return checkLength(field, t.Text, limit)
+}
@@ -0,0 +1,95 @@
+// Copyright (c) Spectro Cloud
+// SPDX-License-Identifier: Apache-2.0
+
+package blockkit
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// View is a modal opened with the views.open method of the Slack Web API.
+type View struct {
+	Type            string  `json:"type"`
+	CallbackID      string  `json:"callback_id,omitempty"`
+	Title           *Text   `json:"title"`
+	Submit          *Text   `json:"submit,omitempty"`
+	Close           *Text   `json:"close,omitempty"`
+	PrivateMetadata string  `json:"private_metadata,omitempty"`
+	Blocks          []Block `json:"blocks"`
+}
+
+// Modal returns a new modal. The callback ID identifies the modal when it's submitted.
+func Modal(callbackID, title string, blocks ...Block) *View {
+	return &View{Type: "modal", CallbackID: callbackID, Title: PlainText(title), Blocks: blocks}
+}
+
+// Add appends blocks to the modal.
+func (v *View) Add(blocks ...Block) *View {
+	v.Blocks = append(v.Blocks, blocks...)
+	return v
+}
+
+// WithSubmit sets the text of the submit button. Modals with input blocks require a submit button.
+func (v *View) WithSubmit(text string) *View {
+	v.Submit = PlainText(text)
+	return v
+}
+
+// WithClose sets the text of the button closing the modal.
+func (v *View) WithClose(text string) *View {
+	v.Close = PlainText(text)
+	return v
+}
+
+// WithPrivateMetadata sets data returned with the submission of the modal, which isn't displayed to the user.
+func (v *View) WithPrivateMetadata(metadata string) *View {
+	v.PrivateMetadata = metadata
+	return v
+}
+
+// Validate returns an error if the modal or any of its blocks exceeds a Slack limit.
+func (v *View) Validate() error {
+	if err := validateText("modal title", v.Title, MaxViewTitleLength, true); err != nil {
+		return err
+	}
+	if v.Submit != nil {
+		if err := validateText("modal submit text", v.Submit, MaxViewTitleLength, true); err != nil {
+			return err
+		}
+	}
+	if v.Close != nil {
+		if err := validateText("modal close text", v.Close, MaxViewTitleLength, true); err != nil {
+			return err
+		}
+	}
+	if err := checkLength("modal callback ID", v.CallbackID, MaxCallbackIDLength); err != nil {
+		return err
+	}
+	if err := checkLength("modal private metadata", v.PrivateMetadata, MaxPrivateMetadataLength); err != nil {
+		return err
+	}
+	if len(v.Blocks) == 0 || len(v.Blocks) > MaxViewBlocks {
+		return invalid("modal has %d blocks, it requires 1 to %d", len(v.Blocks), MaxViewBlocks)
+	}
+
+	hasInput := false
+	for i, block := range v.Blocks {
+		if err := block.Validate(); err != nil {
+			return fmt.Errorf("block %d (%s): %w", i, block.BlockType(), err)
+		}
+		hasInput = hasInput || block.BlockType() == "input"
+	}
+	if hasInput && v.Submit == nil {
+		return invalid("modal has input blocks without a submit button")
+	}
+	return nil
+}
+
+// JSON validates the modal and returns it encoded as JSON.
+func (v *View) JSON() ([]byte, error) {
+	if err := v.Validate(); err != nil {
+		return nil, err
+	}
+	return json.Marshal(v)
+}
@@ -0,0 +1,40 @@
+// Copyright (c) Spectro Cloud
+// SPDX-License-Identifier: Apache-2.0
+
+package blockkit
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestViewValidate(t *testing.T) {
+	input := Input("Question", PlainTextInput("question", "Ask about the docs", true))
+
+	tests := []struct {
+		name    string
+		view    *View
+		wantErr bool
+	}{
+		{"valid", Modal("follow_up", "Ask a follow-up", Section(Markdown("text")), input).WithSubmit("Ask").WithClose("Cancel"), false},
+		{"without blocks", Modal("follow_up", "Ask a follow-up"), true},
+		{"long title", Modal("follow_up", strings.Repeat("a", MaxViewTitleLength+1), Section(Markdown("text"))), true},
+		{"long submit text", Modal("follow_up", "Title", input).WithSubmit(strings.Repeat("a", MaxViewTitleLength+1)), true},
+		{"input without submit", Modal("follow_up", "Title", input), true},
+		{"long private metadata", Modal("follow_up", "Title", input).WithSubmit("Ask").WithPrivateMetadata(strings.Repeat("a", MaxPrivateMetadataLength+1)), true},
+		{"invalid block", Modal("follow_up", "Title", Section(nil)), true},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			err := tc.view.Validate()
+			if (err != nil) != tc.wantErr {
+				t.Fatalf("Validate returned an unexpected error: %v", err)
+			}
+			if err != nil && !errors.Is(err, ErrInvalid) {
+				t.Errorf("expected the error to wrap ErrInvalid, got %v", err)
+			}
+		})
+	}
+}
@@ -6,23 +6,34 @@ package internal
 import (
 	"context"
 	"crypto/tls"
+	"errors"
+	"io"
+	"net"
+	"sync/atomic"
 	"time"
 
 	"github.com/redis/go-redis/v9"
 	"github.com/rs/zerolog/log"
 )
 
+// ErrCacheUnavailable is returned when the cache can't be reached.
+// Callers are expected to continue without the cache where possible.
+var ErrCacheUnavailable = errors.New("cache is unavailable")
+
 // Cache is an interface for a cache. The implementation is up to the user.
 // The default implementation is Redis.
 type Cache interface {
 	StoreHashMap(ctx context.Context, primaryKey string, item map[string]interface{}) error
 	GetHashMap(ctx context.Context, primaryKey string) (bool, map[string]string, error)
 	ExpireKey(ctx context.Context, key string, t time.Duration) error
+	DeleteKey(ctx context.Context, key string) error
+	EvalScript(ctx context.Context, script string, keys []string, args ...interface{}) (interface{}, error)
+	AppendStream(ctx context.Context, stream string, maxLen int64, values map[string]interface{}) error
 	Ping() error
 }
 
 func NewCache(connectionString, redisUser, redisPassword string, tls *tls.Config) Cache {
-	return &RedisCache{
+	c := &RedisCache{
 		redis: redis.NewClient(&redis.Options{
 			Addr:      connectionString,
 			Password:  redisPassword,
@@ -31,20 +42,30 @@ func NewCache(connectionString, redisUser, redisPassword string, tls *tls.Config
 			TLSConfig: tls,
 		}),
 	}
+	c.available.Store(true)
+	return c
 }
 
 // RedisCache is a Redis implementation of the Cache interface.
+// Once a connection error occurs the cache is marked as unavailable and every call
+// returns ErrCacheUnavailable without waiting for a network timeout.
+// The cache is marked as available again by the next successful Ping, see MonitorCache.
 type RedisCache struct {
-	redis *redis.Client
+	redis     *redis.Client
+	available atomic.Bool
 }
 
 // StoreHashMap stores a hash map in the database.
 func (c *RedisCache) StoreHashMap(ctx context.Context, primaryKey string, item map[string]interface{}) error {
 
+	if !c.available.Load() {
+		return ErrCacheUnavailable
+	}
+
 	err := c.redis.HSet(ctx, primaryKey, item).Err()
 	if err != nil {
 		log.Error().Err(err).Msg("Error storing item entry in cache.")
-		return err
+		return c.checkConnection(err)
 	}
 
 	return nil
@@ -53,10 +74,30 @@ func (c *RedisCache) StoreHashMap(ctx context.Context, primaryKey string, item m
 // ExpireKey sets an expiration on a cache key.
 func (c *RedisCache) ExpireKey(ctx context.Context, key string, t time.Duration) error {
 
+	if !c.available.Load() {
+		return ErrCacheUnavailable
+	}
+
 	err := c.redis.Expire(ctx, key, t).Err()
 	if err != nil {
 		log.Error().Err(err).Msg("Error setting expiration on cache key")
-		return err
+		return c.checkConnection(err)
+	}
+
+	return nil
+}
+
+// DeleteKey removes a key from the database. Removing a missing key is not an error.
+func (c *RedisCache) DeleteKey(ctx context.Context, key string) error {
+
+	if !c.available.Load() {
+		return ErrCacheUnavailable
+	}
+
+	err := c.redis.Del(ctx, key).Err()
+	if err != nil {
+		log.Error().Err(err).Msg("Error deleting cache key")
+		return c.checkConnection(err)
 	}
 
 	return nil
@@ -65,6 +106,10 @@ func (c *RedisCache) ExpireKey(ctx context.Context, key string, t time.Duration)
 // GetHashMap gets a hash map from the database.
 func (c *RedisCache) GetHashMap(ctx context.Context, primaryKey string) (bool, map[string]string, error) {
 
+	if !c.available.Load() {
+		return false, nil, ErrCacheUnavailable
+	}
+
 	result, err := c.redis.HGetAll(ctx, primaryKey).Result()
 	if err != nil {
 		if err == redis.Nil {
@@ -72,7 +117,7 @@ func (c *RedisCache) GetHashMap(ctx context.Context, primaryKey string) (bool, m
 			return false, nil, nil
 		}
 		log.Error().Err(err).Msg("error retrieving key from cache.")
-		return false, nil, err
+		return false, nil, c.checkConnection(err)
 	}
 
 	if len(result) == 0 {
@@ -83,7 +128,116 @@ func (c *RedisCache) GetHashMap(ctx context.Context, primaryKey string) (bool, m
 	return true, result, nil
 }
 
+// EvalScript runs a Lua script in the database. Scripts are executed atomically.
+func (c *RedisCache) EvalScript(ctx context.Context, script string, keys []string, args ...interface{}) (interface{}, error) {
+
+	if !c.available.Load() {
+		return nil, ErrCacheUnavailable
+	}
+
+	result, err := c.redis.Eval(ctx, script, keys, args...).Result()
+	if err != nil && err != redis.Nil {
+		log.Error().Err(err).Msg("Error running script in cache.")
+		return nil, c.checkConnection(err)
+	}
+
+	return result, nil
+}
+
+// AppendStream adds an entry to a stream. The oldest entries are trimmed once the stream is
+// approximately maxLen entries long. Set maxLen to 0 to keep all entries.
+func (c *RedisCache) AppendStream(ctx context.Context, stream string, maxLen int64, values map[string]interface{}) error {
+
+	if !c.available.Load() {
+		return ErrCacheUnavailable
+	}
+
+	err := c.redis.XAdd(ctx, &redis.XAddArgs{
+		Stream: stream,
+		MaxLen: maxLen,
+		Approx: maxLen > 0,
+		Values: values,
+	}).Err()
+	if err != nil {
+		log.Error().Err(err).Msg("Error appending entry to stream.")
+		return c.checkConnection(err)
+	}
+
+	return nil
+}
+
 // Ping checks the connection to the database.
+// The result of the ping marks the cache as available or unavailable.
 func (r *RedisCache) Ping() error {
-	return r.redis.Ping(context.Background()).Err()
+	ctx, cancel := context.WithTimeout(context.Background(), DefaultHealthCheckTimeout)
+	defer cancel()
+
+	err := r.redis.Ping(ctx).Err()
+	r.available.Store(err == nil)
+	return err
+}
+
+// checkConnection marks the cache as unavailable if the error is a connection error.
+// Connection errors are wrapped so callers can check for ErrCacheUnavailable.
+func (c *RedisCache) checkConnection(err error) error {
+	if !isConnectionError(err) {
+		return err
+	}
+
+	if c.available.Swap(false) {
+		log.Warn().Err(err).Msg("Lost the connection to the cache. Continuing without the cache until it reconnects.")
+	}
+
+	return errors.Join(ErrCacheUnavailable, err)
+}
+
+// isConnectionError returns true if the connection to the cache failed, rather than the command.
+// A timed out or cancelled request only fails that request, so it doesn't mark the cache as unavailable.
+func isConnectionError(err error) bool {
+	if errors.Is(err, context.DeadlineExceeded) || errors.Is(err, context.Canceled) {
+		return false
+	}
+
+	var opErr *net.OpError
+	if errors.As(err, &opErr) && opErr.Op == "dial" {
+		return true
+	}
+	return errors.Is(err, io.EOF) || errors.Is(err, redis.ErrClosed)
+}
+
+// MonitorCache pings the cache until the context is cancelled.
+// While the cache is available it's pinged once per interval. After a failure the cache is pinged
+// with an exponential backoff starting at retryDelay, so a reconnection is noticed quickly.
+// Every change in availability is logged.
+func MonitorCache(ctx context.Context, cache Cache, interval, retryDelay time.Duration) {
+	available := true
+	failures := 0
+
+	for {
+		err := cache.Ping()
+
+		delay := interval
+		if err != nil {
+			failures++
+			if available {
+				log.Error().Err(err).Msg("The cache is unavailable. Retrying in the background.")
+			}
+			available = false
+			delay = backoffDelay(failures, retryDelay, interval)
+		} else {
+			if !available {
+				log.Info().Int("attempts", failures).Msg("Reconnected to the cache.")
+			}
+			available = true
+			failures = 0
+		}
+
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return
+		case <-timer.C:
+		}
+	}
 }
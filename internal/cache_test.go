@@ -6,11 +6,16 @@ package internal
 import (
 	"context"
 	"crypto/tls"
+	"errors"
 	"fmt"
+	"io"
+	"net"
+	"os"
 	"testing"
 	"time"
 
 	"github.com/golang/mock/gomock"
+	"github.com/redis/go-redis/v9"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	"spectrocloud.com/spectromate/mock"
@@ -181,3 +186,92 @@ func TestPing(t *testing.T) {
 	err = cache.Ping()
 	assert.Error(t, err, "Expected error when Redis client encounters an error")
 }
+
+func TestRedisCacheUnavailable(t *testing.T) {
+	// Nothing listens on port 1, so every connection attempt is refused.
+	cache := NewCache("127.0.0.1:1", "", "", nil)
+	ctx := context.Background()
+
+	err := cache.StoreHashMap(ctx, "testPrimaryKey", map[string]interface{}{"key1": "value1"})
+	require.ErrorIs(t, err, ErrCacheUnavailable)
+
+	// Once unavailable, calls fail fast without contacting Redis.
+	found, _, err := cache.GetHashMap(ctx, "testPrimaryKey")
+	assert.False(t, found)
+	assert.ErrorIs(t, err, ErrCacheUnavailable)
+	assert.ErrorIs(t, cache.ExpireKey(ctx, "testPrimaryKey", time.Minute), ErrCacheUnavailable)
+
+	_, err = cache.EvalScript(ctx, "return 1", []string{"testPrimaryKey"})
+	assert.ErrorIs(t, err, ErrCacheUnavailable)
+
+	assert.Error(t, cache.Ping())
+}
+
+func TestRedisCacheTimeout(t *testing.T) {
+	cache := NewCache("127.0.0.1:1", "", "", nil).(*RedisCache)
+
+	// A request that ran out of time doesn't mark the cache as unavailable.
+	ctx, cancel := context.WithTimeout(context.Background(), time.Nanosecond)
+	defer cancel()
+	<-ctx.Done()
+
+	_, _, err := cache.GetHashMap(ctx, "testPrimaryKey")
+	require.Error(t, err)
+	assert.NotErrorIs(t, err, ErrCacheUnavailable)
+	assert.True(t, cache.available.Load())
+}
+
+func TestIsConnectionError(t *testing.T) {
+	dialErr := &net.OpError{Op: "dial", Net: "tcp", Err: errors.New("connection refused")}
+
+	tests := []struct {
+		name     string
+		err      error
+		expected bool
+	}{
+		{"dial error", dialErr, true},
+		{"connection closed by the server", io.EOF, true},
+		{"client closed", redis.ErrClosed, true},
+		{"deadline exceeded", context.DeadlineExceeded, false},
+		{"cancelled", fmt.Errorf("get: %w", context.Canceled), false},
+		{"dial timed out", &net.OpError{Op: "dial", Net: "tcp", Err: context.DeadlineExceeded}, false},
+		{"read timed out", &net.OpError{Op: "read", Net: "tcp", Err: os.ErrDeadlineExceeded}, false},
+		{"command error", redis.Nil, false},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.expected, isConnectionError(tc.err))
+		})
+	}
+}
+
+func TestMonitorCache(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	cache := mock.NewMockCache(ctrl)
+	ctx, cancel := context.WithCancel(context.Background())
+
+	// The cache reconnects after two failed pings, then the monitor is stopped.
+	gomock.InOrder(
+		cache.EXPECT().Ping().Return(fmt.Errorf("connection refused")),
+		cache.EXPECT().Ping().Return(fmt.Errorf("connection refused")),
+		cache.EXPECT().Ping().DoAndReturn(func() error {
+			cancel()
+			return nil
+		}),
+	)
+
+	done := make(chan struct{})
+	go func() {
+		MonitorCache(ctx, cache, time.Hour, time.Millisecond)
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("MonitorCache did not stop after the context was cancelled")
+	}
+}
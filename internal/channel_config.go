@@ -0,0 +1,338 @@
+// Copyright (c) Spectro Cloud
+// SPDX-License-Identifier: Apache-2.0
+
+package internal
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/rs/zerolog/log"
+)
+
+// DefaultAnswerBackend is the name of the answer backend using the MENDABLE_API_KEY value.
+const DefaultAnswerBackend string = "default"
+
+// ChannelConfig contains the settings of a channel managed with the `/docs config` command.
+// The zero value is the default behavior.
+type ChannelConfig struct {
+	// Private replies to all questions privately.
+	Private bool
+	// Scope is the default product and docs version of the channel.
+	Scope DocsScope
+	// AllowedCommands limits the commands available in the channel. All commands are allowed if empty.
+	AllowedCommands []string
+	// Backend is the name of the answer backend used in the channel.
+	Backend string
+	// MinConfidence overrides the confidence threshold of answers in the channel. The threshold isn't overridden if nil.
+	MinConfidence *float64
+	// LowConfidence overrides how answers below the confidence threshold are displayed in the channel.
+	LowConfidence LowConfidenceMode
+	UpdatedBy     string
+	UpdatedAt     time.Time
+}
+
+// IsDefault returns true if the channel has no settings.
+func (c ChannelConfig) IsDefault() bool {
+	return !c.Private && c.Scope.IsEmpty() && len(c.AllowedCommands) == 0 && c.Backend == "" &&
+		c.MinConfidence == nil && c.LowConfidence == ""
+}
+
+// AllowsCommand returns true if the command can be used in the channel.
+func (c ChannelConfig) AllowsCommand(name string) bool {
+	if len(c.AllowedCommands) == 0 {
+		return true
+	}
+	for _, allowed := range c.AllowedCommands {
+		if strings.EqualFold(allowed, name) {
+			return true
+		}
+	}
+	return false
+}
+
+// channelConfigKey returns the cache key of the channel configuration.
+func channelConfigKey(teamID, channelID string) string {
+	return fmt.Sprintf("docs_bot:channel_config:%s:%s", teamID, channelID)
+}
+
+// GetChannelConfig returns the configuration of the channel.
+// The default configuration is returned if the channel has no settings.
+func GetChannelConfig(ctx context.Context, cache Cache, teamID, channelID string) (ChannelConfig, error) {
+	found, values, err := cache.GetHashMap(ctx, channelConfigKey(teamID, channelID))
+	if err != nil || !found {
+		return ChannelConfig{}, err
+	}
+
+	config := ChannelConfig{
+		Private:       values["private"] == "true",
+		Scope:         DocsScope{Product: values["product"], Version: values["version"]},
+		Backend:       values["backend"],
+		LowConfidence: LowConfidenceMode(values["low_confidence"]),
+		UpdatedBy:     values["updated_by"],
+	}
+
+	if threshold, err := strconv.ParseFloat(values["min_confidence"], 64); err == nil {
+		config.MinConfidence = &threshold
+	}
+
+	if commands := values["commands"]; commands != "" {
+		config.AllowedCommands = strings.Split(commands, ",")
+	}
+
+	if updatedAt, err := time.Parse(time.RFC3339, values["updated_at"]); err == nil {
+		config.UpdatedAt = updatedAt
+	}
+
+	return config, nil
+}
+
+// StoreChannelConfig stores the configuration of the channel. The configuration doesn't expire.
+func StoreChannelConfig(ctx context.Context, cache Cache, teamID, channelID string, config ChannelConfig) error {
+	item := map[string]interface{}{
+		"private":        strconv.FormatBool(config.Private),
+		"product":        config.Scope.Product,
+		"version":        config.Scope.Version,
+		"commands":       strings.Join(config.AllowedCommands, ","),
+		"backend":        config.Backend,
+		"min_confidence": "",
+		"low_confidence": string(config.LowConfidence),
+		"updated_by":     config.UpdatedBy,
+		"updated_at":     config.UpdatedAt.UTC().Format(time.RFC3339),
+	}
+	if config.MinConfidence != nil {
+		item["min_confidence"] = strconv.FormatFloat(*config.MinConfidence, 'f', -1, 64)
+	}
+
+	return cache.StoreHashMap(ctx, channelConfigKey(teamID, channelID), item)
+}
+
+// ResetChannelConfig removes all settings of the channel.
+func ResetChannelConfig(ctx context.Context, cache Cache, teamID, channelID string) error {
+	return cache.DeleteKey(ctx, channelConfigKey(teamID, channelID))
+}
+
+// ApplySetting updates a single setting, such as private=true, of the channel configuration.
+// The backends are the names of the available answer backends and the commands are the names
+// of the available commands. Both are used to validate the value.
+func (c *ChannelConfig) ApplySetting(key, value string, commands []string, backends AnswerBackends) error {
+	value = strings.TrimSpace(value)
+
+	switch strings.ToLower(strings.TrimSpace(key)) {
+	case "private":
+		private, err := strconv.ParseBool(value)
+		if err != nil {
+			return fmt.Errorf("`private` must be `true` or `false`")
+		}
+		c.Private = private
+	case "product", "version":
+		product, version := c.Scope.Product, c.Scope.Version
+		if strings.EqualFold(key, "product") {
+			product = value
+		} else {
+			version = value
+		}
+		scope, err := NewDocsScope(product, version)
+		if err != nil {
+			return err
+		}
+		c.Scope = scope
+	case "commands":
+		c.AllowedCommands = nil
+		for _, name := range strings.Split(value, ",") {
+			name = strings.ToLower(strings.TrimSpace(name))
+			if name == "" {
+				continue
+			}
+			if !containsString(commands, name) {
+				return fmt.Errorf("unknown command `%s`", name)
+			}
+			c.AllowedCommands = append(c.AllowedCommands, name)
+		}
+	case "backend":
+		if value == "" || value == DefaultAnswerBackend {
+			c.Backend = ""
+			return nil
+		}
+		if _, ok := backends[value]; !ok {
+			return fmt.Errorf("unknown answer backend `%s`. Available backends: %s", value, strings.Join(backends.Names(), ", "))
+		}
+		c.Backend = value
+	case "min_confidence":
+		// The default value removes the override, so the channel uses the MIN_ANSWER_CONFIDENCE threshold.
+		if value == "" || value == "default" {
+			c.MinConfidence = nil
+			return nil
+		}
+		threshold, err := ParseConfidenceThreshold(value)
+		if err != nil {
+			return fmt.Errorf("`min_confidence` must be a percentage between 0 and 100, or `default`")
+		}
+		c.MinConfidence = &threshold
+	case "low_confidence":
+		if value == "" || value == "default" {
+			c.LowConfidence = ""
+			return nil
+		}
+		mode, err := ParseLowConfidenceMode(value)
+		if err != nil {
+			return fmt.Errorf("`low_confidence` must be `caveat`, `withhold` or `default`")
+		}
+		c.LowConfidence = mode
+	default:
+		return fmt.Errorf("unknown setting `%s`. Available settings: private, product, version, commands, backend, min_confidence, low_confidence", key)
+	}
+
+	return nil
+}
+
+// AnswerBackend returns the name of the answer backend used in the channel.
+func (c ChannelConfig) AnswerBackend() string {
+	if c.Backend == "" {
+		return DefaultAnswerBackend
+	}
+	return c.Backend
+}
+
+// Summary returns the settings of the channel as markdown.
+func (c ChannelConfig) Summary() string {
+	commands := "all"
+	if len(c.AllowedCommands) > 0 {
+		commands = strings.Join(c.AllowedCommands, ", ")
+	}
+	backend := c.AnswerBackend()
+	product, version := c.Scope.Product, c.Scope.Version
+	if product == "" {
+		product = "any"
+	}
+	if version == "" {
+		version = "latest"
+	}
+
+	minConfidence, lowConfidence := "default", string(c.LowConfidence)
+	if c.MinConfidence != nil {
+		minConfidence = strconv.FormatFloat(*c.MinConfidence, 'f', -1, 64) + "%"
+	}
+	if lowConfidence == "" {
+		lowConfidence = "default"
+	}
+
+	summary := fmt.Sprintf("*Channel settings*\n\n- `private`: %t\n- `product`: %s\n- `version`: %s\n- `commands`: %s\n- `backend`: %s\n- `min_confidence`: %s\n- `low_confidence`: %s",
+		c.Private, product, version, commands, backend, minConfidence, lowConfidence)
+
+	if c.UpdatedBy != "" {
+		summary += fmt.Sprintf("\n\nLast updated by <@%s> on %s.", c.UpdatedBy, c.UpdatedAt.UTC().Format(time.RFC1123))
+	}
+
+	return summary
+}
+
+// AnswerBackends maps the name of an answer backend to its Mendable API key.
+type AnswerBackends map[string]string
+
+// ParseAnswerBackends parses the additional answer backends from a JSON object, such as {"edge": "<api-key>"}.
+func ParseAnswerBackends(raw string) (AnswerBackends, error) {
+	backends := AnswerBackends{}
+	if strings.TrimSpace(raw) == "" {
+		return backends, nil
+	}
+
+	if err := json.Unmarshal([]byte(raw), &backends); err != nil {
+		return nil, fmt.Errorf("invalid answer backends: %w", err)
+	}
+
+	for name, key := range backends {
+		if name == DefaultAnswerBackend || strings.TrimSpace(key) == "" {
+			return nil, fmt.Errorf("invalid answer backend %q: the name is reserved or the API key is empty", name)
+		}
+	}
+
+	return backends, nil
+}
+
+// APIKey returns the API key of the backend, or the fallback key for the default backend.
+// The fallback is used for unknown backends so a removed backend doesn't break the channel.
+func (b AnswerBackends) APIKey(name, fallback string) string {
+	if key, ok := b[name]; ok {
+		return key
+	}
+	if name != "" && name != DefaultAnswerBackend {
+		log.Warn().Str("backend", name).Msg("Unknown answer backend. Using the default backend.")
+	}
+	return fallback
+}
+
+// Names returns the names of all answer backends, including the default backend.
+func (b AnswerBackends) Names() []string {
+	names := make([]string, 0, len(b)+1)
+	for name := range b {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return append([]string{DefaultAnswerBackend}, names...)
+}
+
+// ConfigAuthorizer decides who can change the channel configuration.
+// Users in the admin list are always allowed. If the Slack Web API is available, workspace admins
+// and owners, and the creator of the channel, are also allowed. Channel managers aren't allowed unless they're
+// in the admin list, because the Slack Web API doesn't expose them.
+type ConfigAuthorizer struct {
+	admins map[string]bool
+	api    *SlackAPI
+}
+
+// NewConfigAuthorizer returns a new ConfigAuthorizer. The Slack API is optional.
+func NewConfigAuthorizer(adminUsers []string, api *SlackAPI) *ConfigAuthorizer {
+	admins := map[string]bool{}
+	for _, user := range adminUsers {
+		if user = strings.TrimSpace(user); user != "" {
+			admins[user] = true
+		}
+	}
+	return &ConfigAuthorizer{admins, api}
+}
+
+// CanConfigure returns true if the user can change the configuration of the channel.
+func (a *ConfigAuthorizer) CanConfigure(ctx context.Context, userID, channelID string) (bool, error) {
+	if a == nil {
+		return false, nil
+	}
+
+	if a.admins[userID] {
+		return true, nil
+	}
+
+	if a.api == nil {
+		return false, nil
+	}
+
+	user, err := a.api.UserInfo(ctx, userID)
+	if err != nil {
+		return false, err
+	}
+	if user.IsAdmin || user.IsOwner || user.IsPrimaryOwner {
+		return true, nil
+	}
+
+	channel, err := a.api.ConversationInfo(ctx, channelID)
+	if err != nil {
+		return false, err
+	}
+
+	return channel.Creator == userID, nil
+}
+
+// containsString returns true if the slice contains the value.
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,169 @@
+// Copyright (c) Spectro Cloud
+// SPDX-License-Identifier: Apache-2.0
+
+package internal
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"spectrocloud.com/spectromate/mock"
+)
+
+func TestChannelConfigRoundTrip(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	cache := mock.NewMockCache(ctrl)
+	ctx := context.Background()
+	updatedAt := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+
+	minConfidence := 62.5
+	config := ChannelConfig{
+		Private:         true,
+		Scope:           DocsScope{Product: "palette", Version: "4.2"},
+		AllowedCommands: []string{"ask", "help"},
+		Backend:         "edge",
+		MinConfidence:   &minConfidence,
+		LowConfidence:   LowConfidenceWithhold,
+		UpdatedBy:       "U123",
+		UpdatedAt:       updatedAt,
+	}
+
+	var stored map[string]interface{}
+	cache.EXPECT().StoreHashMap(ctx, "docs_bot:channel_config:T123:C123", gomock.Any()).DoAndReturn(
+		func(_ context.Context, _ string, item map[string]interface{}) error {
+			stored = item
+			return nil
+		})
+	require.NoError(t, StoreChannelConfig(ctx, cache, "T123", "C123", config))
+
+	values := map[string]string{}
+	for k, v := range stored {
+		values[k] = v.(string)
+	}
+	cache.EXPECT().GetHashMap(ctx, "docs_bot:channel_config:T123:C123").Return(true, values, nil)
+
+	loaded, err := GetChannelConfig(ctx, cache, "T123", "C123")
+	require.NoError(t, err)
+	assert.Equal(t, config, loaded)
+
+	cache.EXPECT().GetHashMap(ctx, "docs_bot:channel_config:T123:C456").Return(false, nil, nil)
+	loaded, err = GetChannelConfig(ctx, cache, "T123", "C456")
+	require.NoError(t, err)
+	assert.True(t, loaded.IsDefault())
+
+	cache.EXPECT().DeleteKey(ctx, "docs_bot:channel_config:T123:C123").Return(nil)
+	assert.NoError(t, ResetChannelConfig(ctx, cache, "T123", "C123"))
+}
+
+func TestChannelConfigApplySetting(t *testing.T) {
+	commands := []string{"help", "ask", "pask", "config"}
+	backends := AnswerBackends{"edge": "edge-key"}
+
+	var config ChannelConfig
+	require.NoError(t, config.ApplySetting("private", "true", commands, backends))
+	require.NoError(t, config.ApplySetting("product", "Palette", commands, backends))
+	require.NoError(t, config.ApplySetting("version", "v4.2", commands, backends))
+	require.NoError(t, config.ApplySetting("commands", "ask, HELP", commands, backends))
+	require.NoError(t, config.ApplySetting("backend", "edge", commands, backends))
+	require.NoError(t, config.ApplySetting("min_confidence", "40%", commands, backends))
+	require.NoError(t, config.ApplySetting("low_confidence", "Withhold", commands, backends))
+
+	minConfidence := 40.0
+	assert.Equal(t, ChannelConfig{
+		Private:         true,
+		Scope:           DocsScope{Product: "palette", Version: "4.2"},
+		AllowedCommands: []string{"ask", "help"},
+		Backend:         "edge",
+		MinConfidence:   &minConfidence,
+		LowConfidence:   LowConfidenceWithhold,
+	}, config)
+	assert.True(t, config.AllowsCommand("ask"))
+	assert.False(t, config.AllowsCommand("pask"))
+
+	assert.Error(t, config.ApplySetting("private", "maybe", commands, backends))
+	assert.Error(t, config.ApplySetting("product", "pal ette", commands, backends))
+	assert.Error(t, config.ApplySetting("commands", "coffee", commands, backends))
+	assert.Error(t, config.ApplySetting("backend", "unknown", commands, backends))
+	assert.Error(t, config.ApplySetting("color", "blue", commands, backends))
+	assert.Error(t, config.ApplySetting("min_confidence", "120", commands, backends))
+	assert.Error(t, config.ApplySetting("low_confidence", "hide", commands, backends))
+
+	require.NoError(t, config.ApplySetting("backend", DefaultAnswerBackend, commands, backends))
+	require.NoError(t, config.ApplySetting("commands", "", commands, backends))
+	require.NoError(t, config.ApplySetting("min_confidence", "default", commands, backends))
+	require.NoError(t, config.ApplySetting("low_confidence", "default", commands, backends))
+	assert.Empty(t, config.Backend)
+	assert.Nil(t, config.MinConfidence)
+	assert.Empty(t, config.LowConfidence)
+	assert.True(t, config.AllowsCommand("pask"))
+}
+
+func TestAnswerBackends(t *testing.T) {
+	backends, err := ParseAnswerBackends(`{"edge": "edge-key", "vertex": "vertex-key"}`)
+	require.NoError(t, err)
+
+	assert.Equal(t, "edge-key", backends.APIKey("edge", "default-key"))
+	assert.Equal(t, "default-key", backends.APIKey("", "default-key"))
+	assert.Equal(t, "default-key", backends.APIKey("removed", "default-key"))
+	assert.Equal(t, []string{"default", "edge", "vertex"}, backends.Names())
+	assert.Equal(t, "default", ChannelConfig{}.AnswerBackend())
+	assert.Equal(t, "edge", ChannelConfig{Backend: "edge"}.AnswerBackend())
+
+	_, err = ParseAnswerBackends(`{"default": "key"}`)
+	assert.Error(t, err)
+	_, err = ParseAnswerBackends(`{"edge": ""}`)
+	assert.Error(t, err)
+	_, err = ParseAnswerBackends(`not json`)
+	assert.Error(t, err)
+}
+
+func TestConfigAuthorizer(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "Bearer xoxb-test", r.Header.Get("Authorization"))
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Path {
+		case "/users.info":
+			isAdmin := r.URL.Query().Get("user") == "UADMIN"
+			if r.URL.Query().Get("user") == "UERROR" {
+				_, _ = w.Write([]byte(`{"ok": false, "error": "user_not_found"}`))
+				return
+			}
+			_ = json.NewEncoder(w).Encode(map[string]interface{}{"ok": true, "user": map[string]interface{}{"id": r.URL.Query().Get("user"), "is_admin": isAdmin}})
+		case "/conversations.info":
+			_, _ = w.Write([]byte(`{"ok": true, "channel": {"id": "C123", "creator": "UCREATOR"}}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer ts.Close()
+
+	ctx := context.Background()
+	authorizer := NewConfigAuthorizer([]string{"ULISTED", ""}, NewSlackAPI("xoxb-test", ts.URL))
+
+	for user, expected := range map[string]bool{"ULISTED": true, "UADMIN": true, "UCREATOR": true, "UMEMBER": false} {
+		allowed, err := authorizer.CanConfigure(ctx, user, "C123")
+		require.NoError(t, err)
+		assert.Equal(t, expected, allowed, user)
+	}
+
+	allowed, err := authorizer.CanConfigure(ctx, "UERROR", "C123")
+	assert.False(t, allowed)
+	var apiErr *SlackAPIError
+	require.ErrorAs(t, err, &apiErr)
+	assert.Equal(t, "user_not_found", apiErr.Code)
+
+	// Without a bot token only the admin list is used.
+	authorizer = NewConfigAuthorizer([]string{"ULISTED"}, NewSlackAPI("", ts.URL))
+	allowed, err = authorizer.CanConfigure(ctx, "UADMIN", "C123")
+	require.NoError(t, err)
+	assert.False(t, allowed)
+}
@@ -0,0 +1,145 @@
+// Copyright (c) Spectro Cloud
+// SPDX-License-Identifier: Apache-2.0
+
+package internal
+
+import (
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog/log"
+)
+
+// ErrCircuitOpen is returned when a request is rejected because the circuit breaker is open.
+var ErrCircuitOpen = errors.New("circuit breaker is open")
+
+// CircuitState is the state of a circuit breaker.
+type CircuitState int
+
+const (
+	// CircuitClosed allows all requests.
+	CircuitClosed CircuitState = iota
+	// CircuitOpen rejects all requests until the cooldown period has passed.
+	CircuitOpen
+	// CircuitHalfOpen allows a single probe request to check if the dependency recovered.
+	CircuitHalfOpen
+)
+
+// String converts a CircuitState type to a string.
+func (s CircuitState) String() string {
+	switch s {
+	case CircuitClosed:
+		return "closed"
+	case CircuitOpen:
+		return "open"
+	case CircuitHalfOpen:
+		return "half-open"
+	default:
+		return "unknown"
+	}
+}
+
+// CircuitBreaker stops sending requests to a dependency after consecutive failures.
+// Once the cooldown period has passed a single probe request is allowed through.
+// A successful probe closes the circuit, a failed probe opens it again.
+type CircuitBreaker struct {
+	name             string
+	failureThreshold int
+	cooldown         time.Duration
+	mu               sync.Mutex
+	state            CircuitState
+	failures         int
+	openedAt         time.Time
+	probeInFlight    bool
+	now              func() time.Time
+}
+
+// NewCircuitBreaker returns a closed circuit breaker.
+func NewCircuitBreaker(name string, failureThreshold int, cooldown time.Duration) *CircuitBreaker {
+	return &CircuitBreaker{
+		name:             name,
+		failureThreshold: failureThreshold,
+		cooldown:         cooldown,
+		state:            CircuitClosed,
+		now:              time.Now,
+	}
+}
+
+// State returns the current state of the circuit breaker.
+// An open circuit is reported as half-open once the cooldown period has passed.
+func (b *CircuitBreaker) State() CircuitState {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.state == CircuitOpen && b.now().Sub(b.openedAt) >= b.cooldown {
+		return CircuitHalfOpen
+	}
+
+	return b.state
+}
+
+// Allow returns true if a request may be sent.
+func (b *CircuitBreaker) Allow() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	switch b.state {
+	case CircuitOpen:
+		if b.now().Sub(b.openedAt) < b.cooldown {
+			return false
+		}
+		b.state = CircuitHalfOpen
+		b.probeInFlight = true
+		log.Info().Str("circuit", b.name).Msg("Circuit breaker is half-open. Sending a probe request.")
+		return true
+	case CircuitHalfOpen:
+		if b.probeInFlight {
+			return false
+		}
+		b.probeInFlight = true
+		return true
+	default:
+		return true
+	}
+}
+
+// Success records a successful request and closes the circuit.
+func (b *CircuitBreaker) Success() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.state != CircuitClosed {
+		log.Info().Str("circuit", b.name).Msg("Circuit breaker is closed.")
+	}
+
+	b.state = CircuitClosed
+	b.failures = 0
+	b.probeInFlight = false
+}
+
+// Failure records a failed request and opens the circuit once the failure threshold is reached.
+func (b *CircuitBreaker) Failure() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.failures++
+	b.probeInFlight = false
+
+	if b.state == CircuitHalfOpen || b.failures >= b.failureThreshold {
+		if b.state != CircuitOpen {
+			log.Warn().Str("circuit", b.name).Int("failures", b.failures).Msgf("Circuit breaker is open for %s.", b.cooldown)
+		}
+		b.state = CircuitOpen
+		b.openedAt = b.now()
+	}
+}
+
+// Ignore releases a probe request without recording an outcome.
+// This is used when the caller cancels the request or the dependency rate limits it.
+func (b *CircuitBreaker) Ignore() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.probeInFlight = false
+}
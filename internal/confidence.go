@@ -0,0 +1,101 @@
+// Copyright (c) Spectro Cloud
+// SPDX-License-Identifier: Apache-2.0
+
+package internal
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"spectrocloud.com/spectromate/internal/blockkit"
+)
+
+// LowConfidenceMode is how an answer with a confidence level below the threshold is displayed.
+type LowConfidenceMode string
+
+const (
+	// LowConfidenceCaveat displays the answer below a warning.
+	LowConfidenceCaveat LowConfidenceMode = "caveat"
+	// LowConfidenceWithhold only displays the sources of the answer.
+	LowConfidenceWithhold LowConfidenceMode = "withhold"
+)
+
+// ParseLowConfidenceMode parses the low confidence mode. An empty value is the caveat mode.
+func ParseLowConfidenceMode(raw string) (LowConfidenceMode, error) {
+	switch mode := LowConfidenceMode(strings.ToLower(strings.TrimSpace(raw))); mode {
+	case "":
+		return LowConfidenceCaveat, nil
+	case LowConfidenceCaveat, LowConfidenceWithhold:
+		return mode, nil
+	default:
+		return "", fmt.Errorf("invalid low confidence mode %q: must be %s or %s", raw, LowConfidenceCaveat, LowConfidenceWithhold)
+	}
+}
+
+// ParseConfidenceThreshold parses a confidence threshold in percent, such as 60. An empty value disables the threshold.
+func ParseConfidenceThreshold(raw string) (float64, error) {
+	raw = strings.TrimSuffix(strings.TrimSpace(raw), "%")
+	if raw == "" {
+		return 0, nil
+	}
+
+	threshold, err := strconv.ParseFloat(raw, 64)
+	if err != nil || threshold < 0 || threshold > 100 {
+		return 0, fmt.Errorf("invalid confidence threshold %q: must be a percentage between 0 and 100", raw)
+	}
+	return threshold, nil
+}
+
+// ConfidenceGate decides how answers are displayed based on their confidence level.
+// The zero value displays every answer.
+type ConfidenceGate struct {
+	// Threshold is the confidence level, in percent, below which an answer is caveated or withheld. 0 disables the gate.
+	Threshold float64
+	// Mode is how answers below the threshold are displayed.
+	Mode LowConfidenceMode
+}
+
+// ForChannel returns the gate with the overrides of the channel settings, if any.
+func (g ConfidenceGate) ForChannel(config ChannelConfig) ConfidenceGate {
+	if config.MinConfidence != nil {
+		g.Threshold = *config.MinConfidence
+	}
+	if config.LowConfidence != "" {
+		g.Mode = config.LowConfidence
+	}
+	return g
+}
+
+// Check returns how the answer with the confidence level is displayed, or an empty mode if the confidence level
+// is above the threshold. Answers with an unknown confidence level are displayed.
+func (g ConfidenceGate) Check(confidence string) LowConfidenceMode {
+	if g.Threshold <= 0 {
+		return ""
+	}
+
+	value, err := strconv.ParseFloat(strings.TrimSpace(confidence), 64)
+	if err != nil || value >= g.Threshold {
+		return ""
+	}
+
+	if g.Mode == "" {
+		return LowConfidenceCaveat
+	}
+	return g.Mode
+}
+
+// LowConfidenceNotice returns the warning displayed above an answer with a low confidence level.
+// The block ID contains the mode, so the answer actions can read it from the message.
+func LowConfidenceNotice(state AnswerState) blockkit.Block {
+	message := DefaultLowConfidenceCaveatMessage
+	if state.LowConfidence == LowConfidenceWithhold {
+		message = DefaultLowConfidenceWithheldMessage
+	}
+	// The confidence level isn't known if the answer was read from a rated message.
+	level := ""
+	if state.Confidence != "" {
+		level = " (" + state.Confidence + "%)"
+	}
+	return blockkit.Section(blockkit.Markdown(fmt.Sprintf(message, level))).WithBlockID(answerBlockIDLowConfidencePrefix + string(state.LowConfidence))
+}
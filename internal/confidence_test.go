@@ -0,0 +1,83 @@
+// Copyright (c) Spectro Cloud
+// SPDX-License-Identifier: Apache-2.0
+
+package internal
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"spectrocloud.com/spectromate/internal/blockkit"
+)
+
+func TestParseConfidenceThreshold(t *testing.T) {
+	tests := []struct {
+		raw       string
+		threshold float64
+		err       bool
+	}{
+		{"", 0, false},
+		{"60", 60, false},
+		{" 42.5% ", 42.5, false},
+		{"0", 0, false},
+		{"100", 100, false},
+		{"-1", 0, true},
+		{"101", 0, true},
+		{"high", 0, true},
+	}
+
+	for _, test := range tests {
+		threshold, err := ParseConfidenceThreshold(test.raw)
+		if test.err {
+			assert.Error(t, err, test.raw)
+			continue
+		}
+		assert.NoError(t, err, test.raw)
+		assert.Equal(t, test.threshold, threshold, test.raw)
+	}
+}
+
+func TestParseLowConfidenceMode(t *testing.T) {
+	mode, err := ParseLowConfidenceMode("")
+	assert.NoError(t, err)
+	assert.Equal(t, LowConfidenceCaveat, mode)
+
+	mode, err = ParseLowConfidenceMode(" WITHHOLD ")
+	assert.NoError(t, err)
+	assert.Equal(t, LowConfidenceWithhold, mode)
+
+	_, err = ParseLowConfidenceMode("hide")
+	assert.Error(t, err)
+}
+
+func TestConfidenceGate(t *testing.T) {
+	gate := ConfidenceGate{Threshold: 50, Mode: LowConfidenceCaveat}
+
+	assert.Equal(t, LowConfidenceCaveat, gate.Check("49.99"))
+	assert.Empty(t, gate.Check("50.00"))
+	assert.Empty(t, gate.Check("87.00"))
+	// Answers with an unknown confidence level are displayed.
+	assert.Empty(t, gate.Check(""))
+	// The zero value displays every answer.
+	assert.Empty(t, ConfidenceGate{}.Check("1.00"))
+	assert.Equal(t, LowConfidenceCaveat, ConfidenceGate{Threshold: 50}.Check("10.00"))
+
+	// Channels can make the gate stricter, looser, or disable it.
+	stricter, looser, disabled := 80.0, 20.0, 0.0
+	strict := gate.ForChannel(ChannelConfig{MinConfidence: &stricter, LowConfidence: LowConfidenceWithhold})
+	assert.Equal(t, LowConfidenceWithhold, strict.Check("70.00"))
+	assert.Empty(t, gate.ForChannel(ChannelConfig{MinConfidence: &looser}).Check("30.00"))
+	assert.Empty(t, gate.ForChannel(ChannelConfig{MinConfidence: &disabled}).Check("1.00"))
+	assert.Equal(t, gate, gate.ForChannel(ChannelConfig{}))
+}
+
+func TestLowConfidenceNotice(t *testing.T) {
+	notice := LowConfidenceNotice(AnswerState{Confidence: "12.50", LowConfidence: LowConfidenceWithhold}).(*blockkit.SectionBlock)
+	assert.Equal(t, "answer_low_confidence:withhold", notice.BlockID)
+	assert.Contains(t, notice.Text.Text, "(12.50%)")
+
+	// The confidence level isn't known if the answer was read from a rated message.
+	notice = LowConfidenceNotice(AnswerState{LowConfidence: LowConfidenceCaveat}).(*blockkit.SectionBlock)
+	assert.Equal(t, "answer_low_confidence:caveat", notice.BlockID)
+	assert.Contains(t, notice.Text.Text, "*Low confidence.*")
+}
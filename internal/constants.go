@@ -17,8 +17,12 @@ const (
 	ApiPrefixV1 string = ApiPath + ApiVersionV1
 	// SlackPostMessageURL is the URL for the Slack chat.postMessage API.
 	SlackPostMessageURL string = "https://slack.com/api/chat.postMessage"
+	// SlackAPIURL is the base URL for the Slack Web API.
+	SlackAPIURL string = "https://slack.com/api"
 	// SlackDefaultUserErrorMessage is the default error message for the user.
 	SlackDefaultUserErrorMessage string = "An error occured with the help command. Please reach out to `#docs` for assistance."
+	// MendableAPIURL is the base URL for the Mendable API.
+	MendableAPIURL string = "https://api.mendable.ai"
 	// MendableNewConversationURL is the URL for the Mendable new conversation API.
 	MendandableNewConversationURL string = "https://api.mendable.ai/v0/newConversation"
 	// MendableChatQueryURL is the URL for the Mendable chat query API.
@@ -35,14 +39,158 @@ const (
 	ActionsAskModelPositiveFeedbackID string = "ask_model_positive_feedback"
 	// ActionsAskModelNegativeFeedbackID is the ID for the negative feedback action.
 	ActionsAskModelNegativeFeedbackID string = "ask_model_negative_feedback"
+	// ActionsAskModelRemoveFeedbackID is the ID for the action removing the rating of an answer.
+	ActionsAskModelRemoveFeedbackID string = "ask_model_remove_feedback"
+	// ActionsAskShareID is the ID for the action sharing a private answer in the channel.
+	ActionsAskShareID string = "ask_share_answer"
+	// ActionsAskFollowUpID is the ID for the action opening the follow-up question dialog.
+	ActionsAskFollowUpID string = "ask_follow_up"
+	// ActionsAskRegenerateID is the ID for the action asking the question of an answer again.
+	ActionsAskRegenerateID string = "ask_regenerate"
+	// ActionsAskOpenDocsID is the ID for the link button opening the docs of an answer.
+	ActionsAskOpenDocsID string = "ask_open_docs"
+	// ActionsAskShowRestID is the ID for the action showing the rest of an answer that is too long to display in full.
+	ActionsAskShowRestID string = "ask_show_rest"
+	// ActionsAskEscalateID is the ID for the action escalating a question to the support channel.
+	ActionsAskEscalateID string = "ask_escalate"
+	// FollowUpViewCallbackID is the callback ID of the follow-up question dialog.
+	FollowUpViewCallbackID string = "ask_follow_up_view"
+	// FollowUpInputBlockID is the block ID of the question input of the follow-up question dialog.
+	FollowUpInputBlockID string = "follow_up_question"
+	// FollowUpInputActionID is the action ID of the question input of the follow-up question dialog.
+	FollowUpInputActionID string = "follow_up_question_input"
 	// DefaultCacheExpirationPeriod is the default expiration period for the cache.
 	DefaultCacheExpirationPeriod time.Duration = 15 * time.Minute
+	// DefaultMaxHistoryItems is the default maximum number of history items sent to Mendable.
+	DefaultMaxHistoryItems int = 10
+	// DefaultMaxHistoryChars is the default maximum number of history characters sent to Mendable.
+	DefaultMaxHistoryChars int = 12000
+	// DefaultMaxQuestionsPerConversation is the default maximum number of questions per conversation.
+	DefaultMaxQuestionsPerConversation int = 25
+	// DefaultConversationLimitMessage is the message returned when a conversation reaches its question limit.
+	DefaultConversationLimitMessage string = `:raised_hand: You've reached the limit of %d questions for this conversation. Your conversation resets %s after your last question, so please try again later.`
 	// DefaultMendableQueryTimeout is the default timeout for Mendable queries.
 	DefaultMendableQueryTimeout time.Duration = 60 * time.Second
+	// DefaultMendableRequestTimeout is the default timeout for Mendable requests other than queries.
+	DefaultMendableRequestTimeout time.Duration = 15 * time.Second
+	// DefaultSlackRequestTimeout is the default timeout for a single request to the Slack API.
+	DefaultSlackRequestTimeout time.Duration = 10 * time.Second
+	// DefaultAskCommandTimeout is the default timeout for the entire ask command, including the reply to Slack.
+	DefaultAskCommandTimeout time.Duration = 2 * time.Minute
+	// DefaultHTTPClientTimeout is the default timeout for the HTTP client.
+	DefaultHTTPClientTimeout time.Duration = 90 * time.Second
+	// DefaultMendableRetryAfter is the default delay suggested to the user when Mendable rate limits a request without a Retry-After header.
+	DefaultMendableRetryAfter time.Duration = time.Minute
+	// DefaultMendableUnauthorizedMessage is the message returned when Mendable rejects the API key.
+	DefaultMendableUnauthorizedMessage string = `:lock: I'm sorry, I'm unable to reach the docs service because of a configuration issue. Notify the docs team @ #docs.`
+	// DefaultMendableRateLimitedMessage is the message returned when Mendable rate limits a request.
+	DefaultMendableRateLimitedMessage string = `:traffic_light: I'm receiving too many questions right now. Please try again in %s.`
+	// DefaultMendableServerErrorMessage is the message returned when Mendable fails to process a request.
+	DefaultMendableServerErrorMessage string = `:warning: I'm sorry, the docs service is having issues right now. Please try again in a few minutes.`
+	// DefaultRetryAttempts is the default number of attempts for requests to Mendable.
+	DefaultRetryAttempts int = 3
+	// DefaultRetryBaseDelay is the default delay before the first retry. The delay doubles for every attempt.
+	DefaultRetryBaseDelay time.Duration = 500 * time.Millisecond
+	// DefaultRetryMaxDelay is the default maximum delay between retries.
+	DefaultRetryMaxDelay time.Duration = 5 * time.Second
+	// DefaultCircuitBreakerThreshold is the default number of consecutive failures that open the circuit breaker.
+	DefaultCircuitBreakerThreshold int = 5
+	// DefaultCircuitBreakerCooldown is the default period the circuit breaker stays open before allowing a probe request.
+	DefaultCircuitBreakerCooldown time.Duration = 30 * time.Second
+	// DefaultDegradedMessage is the message returned when the docs service is unavailable and requests are not attempted.
+	DefaultDegradedMessage string = `:construction: The docs assistant is degraded because the docs service is not responding. Please try again in a few minutes or browse https://docs.spectrocloud.com in the meantime.`
+	// DefaultUserRateLimit is the default number of questions a user can ask within a period.
+	DefaultUserRateLimit string = "5/1m"
+	// DefaultChannelRateLimit is the default number of questions that can be asked in a channel within a period.
+	DefaultChannelRateLimit string = "20/1m"
+	// DefaultWorkspaceRateLimit is the default number of questions that can be asked in a workspace within a period.
+	DefaultWorkspaceRateLimit string = "60/1m"
+	// DefaultRateLimitedMessage is the message returned when a user exceeds the question rate limit.
+	DefaultRateLimitedMessage string = `:traffic_light: Whoa, slow down! You can ask your next question in %s.`
+	// DefaultHealthCheckTimeout is the default timeout for a single dependency check.
+	DefaultHealthCheckTimeout time.Duration = 5 * time.Second
+	// DefaultHealthCheckCacheTTL is the default period the answer backend check result is reused.
+	DefaultHealthCheckCacheTTL time.Duration = 30 * time.Second
+	// DefaultCacheMonitorInterval is the default period between cache pings while the cache is available.
+	DefaultCacheMonitorInterval time.Duration = 15 * time.Second
+	// DefaultCacheRetryDelay is the initial delay before reconnecting to an unavailable cache.
+	DefaultCacheRetryDelay time.Duration = 1 * time.Second
+	// DefaultWorkerBacklogLimit is the default number of background commands at which the server reports it's not ready.
+	DefaultWorkerBacklogLimit int = 100
+	// DefaultSyncCommandTimeout is the default timeout of commands answered in the HTTP response, within the Slack 3 second limit.
+	DefaultSyncCommandTimeout time.Duration = 2500 * time.Millisecond
+	// DefaultAuditWriteTimeout is the default timeout for writing a single audit record.
+	DefaultAuditWriteTimeout time.Duration = 2 * time.Second
+	// DefaultAuditStream is the default Redis stream of the audit log.
+	DefaultAuditStream string = "docs_bot:audit"
+	// DefaultAuditStreamMaxLen is the approximate number of entries kept in the audit stream.
+	DefaultAuditStreamMaxLen int64 = 100000
+	// DefaultAccessDeniedMessage is the message returned when the access policy denies a command or action.
+	DefaultAccessDeniedMessage string = ":no_entry: Sorry, you don't have access to the docs assistant here. Reach out to the docs team in `#docs` if you think this is a mistake."
+	// DefaultRedactionNoticeMessage is the message returned when sensitive information is removed from a question.
+	DefaultRedactionNoticeMessage string = ":lock: I removed what looks like sensitive information (%s) from your question before sending it to the docs service. Please avoid pasting credentials, keys, or personal data in Slack."
+	// DefaultMaxAnswerFollowUps is the maximum number of follow-up messages used for the rest of a long answer.
+	// Slack accepts up to five messages per response URL.
+	DefaultMaxAnswerFollowUps int = 2
+	// DefaultAnswerContinuedMessage is displayed at the end of an answer message when the answer continues in the next message.
+	DefaultAnswerContinuedMessage string = "The answer continues in the next message."
+	// DefaultAnswerTruncatedMessage is displayed at the end of an answer that is too long to display in full.
+	DefaultAnswerTruncatedMessage string = "The answer is too long to display in full. Select *Show the rest* to read the rest privately, or check the sources for the complete details."
+	// DefaultCommandDisabledMessage is the message returned when a command is disabled in a channel.
+	DefaultCommandDisabledMessage string = "The `%s` command is disabled in this channel. Use `/docs help` to list the available commands."
+	// DefaultConfigForbiddenMessage is the message returned when a user isn't allowed to change the channel settings.
+	DefaultConfigForbiddenMessage string = ":lock: Only workspace admins and the creator of this channel can change its settings."
+	// DefaultConfigUsageMessage is the message returned when the config command is used incorrectly.
+	DefaultConfigUsageMessage string = "Usage: `/docs config show`, `/docs config set <setting>=<value>...` or `/docs config reset`. Available settings: `private`, `product`, `version`, `commands`, `backend`, `min_confidence`, `low_confidence`."
+	// DefaultConfigErrorMessage is the message returned when the channel settings can't be read or stored.
+	DefaultConfigErrorMessage string = ":warning: I'm unable to access the channel settings right now. Please try again later."
+	// DefaultAnswerStateExpirationPeriod is how long the content of an answer is kept for the answer actions, such as rating.
+	DefaultAnswerStateExpirationPeriod time.Duration = 7 * 24 * time.Hour
+	// DefaultInvalidScopeMessage is the message returned when the product or version flag is invalid.
+	DefaultInvalidScopeMessage string = "Sorry, I can't search the docs with that scope: %s. Products and versions may only contain letters, numbers, dots, dashes and underscores."
+	// DefaultAskUsageMessage is the message returned when the ask command is used without a question.
+	DefaultAskUsageMessage string = "Please include a question. For example, `/docs ask how do I enable Prometheus?` Add `--private` to reply privately or `--new` to start a new conversation."
+	// DefaultTimeoutMessage is the message returned when the docs service takes too long to answer.
+	DefaultTimeoutMessage string = `:hourglass: I'm sorry, the docs service took too long to answer your question. Please try again in a few minutes.`
 	// DefaultPositiveRatingMessage is the default message for positive feedback.
 	DefaultPositiveRatingMessage string = `Thank you for providing the :thumbsup: feedback!`
 	// DefaultNegativeRatingMessage is the default message for negative feedback.
 	DefaultNegativeRatingMessage string = `Thank you for providing the :thumbsdown: feedback!`
+	// DefaultChangedRatingMessage is the message displayed when a user changes their rating.
+	DefaultChangedRatingMessage string = `Your rating was changed to %s. Thank you for the feedback!`
+	// DefaultUnchangedRatingMessage is the message displayed when a user rates an answer again with the same rating.
+	DefaultUnchangedRatingMessage string = `You already rated this answer %s. Use the buttons to change your rating.`
+	// DefaultRemovedRatingMessage is the message displayed when a user removes their rating.
+	DefaultRemovedRatingMessage string = `Your rating was removed. You can rate the answer again at any time.`
+	// DefaultSharedAnswerMessage is displayed at the end of an answer shared in the channel.
+	DefaultSharedAnswerMessage string = "Shared by <@%s>."
+	// DefaultAnswerUnavailableMessage is the message returned when an answer action can't find the answer.
+	DefaultAnswerUnavailableMessage string = "Sorry, I can't find this answer anymore. Use `/docs ask` to ask the question again."
+	// DefaultFollowUpUnavailableMessage is the message returned when the follow-up question dialog can't be opened.
+	DefaultFollowUpUnavailableMessage string = "Use `/docs ask` to ask a follow-up question. Your conversation continues where you left off."
+	// DefaultEscalationExpirationPeriod is how long the replies to an escalated question are forwarded to the user.
+	DefaultEscalationExpirationPeriod time.Duration = 30 * 24 * time.Hour
+	// DefaultEscalatedMessage is the message returned when a question is escalated to the support channel.
+	DefaultEscalatedMessage string = ":raising_hand: Your question was sent to the support team in <#%s>. I'll send you a direct message when someone replies."
+	// DefaultAlreadyEscalatedMessage is the message returned when a user escalates the same question again.
+	DefaultAlreadyEscalatedMessage string = "You already sent this question to the support team in <#%s>. I'll send you a direct message when someone replies."
+	// DefaultEscalationUnavailableMessage is the message returned when the question can't be escalated.
+	DefaultEscalationUnavailableMessage string = "Sorry, I can't reach the support team right now. Please reach out to the docs team in `#docs`."
+	// DefaultEscalationHintMessage is added to the response to a negative rating when the question can be escalated.
+	DefaultEscalationHintMessage string = "Use the *Escalate* button to ask the support team."
+	// DefaultEscalationReplyMessage is the direct message sent to the user when someone replies to their escalated question.
+	DefaultEscalationReplyMessage string = ":speech_balloon: <@%s> replied to your question in <#%s>:"
+	// DefaultLowConfidenceCaveatMessage is displayed above an answer with a confidence level below the threshold.
+	// The confidence level is added after "Low confidence" if it's known.
+	DefaultLowConfidenceCaveatMessage string = ":warning: *Low confidence%s.* This answer may be inaccurate. Verify it in the sources below."
+	// DefaultLowConfidenceWithheldMessage replaces an answer with a confidence level below the threshold.
+	DefaultLowConfidenceWithheldMessage string = ":warning: *Low confidence%s.* I'm not confident enough to answer this question. Review the sources below, or try rephrasing your question."
+	// DefaultMaxSources is the default maximum number of sources displayed with an answer.
+	DefaultMaxSources int = 5
+	// DefaultSourceLabelLength is the maximum length, in characters, of the title and section of a source.
+	DefaultSourceLabelLength int = 100
+	// DefaultSourceSnippetLength is the maximum length, in characters, of the snippet displayed for each source.
+	DefaultSourceSnippetLength int = 160
 	// DefaultNoSourcesIdentifiedMessage is the default message for when no sources are identified.
 	DefaultNoSourcesIdentifiedMessage string = `:mag: Unable to identify a specific documentation URL.`
 	// DefaultUserAgent is the default user agent for the HTTP client.
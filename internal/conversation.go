@@ -0,0 +1,63 @@
+// Copyright (c) Spectro Cloud
+// SPDX-License-Identifier: Apache-2.0
+
+package internal
+
+import (
+	"time"
+)
+
+// ConversationConfig controls how long a conversation is kept in the cache and
+// how much of its history is forwarded to Mendable.
+// A zero value for any of the limits disables that limit.
+type ConversationConfig struct {
+	// TTL is the expiration period of a conversation in the cache.
+	TTL time.Duration
+	// MaxHistoryItems is the maximum number of question and answer pairs kept in the history.
+	MaxHistoryItems int
+	// MaxHistoryChars is the maximum number of characters kept in the history.
+	MaxHistoryChars int
+	// MaxQuestions is the maximum number of questions allowed per conversation.
+	MaxQuestions int
+}
+
+// CacheTTL returns the conversation TTL or the default expiration period if the TTL is not set.
+func (c ConversationConfig) CacheTTL() time.Duration {
+	if c.TTL <= 0 {
+		return DefaultCacheExpirationPeriod
+	}
+
+	return c.TTL
+}
+
+// LimitReached returns true if the conversation has reached the maximum number of questions.
+func (c ConversationConfig) LimitReached(counter int) bool {
+	return c.MaxQuestions > 0 && counter >= c.MaxQuestions
+}
+
+// TruncateHistory drops the oldest history items until the history fits within
+// the maximum number of items and the maximum number of characters.
+// The most recent items are always preserved first.
+func TruncateHistory(history []HistoryItems, maxItems, maxChars int) []HistoryItems {
+
+	if maxItems > 0 && len(history) > maxItems {
+		history = history[len(history)-maxItems:]
+	}
+
+	if maxChars <= 0 {
+		return history
+	}
+
+	total := 0
+	start := len(history)
+	for i := len(history) - 1; i >= 0; i-- {
+		size := len(history[i].Prompt) + len(history[i].Response)
+		if total+size > maxChars {
+			break
+		}
+		total += size
+		start = i
+	}
+
+	return history[start:]
+}
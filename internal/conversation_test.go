@@ -0,0 +1,46 @@
+// Copyright (c) Spectro Cloud
+// SPDX-License-Identifier: Apache-2.0
+
+package internal
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTruncateHistory(t *testing.T) {
+	history := []HistoryItems{
+		{Prompt: "first", Response: "one"},
+		{Prompt: "second", Response: "two"},
+		{Prompt: "third", Response: "three"},
+	}
+
+	// No limits keeps the full history
+	result := TruncateHistory(history, 0, 0)
+	assert.Equal(t, history, result)
+
+	// Item limit keeps the most recent items
+	result = TruncateHistory(history, 2, 0)
+	assert.Equal(t, history[1:], result)
+
+	// Character limit drops the oldest items first
+	result = TruncateHistory(history, 0, len("third")+len("three")+len("second")+len("two"))
+	assert.Equal(t, history[1:], result)
+
+	// Character limit smaller than the latest item drops everything
+	result = TruncateHistory(history, 0, 3)
+	assert.Empty(t, result)
+}
+
+func TestConversationConfig(t *testing.T) {
+	config := ConversationConfig{}
+	assert.Equal(t, DefaultCacheExpirationPeriod, config.CacheTTL())
+	assert.False(t, config.LimitReached(100))
+
+	config = ConversationConfig{TTL: 5 * time.Minute, MaxQuestions: 3}
+	assert.Equal(t, 5*time.Minute, config.CacheTTL())
+	assert.False(t, config.LimitReached(2))
+	assert.True(t, config.LimitReached(3))
+}
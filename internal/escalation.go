@@ -0,0 +1,208 @@
+// Copyright (c) Spectro Cloud
+// SPDX-License-Identifier: Apache-2.0
+
+package internal
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"spectrocloud.com/spectromate/internal/blockkit"
+)
+
+// claimEscalationScript claims the escalation of an answer by a user, so concurrent clicks post the question once.
+// It returns 1 and the support channel if the escalation was claimed, or 0 and the support channel of the
+// existing escalation. KEYS[1] is the escalation key of the answer, ARGV[1] the support channel, and
+// ARGV[2] the TTL in seconds.
+const claimEscalationScript = `
+if redis.call('HSETNX', KEYS[1], 'support_channel', ARGV[1]) == 1 then
+	redis.call('EXPIRE', KEYS[1], ARGV[2])
+	return {1, ARGV[1]}
+end
+return {0, redis.call('HGET', KEYS[1], 'support_channel') or ''}
+`
+
+// EscalationConfig is the support channel questions are escalated to.
+type EscalationConfig struct {
+	// Channel is the ID of the support channel. Escalation is disabled if it's empty.
+	Channel string
+}
+
+// Enabled returns true if questions can be escalated.
+func (c EscalationConfig) Enabled() bool {
+	return c.Channel != ""
+}
+
+// Escalation is a question escalated to the support channel. The replies in the thread of the escalation
+// message are sent to the user who escalated the question.
+type Escalation struct {
+	MessageID      string
+	UserID         string
+	ChannelID      string
+	SupportChannel string
+	ThreadTS       string
+	CreatedAt      time.Time
+}
+
+// escalationThreadKey returns the cache key of the escalation posted in the support channel with the timestamp.
+func escalationThreadKey(supportChannel, threadTS string) string {
+	return fmt.Sprintf("docs_bot:escalation:thread:%s:%s", supportChannel, threadTS)
+}
+
+// escalationAnswerKey returns the cache key of the escalation of the answer by the user.
+func escalationAnswerKey(messageID, userID string) string {
+	return fmt.Sprintf("docs_bot:escalation:answer:%s:%s", messageID, userID)
+}
+
+// StoreEscalation stores the escalation by thread, to forward the replies, and by answer and user,
+// so a user escalates an answer once. The escalation expires after the TTL.
+func StoreEscalation(ctx context.Context, cache Cache, escalation Escalation, ttl time.Duration) error {
+	if cache == nil {
+		return ErrCacheUnavailable
+	}
+
+	item := map[string]interface{}{
+		"message_id":      escalation.MessageID,
+		"user_id":         escalation.UserID,
+		"channel_id":      escalation.ChannelID,
+		"support_channel": escalation.SupportChannel,
+		"thread_ts":       escalation.ThreadTS,
+		"created_at":      escalation.CreatedAt.UTC().Format(time.RFC3339),
+	}
+
+	for _, key := range []string{escalationThreadKey(escalation.SupportChannel, escalation.ThreadTS), escalationAnswerKey(escalation.MessageID, escalation.UserID)} {
+		if err := cache.StoreHashMap(ctx, key, item); err != nil {
+			return err
+		}
+		if err := cache.ExpireKey(ctx, key, ttl); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// ClaimEscalation reserves the escalation of the answer by the user before the question is posted.
+// The returned bool is false if the user already escalated the answer, with the support channel of that escalation.
+// The claim expires after the TTL, and is replaced by StoreEscalation once the question is posted.
+func ClaimEscalation(ctx context.Context, cache Cache, messageID, userID, supportChannel string, ttl time.Duration) (bool, string, error) {
+	if cache == nil {
+		return false, "", ErrCacheUnavailable
+	}
+
+	result, err := cache.EvalScript(ctx, claimEscalationScript, []string{escalationAnswerKey(messageID, userID)}, supportChannel, int64(ttl.Seconds()))
+	if err != nil {
+		return false, "", err
+	}
+
+	values, ok := result.([]interface{})
+	if !ok || len(values) != 2 {
+		return false, "", fmt.Errorf("unexpected escalation claim script result: %v", result)
+	}
+
+	claimed, _ := values[0].(int64)
+	existing, _ := values[1].(string)
+	return claimed == 1, existing, nil
+}
+
+// ReleaseEscalation removes the claim of the escalation of the answer by the user, so the question can be
+// escalated again after it couldn't be posted.
+func ReleaseEscalation(ctx context.Context, cache Cache, messageID, userID string) error {
+	if cache == nil {
+		return ErrCacheUnavailable
+	}
+	return cache.DeleteKey(ctx, escalationAnswerKey(messageID, userID))
+}
+
+// GetEscalationByThread returns the escalation posted in the support channel with the thread timestamp.
+// The returned bool is false if the thread isn't an escalation or the escalation expired.
+func GetEscalationByThread(ctx context.Context, cache Cache, supportChannel, threadTS string) (Escalation, bool, error) {
+	return getEscalation(ctx, cache, escalationThreadKey(supportChannel, threadTS))
+}
+
+// GetEscalationByAnswer returns the escalation of the answer by the user.
+// The returned bool is false if the user didn't escalate the answer or the escalation expired.
+func GetEscalationByAnswer(ctx context.Context, cache Cache, messageID, userID string) (Escalation, bool, error) {
+	return getEscalation(ctx, cache, escalationAnswerKey(messageID, userID))
+}
+
+func getEscalation(ctx context.Context, cache Cache, key string) (Escalation, bool, error) {
+	if cache == nil {
+		return Escalation{}, false, ErrCacheUnavailable
+	}
+
+	found, values, err := cache.GetHashMap(ctx, key)
+	if err != nil || !found {
+		return Escalation{}, false, err
+	}
+
+	escalation := Escalation{
+		MessageID:      values["message_id"],
+		UserID:         values["user_id"],
+		ChannelID:      values["channel_id"],
+		SupportChannel: values["support_channel"],
+		ThreadTS:       values["thread_ts"],
+	}
+	if createdAt, err := time.Parse(time.RFC3339, values["created_at"]); err == nil {
+		escalation.CreatedAt = createdAt
+	}
+
+	return escalation, true, nil
+}
+
+// EscalationMessage returns the message posted in the support channel with the question, answer, sources,
+// and confidence level of the answer, and the user who escalated it.
+func EscalationMessage(state AnswerState, userID, channelID string) *blockkit.Message {
+	asker := fmt.Sprintf("*Asked by* <@%s>", userID)
+	if channelID != "" {
+		asker += fmt.Sprintf(" in <#%s>", channelID)
+	}
+
+	message := blockkit.NewMessage(false,
+		blockkit.Header(TruncateText(":raising_hand: "+state.Title, blockkit.MaxHeaderTextLength)),
+		blockkit.Section(blockkit.Markdown(asker)),
+		blockkit.Section(blockkit.Markdown(TruncateText(state.Question, blockkit.MaxSectionTextLength))),
+		blockkit.Divider(),
+		blockkit.Section(blockkit.Markdown(TruncateText(state.Answer, blockkit.MaxSectionTextLength))),
+		blockkit.Divider(),
+	)
+	if state.Links != "" {
+		message.Add(blockkit.Section(blockkit.Markdown(TruncateText(state.Links, blockkit.MaxSectionTextLength))))
+	}
+	if state.Confidence != "" {
+		message.Add(blockkit.Fields(blockkit.Markdown("*Answer Confidence Level:* " + state.Confidence + "%")))
+	}
+	message.Add(blockkit.Context(blockkit.Markdown(fmt.Sprintf("Reply in this thread to answer <@%s>. Every reply is sent to them in a direct message.", userID))))
+
+	return message.WithText(TruncateText(fmt.Sprintf("Question escalated by <@%s>: %s", userID, state.Query()), blockkit.MaxSectionTextLength))
+}
+
+// EscalationReplyMessage returns the direct message notifying the user of a reply to their escalated question.
+func EscalationReplyMessage(escalation Escalation, reply SlackMessageEvent) *blockkit.Message {
+	intro := fmt.Sprintf(DefaultEscalationReplyMessage, reply.User, escalation.SupportChannel)
+
+	quoted := "> " + strings.ReplaceAll(strings.TrimSpace(reply.Text), "\n", "\n> ")
+	return blockkit.NewMessage(false,
+		blockkit.Section(blockkit.Markdown(intro)),
+		blockkit.Section(blockkit.Markdown(TruncateText(quoted, blockkit.MaxSectionTextLength))),
+	).WithText(intro)
+}
+
+// ForwardEscalationReply sends a reply in the thread of an escalation to the user who escalated the question.
+// The returned bool is false if the thread isn't an escalation, such as a regular thread in the support channel.
+func ForwardEscalationReply(ctx context.Context, cache Cache, api *SlackAPI, reply SlackMessageEvent) (bool, error) {
+	escalation, found, err := GetEscalationByThread(ctx, cache, reply.Channel, reply.ThreadTS)
+	if err != nil || !found {
+		return false, err
+	}
+
+	// The user is not notified of their own replies.
+	if reply.User == escalation.UserID {
+		return true, nil
+	}
+
+	_, err = api.PostMessage(ctx, escalation.UserID, "", EscalationReplyMessage(escalation, reply))
+	return true, err
+}
@@ -0,0 +1,183 @@
+// Copyright (c) Spectro Cloud
+// SPDX-License-Identifier: Apache-2.0
+
+package internal
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"spectrocloud.com/spectromate/mock"
+)
+
+func TestEscalationRoundTrip(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	cache := mock.NewMockCache(ctrl)
+	ctx := context.Background()
+
+	escalation := Escalation{
+		MessageID:      "123",
+		UserID:         "U123",
+		ChannelID:      "C123",
+		SupportChannel: "CSUPPORT",
+		ThreadTS:       "1700000000.000100",
+		CreatedAt:      time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC),
+	}
+
+	stored := map[string]map[string]string{}
+	cache.EXPECT().StoreHashMap(ctx, gomock.Any(), gomock.Any()).DoAndReturn(
+		func(_ context.Context, key string, item map[string]interface{}) error {
+			stored[key] = map[string]string{}
+			for k, v := range item {
+				stored[key][k] = v.(string)
+			}
+			return nil
+		}).Times(2)
+	cache.EXPECT().ExpireKey(ctx, gomock.Any(), DefaultEscalationExpirationPeriod).Return(nil).Times(2)
+	require.NoError(t, StoreEscalation(ctx, cache, escalation, DefaultEscalationExpirationPeriod))
+
+	threadKey := "docs_bot:escalation:thread:CSUPPORT:1700000000.000100"
+	answerKey := "docs_bot:escalation:answer:123:U123"
+	require.Contains(t, stored, threadKey)
+	require.Contains(t, stored, answerKey)
+
+	cache.EXPECT().GetHashMap(ctx, threadKey).Return(true, stored[threadKey], nil)
+	loaded, found, err := GetEscalationByThread(ctx, cache, "CSUPPORT", "1700000000.000100")
+	require.NoError(t, err)
+	assert.True(t, found)
+	assert.Equal(t, escalation, loaded)
+
+	cache.EXPECT().GetHashMap(ctx, answerKey).Return(true, stored[answerKey], nil)
+	_, found, err = GetEscalationByAnswer(ctx, cache, "123", "U123")
+	require.NoError(t, err)
+	assert.True(t, found)
+
+	cache.EXPECT().GetHashMap(ctx, "docs_bot:escalation:answer:123:U456").Return(false, nil, nil)
+	_, found, err = GetEscalationByAnswer(ctx, cache, "123", "U456")
+	require.NoError(t, err)
+	assert.False(t, found)
+
+	assert.ErrorIs(t, StoreEscalation(ctx, nil, escalation, DefaultEscalationExpirationPeriod), ErrCacheUnavailable)
+}
+
+func TestClaimEscalation(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	cache := mock.NewMockCache(ctrl)
+	ctx := context.Background()
+	answerKey := "docs_bot:escalation:answer:123:U123"
+	ttl := int64(DefaultEscalationExpirationPeriod.Seconds())
+
+	cache.EXPECT().EvalScript(ctx, claimEscalationScript, []string{answerKey}, "CSUPPORT", ttl).Return([]interface{}{int64(1), "CSUPPORT"}, nil)
+	claimed, existing, err := ClaimEscalation(ctx, cache, "123", "U123", "CSUPPORT", DefaultEscalationExpirationPeriod)
+	require.NoError(t, err)
+	assert.True(t, claimed)
+	assert.Equal(t, "CSUPPORT", existing)
+
+	// A second claim returns the support channel of the first escalation.
+	cache.EXPECT().EvalScript(ctx, claimEscalationScript, []string{answerKey}, "CNEW", ttl).Return([]interface{}{int64(0), "CSUPPORT"}, nil)
+	claimed, existing, err = ClaimEscalation(ctx, cache, "123", "U123", "CNEW", DefaultEscalationExpirationPeriod)
+	require.NoError(t, err)
+	assert.False(t, claimed)
+	assert.Equal(t, "CSUPPORT", existing)
+
+	cache.EXPECT().EvalScript(ctx, claimEscalationScript, []string{answerKey}, "CSUPPORT", ttl).Return("OK", nil)
+	_, _, err = ClaimEscalation(ctx, cache, "123", "U123", "CSUPPORT", DefaultEscalationExpirationPeriod)
+	assert.Error(t, err)
+
+	cache.EXPECT().DeleteKey(ctx, answerKey).Return(nil)
+	require.NoError(t, ReleaseEscalation(ctx, cache, "123", "U123"))
+
+	_, _, err = ClaimEscalation(ctx, nil, "123", "U123", "CSUPPORT", DefaultEscalationExpirationPeriod)
+	assert.ErrorIs(t, err, ErrCacheUnavailable)
+}
+
+func TestEscalationMessage(t *testing.T) {
+	state := AnswerState{
+		Title:      "Docs Answer",
+		Question:   FormatAnswerQuestion("How do I install Palette?"),
+		Answer:     DefaultNotFoundResponse,
+		Links:      DefaultNoSourcesIdentifiedMessage,
+		Confidence: "12.50",
+	}
+
+	message := EscalationMessage(state, "U123", "C123")
+	require.NoError(t, message.Validate())
+	assert.Contains(t, message.Text, "How do I install Palette?")
+
+	data, err := message.JSON()
+	require.NoError(t, err)
+	assert.Contains(t, string(data), `*Asked by* \u003c@U123\u003e in \u003c#C123\u003e`)
+	assert.Contains(t, string(data), "*Answer Confidence Level:* 12.50%")
+}
+
+func TestSlackMessageEventIsThreadReply(t *testing.T) {
+	reply := SlackMessageEvent{Type: "message", Channel: "CSUPPORT", User: "U456", Text: "Try this.", TS: "2.0", ThreadTS: "1.0"}
+	assert.True(t, reply.IsThreadReply())
+
+	parent := reply
+	parent.TS = parent.ThreadTS
+	assert.False(t, parent.IsThreadReply())
+
+	bot := reply
+	bot.BotID = "B123"
+	assert.False(t, bot.IsThreadReply())
+
+	edited := reply
+	edited.Subtype = "message_changed"
+	assert.False(t, edited.IsThreadReply())
+
+	assert.False(t, SlackMessageEvent{Type: "message", User: "U456", TS: "2.0"}.IsThreadReply())
+}
+
+func TestForwardEscalationReply(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	var posted []map[string]interface{}
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body map[string]interface{}
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+		posted = append(posted, body)
+		_, _ = w.Write([]byte(`{"ok": true, "ts": "3.0"}`))
+	}))
+	defer ts.Close()
+
+	cache := mock.NewMockCache(ctrl)
+	ctx := context.Background()
+	api := NewSlackAPI("xoxb-test", ts.URL)
+
+	escalation := map[string]string{"message_id": "123", "user_id": "U123", "support_channel": "CSUPPORT", "thread_ts": "1.0"}
+	cache.EXPECT().GetHashMap(ctx, "docs_bot:escalation:thread:CSUPPORT:1.0").Return(true, escalation, nil).Times(2)
+
+	// The reply is sent to the user in a direct message.
+	escalated, err := ForwardEscalationReply(ctx, cache, api, SlackMessageEvent{Type: "message", Channel: "CSUPPORT", User: "U456", Text: "Try this.\nThen that.", TS: "2.0", ThreadTS: "1.0"})
+	require.NoError(t, err)
+	assert.True(t, escalated)
+	require.Len(t, posted, 1)
+	assert.Equal(t, "U123", posted[0]["channel"])
+	quote := posted[0]["blocks"].([]interface{})[1].(map[string]interface{})["text"].(map[string]interface{})["text"]
+	assert.Equal(t, "> Try this.\n> Then that.", quote)
+
+	// The user isn't notified of their own replies.
+	escalated, err = ForwardEscalationReply(ctx, cache, api, SlackMessageEvent{Type: "message", Channel: "CSUPPORT", User: "U123", Text: "Thanks!", TS: "4.0", ThreadTS: "1.0"})
+	require.NoError(t, err)
+	assert.True(t, escalated)
+	assert.Len(t, posted, 1)
+
+	// Other threads of the support channel are ignored.
+	cache.EXPECT().GetHashMap(ctx, "docs_bot:escalation:thread:CSUPPORT:9.0").Return(false, nil, nil)
+	escalated, err = ForwardEscalationReply(ctx, cache, api, SlackMessageEvent{Type: "message", Channel: "CSUPPORT", User: "U456", Text: "Hi", TS: "10.0", ThreadTS: "9.0"})
+	require.NoError(t, err)
+	assert.False(t, escalated)
+}
@@ -0,0 +1,73 @@
+// Copyright (c) Spectro Cloud
+// SPDX-License-Identifier: Apache-2.0
+
+package internal
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// CheckResult is the outcome of a dependency check.
+type CheckResult struct {
+	Err       error
+	Latency   time.Duration
+	CheckedAt time.Time
+}
+
+// CachedCheck runs a dependency check at most once per TTL and returns the cached result in between.
+// This prevents readiness probes from hammering external services.
+type CachedCheck struct {
+	check func(ctx context.Context) error
+	ttl   time.Duration
+	mu    sync.Mutex
+	last  *CheckResult
+	now   func() time.Time
+}
+
+// NewCachedCheck returns a new CachedCheck.
+func NewCachedCheck(ttl time.Duration, check func(ctx context.Context) error) *CachedCheck {
+	return &CachedCheck{check: check, ttl: ttl, now: time.Now}
+}
+
+// Run returns the cached result if it's still fresh, otherwise it runs the check.
+func (c *CachedCheck) Run(ctx context.Context) CheckResult {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.last != nil && c.now().Sub(c.last.CheckedAt) < c.ttl {
+		return *c.last
+	}
+
+	start := c.now()
+	err := c.check(ctx)
+	c.last = &CheckResult{
+		Err:       err,
+		Latency:   c.now().Sub(start),
+		CheckedAt: start,
+	}
+
+	return *c.last
+}
+
+// MendableReachable checks whether the Mendable API can be reached.
+// Any HTTP response counts as reachable, only network errors fail the check.
+func MendableReachable(ctx context.Context) error {
+	ctx, cancel := context.WithTimeout(ctx, DefaultHealthCheckTimeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodHead, MendableAPIURL, nil)
+	if err != nil {
+		return err
+	}
+
+	resp, err := DefaultHTTPClient().Do(req)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+
+	return nil
+}
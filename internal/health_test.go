@@ -0,0 +1,57 @@
+// Copyright (c) Spectro Cloud
+// SPDX-License-Identifier: Apache-2.0
+
+package internal
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCachedCheck(t *testing.T) {
+	now := time.Now()
+	calls := 0
+	check := NewCachedCheck(time.Minute, func(ctx context.Context) error {
+		calls++
+		return errors.New("unreachable")
+	})
+	check.now = func() time.Time { return now }
+
+	result := check.Run(context.Background())
+	assert.EqualError(t, result.Err, "unreachable")
+
+	// The cached result is reused within the TTL
+	check.Run(context.Background())
+	assert.Equal(t, 1, calls)
+
+	// The check runs again once the TTL expired
+	now = now.Add(time.Minute)
+	check.Run(context.Background())
+	assert.Equal(t, 2, calls)
+}
+
+func TestWorkerTracker(t *testing.T) {
+	workers := NewWorkerTracker(2)
+	release := make(chan struct{})
+	done := make(chan struct{}, 2)
+
+	for i := 0; i < 2; i++ {
+		workers.Go(func() {
+			<-release
+			done <- struct{}{}
+		})
+	}
+
+	assert.Equal(t, int64(2), workers.Active())
+	assert.True(t, workers.Saturated())
+
+	close(release)
+	<-done
+	<-done
+	assert.Eventually(t, func() bool { return workers.Active() == 0 }, time.Second, time.Millisecond)
+	assert.False(t, workers.Saturated())
+}
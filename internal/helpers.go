@@ -4,9 +4,15 @@
 package internal
 
 import (
+	"context"
+	"errors"
+	"math"
+	"net"
 	"net/http"
 	"os"
 	"strconv"
+	"strings"
+	"time"
 
 	"github.com/rs/zerolog/log"
 )
@@ -29,15 +35,67 @@ func StringToInt64(s string) int64 {
 	return i
 }
 
+// StringToDuration parses a duration string such as "15m" or "1h30m".
+// The fallback value is returned if the string is empty or invalid.
+func StringToDuration(s string, fallback time.Duration) time.Duration {
+	if s == "" {
+		return fallback
+	}
+
+	d, err := time.ParseDuration(s)
+	if err != nil {
+		log.Error().Err(err).Msgf("Error converting %s string to a duration. Using the default value %s", s, fallback)
+		return fallback
+	}
+
+	return d
+}
+
+// FormatDuration returns a short human readable representation of a duration, such as "15m" or "1h30m".
+// The duration is rounded up to the nearest second.
+func FormatDuration(d time.Duration) string {
+	if d < time.Second {
+		d = time.Second
+	}
+	// Fractions of a second are rounded up, so users aren't told to retry too early.
+	d = time.Duration(math.Ceil(d.Seconds())) * time.Second
+
+	s := d.String()
+	if strings.HasSuffix(s, "m0s") {
+		s = strings.TrimSuffix(s, "0s")
+	}
+	if strings.HasSuffix(s, "h0m") {
+		s = strings.TrimSuffix(s, "0m")
+	}
+
+	return s
+}
+
 // DefaultHTTPClient returns a default HTTP client with proxy support.
+// The client timeout is a safety net, callers are expected to set a deadline on the request context.
 func DefaultHTTPClient() *http.Client {
 	return &http.Client{
+		Timeout: DefaultHTTPClientTimeout,
 		Transport: &http.Transport{
 			Proxy: http.ProxyFromEnvironment,
 		},
 	}
 }
 
+// IsTimeoutError returns true if the error was caused by a deadline being exceeded.
+func IsTimeoutError(err error) bool {
+	if err == nil {
+		return false
+	}
+
+	if errors.Is(err, context.DeadlineExceeded) {
+		return true
+	}
+
+	var netErr net.Error
+	return errors.As(err, &netErr) && netErr.Timeout()
+}
+
 // SetUserAgent sets the User-Agent header on the request.
 func SetUserAgent(version string) string {
 
@@ -4,8 +4,12 @@
 package internal
 
 import (
+	"context"
+	"errors"
+	"fmt"
 	"os"
 	"testing"
+	"time"
 )
 
 func TestGetenv(t *testing.T) {
@@ -60,3 +64,51 @@ func TestSetUserAgent(t *testing.T) {
 		t.Errorf("SetUserAgent returned %q, expected %q", result, expected)
 	}
 }
+
+func TestStringToDuration(t *testing.T) {
+	if result := StringToDuration("", time.Minute); result != time.Minute {
+		t.Errorf("StringToDuration(%q) = %s; expected %s", "", result, time.Minute)
+	}
+
+	if result := StringToDuration("30m", time.Minute); result != 30*time.Minute {
+		t.Errorf("StringToDuration(%q) = %s; expected %s", "30m", result, 30*time.Minute)
+	}
+
+	if result := StringToDuration("invalid", time.Minute); result != time.Minute {
+		t.Errorf("StringToDuration(%q) = %s; expected %s", "invalid", result, time.Minute)
+	}
+}
+
+func TestFormatDuration(t *testing.T) {
+	cases := map[time.Duration]string{
+		15 * time.Minute:                      "15m",
+		90 * time.Minute:                      "1h30m",
+		2 * time.Hour:                         "2h",
+		42 * time.Second:                      "42s",
+		100 * time.Millisecond:                "1s",
+		time.Minute + 30*time.Second:          "1m30s",
+		1400 * time.Millisecond:               "2s",
+		59*time.Second + time.Millisecond:     "1m",
+		90*time.Minute + 100*time.Millisecond: "1h30m1s",
+	}
+
+	for input, expected := range cases {
+		if result := FormatDuration(input); result != expected {
+			t.Errorf("FormatDuration(%s) = %q; expected %q", input, result, expected)
+		}
+	}
+}
+
+func TestIsTimeoutError(t *testing.T) {
+	if IsTimeoutError(nil) {
+		t.Errorf("IsTimeoutError(nil) = true; expected false")
+	}
+
+	if !IsTimeoutError(fmt.Errorf("wrapped: %w", context.DeadlineExceeded)) {
+		t.Errorf("IsTimeoutError(context.DeadlineExceeded) = false; expected true")
+	}
+
+	if IsTimeoutError(errors.New("connection refused")) {
+		t.Errorf("IsTimeoutError(connection refused) = true; expected false")
+	}
+}
@@ -27,17 +27,20 @@ func CreateNewConversation(ctx context.Context, apiKey, apiURL string) (int64, e
 		return -1, err
 	}
 
-	req, err := http.NewRequest("POST", apiURL, bytes.NewBuffer(jsonBody))
+	ctx, cancel := context.WithTimeout(ctx, DefaultMendableRequestTimeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, "POST", apiURL, bytes.NewBuffer(jsonBody))
 	if err != nil {
 		log.Debug().Err(err).Msg("error creating new conversation request")
 		return -1, err
 	}
 
 	req.Header.Set("Content-Type", "application/json")
+	// Creating a conversation has no side effects besides an unused ID, so it's safe to retry.
+	MarkIdempotent(req)
 
-	client := DefaultHTTPClient()
-
-	resp, err := client.Do(req)
+	resp, err := mendableClient.Do(req)
 	if err != nil {
 		log.Debug().Err(err).Msg("an error occured during the HTTP request")
 		LogError(err)
@@ -45,10 +48,32 @@ func CreateNewConversation(ctx context.Context, apiKey, apiURL string) (int64, e
 	}
 	defer resp.Body.Close()
 
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		log.Debug().Err(err).Msg("error reading new conversation response body")
+		LogError(err)
+		return -1, err
+	}
+
+	err = checkMendableResponse(resp, body)
+	if err != nil {
+		log.Debug().Err(err).Msg("error while creating a new conversation")
+		LogError(err)
+		return -1, err
+	}
+
 	var responseBody MendableNewConversationResponse
-	err = json.NewDecoder(resp.Body).Decode(&responseBody)
+	err = json.Unmarshal(body, &responseBody)
 	if err != nil {
 		log.Debug().Err(err).Msg("error decoding response body")
+		err = newMalformedResponseError(resp.StatusCode, err.Error())
+		LogError(err)
+		return -1, err
+	}
+
+	if responseBody.ConversationID <= 0 {
+		err = newMalformedResponseError(resp.StatusCode, "missing conversation ID")
+		log.Debug().Err(err).Msg("the new conversation response did not include a conversation ID")
 		LogError(err)
 		return -1, err
 	}
@@ -73,7 +98,10 @@ func SendModelRating(ctx context.Context, messageID int, rateScore MendableRatin
 		return err
 	}
 
-	req, err := http.NewRequest("POST", apiURL, bytes.NewBuffer(jsonBody))
+	ctx, cancel := context.WithTimeout(ctx, DefaultMendableRequestTimeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, "POST", apiURL, bytes.NewBuffer(jsonBody))
 	if err != nil {
 		log.Debug().Err(err).Msg("error creating the model rating request")
 		return err
@@ -81,9 +109,10 @@ func SendModelRating(ctx context.Context, messageID int, rateScore MendableRatin
 
 	req.Header.Set("Content-Type", "application/json")
 	req.Header.Set("User-Agent", GetUserAgentString(&version))
-	client := DefaultHTTPClient()
+	// Sending the same rating twice results in the same rating.
+	MarkIdempotent(req)
 
-	resp, err := client.Do(req)
+	resp, err := mendableClient.Do(req)
 	if err != nil {
 		log.Debug().Err(err).Msg("an error occured during the HTTP request")
 		LogError(err)
@@ -98,10 +127,11 @@ func SendModelRating(ctx context.Context, messageID int, rateScore MendableRatin
 		return err
 	}
 
-	if resp.StatusCode != http.StatusOK {
-		log.Debug().Msgf("error while sending model rating: %s", responseBody)
+	err = checkMendableResponse(resp, responseBody)
+	if err != nil {
+		log.Debug().Err(err).Msg("error while sending model rating")
 		log.Debug().Msgf("status code: %d", resp.StatusCode)
-		return fmt.Errorf("error while sending model rating: %s", responseBody)
+		return err
 	}
 
 	return nil
@@ -121,6 +151,7 @@ func SendDocsQuery(ctx context.Context, query MendableRequestPayload, queryURL,
 		History:        query.History,
 		ShouldStream:   false,
 		ConversationID: query.ConversationID,
+		Metadata:       query.Metadata,
 	}
 
 	jsonData, err := json.Marshal(payload)
@@ -129,9 +160,10 @@ func SendDocsQuery(ctx context.Context, query MendableRequestPayload, queryURL,
 		return mendableResponse, err
 	}
 
-	client := DefaultHTTPClient()
+	ctx, cancel := context.WithTimeout(ctx, DefaultMendableQueryTimeout)
+	defer cancel()
 
-	request, err := http.NewRequest("POST", queryURL, bytes.NewBuffer(jsonData))
+	request, err := http.NewRequestWithContext(ctx, "POST", queryURL, bytes.NewBuffer(jsonData))
 	if err != nil {
 		log.Debug().Err(err).Msg("Error while creating POST request:")
 		LogError(err)
@@ -139,7 +171,7 @@ func SendDocsQuery(ctx context.Context, query MendableRequestPayload, queryURL,
 	}
 	request.Header.Set("User-Agent", SetUserAgent(version))
 	request.Header.Set("Content-Type", "application/json")
-	response, err := client.Do(request)
+	response, err := mendableClient.Do(request)
 	if err != nil {
 		log.Debug().Err(err).Msg("Error while making POST request:")
 		LogError(err)
@@ -154,22 +186,28 @@ func SendDocsQuery(ctx context.Context, query MendableRequestPayload, queryURL,
 		return mendableResponse, err
 	}
 
+	err = checkMendableResponse(response, body)
+	if err != nil {
+		log.Debug().Err(err).Msg("Error returned by the Mendable query:")
+		LogError(err)
+		return mendableResponse, err
+	}
+
 	var result MendablePayload
 	err = json.Unmarshal(body, &result)
 	if err != nil {
 		log.Debug().Err(err).Msg("Error while unmarshalling response:")
+		err = newMalformedResponseError(response.StatusCode, err.Error())
 		LogError(err)
 		return mendableResponse, err
 	}
 
-	uniqueLinks := retrieveUniqueLinks(&result.Sources)
-
 	mendableResponse = MendableQueryResponse{
 		ConversationID: int64(query.ConversationID),
 		MessageID:      fmt.Sprint(result.MessageID),
 		Question:       query.Question,
 		Answer:         result.Answer.Text,
-		Links:          uniqueLinks,
+		Sources:        NewSources(result.Sources),
 		Confidence:     fmt.Sprintf("%.2f", result.Confidence),
 	}
 
@@ -183,31 +221,3 @@ func SendDocsQuery(ctx context.Context, query MendableRequestPayload, queryURL,
 	return mendableResponse, nil
 
 }
-
-// retrieveUniqueLinks returns a slice of unique links from the Mendable sources.
-func retrieveUniqueLinks(list *[]MendableSources) []string {
-	uniqueLinks := make([]string, 0)
-	reviewedLinksMap := make(map[string]bool)
-
-	for _, source := range *list {
-
-		link := source.Link
-
-		// Skip links that are equal to "https://docs.spectrocloud.com"
-		if link == PublicDocumentationURL {
-			continue
-		}
-
-		// Check if the link already exists in the map
-		if _, exists := reviewedLinksMap[link]; exists {
-			continue
-		}
-
-		// Add the link to the reviewedLinksMap and to the unique links slice
-		reviewedLinksMap[link] = true
-		uniqueLinks = append(uniqueLinks, link)
-	}
-
-	return uniqueLinks
-
-}
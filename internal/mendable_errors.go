@@ -0,0 +1,182 @@
+// Copyright (c) Spectro Cloud
+// SPDX-License-Identifier: Apache-2.0
+
+package internal
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/rs/zerolog"
+)
+
+var (
+	// ErrMendableUnauthorized is returned when Mendable rejects the API key.
+	ErrMendableUnauthorized = errors.New("mendable rejected the API key")
+	// ErrMendableRateLimited is returned when Mendable rate limits the request.
+	ErrMendableRateLimited = errors.New("mendable rate limit exceeded")
+	// ErrMendableServerError is returned when Mendable fails to process the request.
+	ErrMendableServerError = errors.New("mendable server error")
+	// ErrMendableBadRequest is returned when Mendable rejects the request for any other reason.
+	ErrMendableBadRequest = errors.New("mendable rejected the request")
+	// ErrMendableMalformedResponse is returned when the Mendable response can't be decoded or is missing required values.
+	ErrMendableMalformedResponse = errors.New("mendable returned a malformed response")
+)
+
+// maxErrorBodyLength is the maximum number of characters of a response body kept in an error.
+const maxErrorBodyLength = 512
+
+// MendableAPIError is returned by the Mendable client when a request fails.
+// Use errors.Is with one of the ErrMendable* values to determine the kind of failure.
+type MendableAPIError struct {
+	Kind       error
+	StatusCode int
+	RetryAfter time.Duration
+	Body       string
+}
+
+func (e *MendableAPIError) Error() string {
+	if e.Body == "" {
+		return fmt.Sprintf("%s (status code %d)", e.Kind, e.StatusCode)
+	}
+	return fmt.Sprintf("%s (status code %d): %s", e.Kind, e.StatusCode, e.Body)
+}
+
+func (e *MendableAPIError) Unwrap() error {
+	return e.Kind
+}
+
+// checkMendableResponse returns a MendableAPIError if the response status code is not successful.
+func checkMendableResponse(resp *http.Response, body []byte) error {
+	if resp.StatusCode >= http.StatusOK && resp.StatusCode < http.StatusMultipleChoices {
+		return nil
+	}
+
+	apiErr := &MendableAPIError{
+		StatusCode: resp.StatusCode,
+		Body:       truncateErrorBody(string(body)),
+	}
+
+	switch {
+	case resp.StatusCode == http.StatusUnauthorized || resp.StatusCode == http.StatusForbidden:
+		apiErr.Kind = ErrMendableUnauthorized
+	case resp.StatusCode == http.StatusTooManyRequests:
+		apiErr.Kind = ErrMendableRateLimited
+		apiErr.RetryAfter = parseRetryAfter(resp.Header.Get("Retry-After"), time.Now())
+	case resp.StatusCode >= http.StatusInternalServerError:
+		apiErr.Kind = ErrMendableServerError
+		apiErr.RetryAfter = parseRetryAfter(resp.Header.Get("Retry-After"), time.Now())
+	default:
+		apiErr.Kind = ErrMendableBadRequest
+	}
+
+	return apiErr
+}
+
+// newMalformedResponseError returns a MendableAPIError for a response that could not be used.
+func newMalformedResponseError(statusCode int, reason string) error {
+	return &MendableAPIError{
+		Kind:       ErrMendableMalformedResponse,
+		StatusCode: statusCode,
+		Body:       truncateErrorBody(reason),
+	}
+}
+
+// parseRetryAfter parses the value of a Retry-After header.
+// The header value is either a number of seconds or an HTTP date.
+// Zero is returned if the value is empty or invalid.
+func parseRetryAfter(value string, now time.Time) time.Duration {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return 0
+	}
+
+	if seconds, err := strconv.Atoi(value); err == nil {
+		if seconds < 0 {
+			return 0
+		}
+		return time.Duration(seconds) * time.Second
+	}
+
+	if date, err := http.ParseTime(value); err == nil {
+		if d := date.Sub(now); d > 0 {
+			return d
+		}
+	}
+
+	return 0
+}
+
+func truncateErrorBody(body string) string {
+	body = strings.TrimSpace(body)
+	if len(body) > maxErrorBodyLength {
+		return body[:maxErrorBodyLength] + "..."
+	}
+	return body
+}
+
+// ErrorKind returns a short identifier for the error that is suitable for log fields.
+func ErrorKind(err error) string {
+	switch {
+	case err == nil:
+		return ""
+	case IsTimeoutError(err):
+		return "timeout"
+	case errors.Is(err, ErrCircuitOpen):
+		return "circuit_open"
+	case errors.Is(err, ErrMendableUnauthorized):
+		return "mendable_unauthorized"
+	case errors.Is(err, ErrMendableRateLimited):
+		return "mendable_rate_limited"
+	case errors.Is(err, ErrMendableServerError):
+		return "mendable_server_error"
+	case errors.Is(err, ErrMendableBadRequest):
+		return "mendable_bad_request"
+	case errors.Is(err, ErrMendableMalformedResponse):
+		return "mendable_malformed_response"
+	default:
+		return "unknown"
+	}
+}
+
+// UserErrorMessage returns the message displayed to the user for the error.
+func UserErrorMessage(err error) string {
+	switch {
+	case IsTimeoutError(err):
+		return DefaultTimeoutMessage
+	case errors.Is(err, ErrCircuitOpen):
+		return DefaultDegradedMessage
+	case errors.Is(err, ErrMendableUnauthorized):
+		return DefaultMendableUnauthorizedMessage
+	case errors.Is(err, ErrMendableRateLimited):
+		retryAfter := DefaultMendableRetryAfter
+		var apiErr *MendableAPIError
+		if errors.As(err, &apiErr) && apiErr.RetryAfter > 0 {
+			retryAfter = apiErr.RetryAfter
+		}
+		return fmt.Sprintf(DefaultMendableRateLimitedMessage, FormatDuration(retryAfter))
+	case errors.Is(err, ErrMendableServerError):
+		return DefaultMendableServerErrorMessage
+	default:
+		return DefaultUserErrorMessage
+	}
+}
+
+// LogErrorFields adds the error kind and, for Mendable errors, the status code and retry delay to the log event.
+func LogErrorFields(event *zerolog.Event, err error) *zerolog.Event {
+	event = event.Err(err).Str("error_kind", ErrorKind(err))
+
+	var apiErr *MendableAPIError
+	if errors.As(err, &apiErr) {
+		event = event.Int("status_code", apiErr.StatusCode)
+		if apiErr.RetryAfter > 0 {
+			event = event.Dur("retry_after", apiErr.RetryAfter)
+		}
+	}
+
+	return event
+}
@@ -0,0 +1,105 @@
+// Copyright (c) Spectro Cloud
+// SPDX-License-Identifier: Apache-2.0
+
+package internal
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSendDocsQueryStatusErrors(t *testing.T) {
+	cases := []struct {
+		name       string
+		statusCode int
+		retryAfter string
+		body       string
+		kind       error
+		wantRetry  time.Duration
+		message    string
+	}{
+		{"unauthorized", http.StatusUnauthorized, "", `{"error":"invalid api key"}`, ErrMendableUnauthorized, 0, DefaultMendableUnauthorizedMessage},
+		{"forbidden", http.StatusForbidden, "", "", ErrMendableUnauthorized, 0, DefaultMendableUnauthorizedMessage},
+		{"rate limited", http.StatusTooManyRequests, "30", "slow down", ErrMendableRateLimited, 30 * time.Second, "30s"},
+		{"server error", http.StatusBadGateway, "", "bad gateway", ErrMendableServerError, 0, DefaultMendableServerErrorMessage},
+		{"bad request", http.StatusBadRequest, "", "missing question", ErrMendableBadRequest, 0, DefaultUserErrorMessage},
+		{"malformed", http.StatusOK, "", "<html>not json</html>", ErrMendableMalformedResponse, 0, DefaultUserErrorMessage},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			useTestMendableClient(t)
+
+			ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if tc.retryAfter != "" {
+					w.Header().Set("Retry-After", tc.retryAfter)
+				}
+				w.WriteHeader(tc.statusCode)
+				_, _ = w.Write([]byte(tc.body))
+			}))
+			defer ts.Close()
+
+			_, err := SendDocsQuery(context.Background(), MendableRequestPayload{Question: "test_question"}, ts.URL, "1.0.0")
+			require.Error(t, err)
+			assert.True(t, errors.Is(err, tc.kind), "Expected %v, got %v", tc.kind, err)
+
+			var apiErr *MendableAPIError
+			require.True(t, errors.As(err, &apiErr))
+			assert.Equal(t, tc.statusCode, apiErr.StatusCode)
+			assert.Equal(t, tc.wantRetry, apiErr.RetryAfter)
+			assert.Contains(t, UserErrorMessage(err), tc.message)
+		})
+	}
+}
+
+func TestCreateNewConversationMissingID(t *testing.T) {
+	useTestMendableClient(t)
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{}`))
+	}))
+	defer ts.Close()
+
+	id, err := CreateNewConversation(context.Background(), "test-api-key", ts.URL)
+	assert.Equal(t, int64(-1), id)
+	assert.True(t, errors.Is(err, ErrMendableMalformedResponse), "Expected a malformed response error, got %v", err)
+}
+
+func TestCreateNewConversationUnauthorized(t *testing.T) {
+	useTestMendableClient(t)
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusUnauthorized)
+	}))
+	defer ts.Close()
+
+	_, err := CreateNewConversation(context.Background(), "test-api-key", ts.URL)
+	assert.True(t, errors.Is(err, ErrMendableUnauthorized), "Expected an unauthorized error, got %v", err)
+	assert.Equal(t, "mendable_unauthorized", ErrorKind(err))
+}
+
+func TestParseRetryAfter(t *testing.T) {
+	now := time.Date(2023, 6, 1, 12, 0, 0, 0, time.UTC)
+
+	assert.Equal(t, time.Duration(0), parseRetryAfter("", now))
+	assert.Equal(t, time.Duration(0), parseRetryAfter("invalid", now))
+	assert.Equal(t, time.Duration(0), parseRetryAfter("-5", now))
+	assert.Equal(t, 120*time.Second, parseRetryAfter("120", now))
+	assert.Equal(t, 90*time.Second, parseRetryAfter(now.Add(90*time.Second).Format(http.TimeFormat), now))
+	assert.Equal(t, time.Duration(0), parseRetryAfter(now.Add(-time.Minute).Format(http.TimeFormat), now))
+}
+
+func TestTruncateErrorBody(t *testing.T) {
+	long := strings.Repeat("a", maxErrorBodyLength+10)
+	assert.Len(t, truncateErrorBody(long), maxErrorBodyLength+3)
+	assert.Equal(t, "short", truncateErrorBody("  short  "))
+}
@@ -14,6 +14,8 @@ import (
 )
 
 func TestCreateNewConversation(t *testing.T) {
+	useTestMendableClient(t)
+
 	// Define a mock HTTP server that returns a specific response for testing purposes
 	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		// Verify that the request has the expected HTTP method and Content-Type header
@@ -68,6 +70,8 @@ func TestCreateNewConversation(t *testing.T) {
 }
 
 func TestSendDocsQuery(t *testing.T) {
+	useTestMendableClient(t)
+
 	var version string = "1.0.0"
 	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		w.Header().Set("Content-Type", "application/json")
@@ -115,7 +119,8 @@ func TestSendDocsQuery(t *testing.T) {
 		ConversationID: 1,
 		Question:       "test_question",
 		Answer:         "This is a test answer.",
-		Links:          []string{"https://example.com/1"},
+		// The duplicate link keeps the most relevant source, and the link to the docs home page is skipped.
+		Sources: []Source{{URL: "https://example.com/1", Title: "1", Score: 0.9, Snippet: "Content 3"}},
 	}
 
 	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Second)
@@ -129,19 +134,29 @@ func TestSendDocsQuery(t *testing.T) {
 
 	// Compare the result with the expected output
 	if result.ConversationID != expectedOutput.ConversationID || result.Question != expectedOutput.Question ||
-		result.Answer != expectedOutput.Answer || !compareLinks(result.Links, expectedOutput.Links) {
+		result.Answer != expectedOutput.Answer || !reflect.DeepEqual(result.Sources, expectedOutput.Sources) {
 		t.Fatalf("SendDocsQuery returned incorrect result: %+v, expected: %+v", result, expectedOutput)
 	}
 }
 
-func compareLinks(a, b []string) bool {
-	if len(a) != len(b) {
-		return false
+func TestSendDocsQueryContextDeadline(t *testing.T) {
+	useTestMendableClient(t)
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// Respond after the client deadline has passed.
+		time.Sleep(250 * time.Millisecond)
+	}))
+	defer ts.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	_, err := SendDocsQuery(ctx, MendableRequestPayload{Question: "test_question"}, ts.URL, "1.0.0")
+	if err == nil {
+		t.Fatal("SendDocsQuery did not return an error when the context deadline was exceeded")
 	}
-	for i, v := range a {
-		if v != b[i] {
-			return false
-		}
+
+	if !IsTimeoutError(err) {
+		t.Errorf("Expected a timeout error, got: %v", err)
 	}
-	return true
 }
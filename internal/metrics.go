@@ -0,0 +1,47 @@
+// Copyright (c) Spectro Cloud
+// SPDX-License-Identifier: Apache-2.0
+
+package internal
+
+import (
+	"sync"
+)
+
+const (
+	// DegradationCacheRead is recorded when a question is answered without history because the cache read failed.
+	DegradationCacheRead string = "cache_read"
+	// DegradationCacheWrite is recorded when an answer is delivered but not stored because the cache write failed.
+	DegradationCacheWrite string = "cache_write"
+)
+
+// degradedAsks counts the questions answered in a degraded mode, keyed by the reason.
+// The counters are published in the readiness report.
+var degradedAsks = struct {
+	mu     sync.Mutex
+	counts map[string]int64
+}{counts: map[string]int64{}}
+
+// RecordDegradation increments the degraded ask counter for the reason.
+func RecordDegradation(reason string) {
+	degradedAsks.mu.Lock()
+	defer degradedAsks.mu.Unlock()
+	degradedAsks.counts[reason]++
+}
+
+// DegradationCount returns the number of degraded asks recorded for the reason.
+func DegradationCount(reason string) int64 {
+	degradedAsks.mu.Lock()
+	defer degradedAsks.mu.Unlock()
+	return degradedAsks.counts[reason]
+}
+
+// DegradationCounts returns a copy of every degraded ask counter, keyed by the reason.
+func DegradationCounts() map[string]int64 {
+	degradedAsks.mu.Lock()
+	defer degradedAsks.mu.Unlock()
+	counts := make(map[string]int64, len(degradedAsks.counts))
+	for reason, count := range degradedAsks.counts {
+		counts[reason] = count
+	}
+	return counts
+}
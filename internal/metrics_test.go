@@ -0,0 +1,28 @@
+// Copyright (c) Spectro Cloud
+// SPDX-License-Identifier: Apache-2.0
+
+package internal
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRecordDegradation(t *testing.T) {
+	read := DegradationCount(DegradationCacheRead)
+	write := DegradationCount(DegradationCacheWrite)
+
+	RecordDegradation(DegradationCacheRead)
+	RecordDegradation(DegradationCacheRead)
+	RecordDegradation(DegradationCacheWrite)
+
+	assert.Equal(t, read+2, DegradationCount(DegradationCacheRead))
+	assert.Equal(t, write+1, DegradationCount(DegradationCacheWrite))
+	assert.Equal(t, int64(0), DegradationCount("unknown_reason"))
+
+	counts := DegradationCounts()
+	assert.Equal(t, read+2, counts[DegradationCacheRead])
+	counts[DegradationCacheRead] = 0
+	assert.Equal(t, read+2, DegradationCount(DegradationCacheRead), "Expected the counters to be copied")
+}
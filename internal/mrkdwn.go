@@ -0,0 +1,206 @@
+// Copyright (c) Spectro Cloud
+// SPDX-License-Identifier: Apache-2.0
+
+package internal
+
+import (
+	"regexp"
+	"strings"
+	"unicode/utf8"
+)
+
+var (
+	mdHeadingPattern        = regexp.MustCompile(`^\s{0,3}#{1,6}\s+(.*?)\s*#*\s*$`)
+	mdRulePattern           = regexp.MustCompile(`^\s{0,3}(?:(?:\*\s*){3,}|(?:-\s*){3,}|(?:_\s*){3,})$`)
+	mdBulletPattern         = regexp.MustCompile(`^(\s*)[-*+]\s+(.*)$`)
+	mdTaskPattern           = regexp.MustCompile(`^\[([ xX])\]\s+`)
+	mdQuotePattern          = regexp.MustCompile(`^\s{0,3}>\s?(.*)$`)
+	mdTableSeparatorPattern = regexp.MustCompile(`^\s*\|?\s*:?-+:?\s*(?:\|\s*:?-+:?\s*)*\|?\s*$`)
+	mdInlineCodePattern     = regexp.MustCompile("`[^`\n]+`")
+	mdLinkPattern           = regexp.MustCompile(`!?\[([^\]]*)\]\(\s*<?([^)\s>]+)>?(?:\s+"[^"]*")?\s*\)|<(https?://[^>\s]+)>`)
+	mdBoldPattern           = regexp.MustCompile(`\*\*(\S(?:.*?\S)?)\*\*|__(\S(?:.*?\S)?)__`)
+	mdItalicPattern         = regexp.MustCompile(`(^|[^\w*])\*(\S(?:[^*]*?\S)?)\*`)
+	mdStrikePattern         = regexp.MustCompile(`~~(\S(?:.*?\S)?)~~`)
+)
+
+// slackEscaper escapes the characters Slack uses for links and mentions.
+// https://api.slack.com/reference/surfaces/formatting#escaping
+var slackEscaper = strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;")
+
+// MarkdownToMrkdwn converts GitHub-flavored Markdown to Slack mrkdwn.
+// Links become <url|text>, headings and bold text become bold, bullets become "•", and tables become
+// preformatted text. Code is kept as is, except for the language of code blocks, which Slack doesn't support.
+// The characters &, < and > are escaped everywhere so they aren't mistaken for Slack links or mentions.
+func MarkdownToMrkdwn(markdown string) string {
+	lines := strings.Split(strings.ReplaceAll(markdown, "\r\n", "\n"), "\n")
+	out := make([]string, 0, len(lines))
+
+	for i := 0; i < len(lines); i++ {
+		line := lines[i]
+		trimmed := strings.TrimSpace(line)
+
+		switch {
+		case strings.HasPrefix(trimmed, "```"):
+			// Copy the code block until the closing fence, or the end of the text if it isn't closed.
+			out = append(out, "```")
+			for i++; i < len(lines) && !strings.HasPrefix(strings.TrimSpace(lines[i]), "```"); i++ {
+				out = append(out, slackEscaper.Replace(lines[i]))
+			}
+			out = append(out, "```")
+
+		case strings.HasPrefix(trimmed, "|") && i+1 < len(lines) && mdTableSeparatorPattern.MatchString(lines[i+1]):
+			rows := [][]string{tableCells(line)}
+			for i += 2; i < len(lines) && strings.HasPrefix(strings.TrimSpace(lines[i]), "|"); i++ {
+				rows = append(rows, tableCells(lines[i]))
+			}
+			i--
+			out = append(out, "```")
+			out = append(out, formatTable(rows)...)
+			out = append(out, "```")
+
+		case mdHeadingPattern.MatchString(line):
+			heading := mdHeadingPattern.FindStringSubmatch(line)[1]
+			heading = strings.NewReplacer("**", "", "__", "").Replace(heading)
+			out = append(out, "*"+convertInline(heading)+"*")
+
+		case mdRulePattern.MatchString(line):
+			// Slack has no horizontal rule. The rule is removed along with the blank line after it.
+			if i+1 < len(lines) && strings.TrimSpace(lines[i+1]) == "" {
+				i++
+			}
+
+		case mdBulletPattern.MatchString(line):
+			match := mdBulletPattern.FindStringSubmatch(line)
+			item := match[2]
+			marker := "•"
+			if len(match[1]) >= 2 {
+				marker = "◦"
+			}
+			if task := mdTaskPattern.FindStringSubmatch(item); task != nil {
+				marker = "☐"
+				if task[1] != " " {
+					marker = "☑"
+				}
+				item = item[len(task[0]):]
+			}
+			out = append(out, match[1]+marker+" "+convertInline(item))
+
+		case mdQuotePattern.MatchString(line):
+			out = append(out, "> "+convertInline(mdQuotePattern.FindStringSubmatch(line)[1]))
+
+		default:
+			out = append(out, convertInline(line))
+		}
+	}
+
+	return strings.TrimSpace(strings.Join(out, "\n"))
+}
+
+// convertInline converts the links, emphasis and inline code of a single line.
+// Inline code is escaped but otherwise left unchanged.
+func convertInline(text string) string {
+	var sb strings.Builder
+	last := 0
+	for _, loc := range mdInlineCodePattern.FindAllStringIndex(text, -1) {
+		sb.WriteString(convertLinks(text[last:loc[0]]))
+		sb.WriteString(slackEscaper.Replace(text[loc[0]:loc[1]]))
+		last = loc[1]
+	}
+	sb.WriteString(convertLinks(text[last:]))
+	return sb.String()
+}
+
+// convertLinks converts the links of a text without inline code, and the emphasis of the text around them.
+func convertLinks(text string) string {
+	var sb strings.Builder
+	last := 0
+	for _, m := range mdLinkPattern.FindAllStringSubmatchIndex(text, -1) {
+		sb.WriteString(convertEmphasis(text[last:m[0]]))
+
+		if m[6] >= 0 {
+			// Autolink, such as <https://docs.spectrocloud.com>.
+			sb.WriteString("<" + slackEscaper.Replace(text[m[6]:m[7]]) + ">")
+		} else {
+			label, url := text[m[2]:m[3]], text[m[4]:m[5]]
+			label = strings.NewReplacer("**", "", "__", "", "`", "", "|", "¦").Replace(label)
+			if label == "" {
+				sb.WriteString("<" + slackEscaper.Replace(url) + ">")
+			} else {
+				sb.WriteString("<" + slackEscaper.Replace(url) + "|" + slackEscaper.Replace(label) + ">")
+			}
+		}
+		last = m[1]
+	}
+	sb.WriteString(convertEmphasis(text[last:]))
+	return sb.String()
+}
+
+// convertEmphasis escapes the text and converts bold, italic and strikethrough text.
+func convertEmphasis(text string) string {
+	text = slackEscaper.Replace(text)
+	// Bold text is marked with a placeholder first so it isn't converted to italic text.
+	text = mdBoldPattern.ReplaceAllString(text, "\x00$1$2\x00")
+	text = mdItalicPattern.ReplaceAllString(text, "${1}_${2}_")
+	text = mdStrikePattern.ReplaceAllString(text, "~$1~")
+	return strings.ReplaceAll(text, "\x00", "*")
+}
+
+// tableCells returns the cells of a table row without inline formatting.
+// The cells are escaped once the table is formatted, so the escaped characters don't change the column widths.
+func tableCells(row string) []string {
+	row = strings.TrimSpace(row)
+	row = strings.TrimSuffix(strings.TrimPrefix(row, "|"), "|")
+
+	cells := strings.Split(row, "|")
+	for i, cell := range cells {
+		cell = mdLinkPattern.ReplaceAllStringFunc(strings.TrimSpace(cell), func(link string) string {
+			m := mdLinkPattern.FindStringSubmatch(link)
+			if m[3] != "" {
+				return m[3]
+			}
+			if m[1] == "" {
+				return m[2]
+			}
+			return m[1] + " (" + m[2] + ")"
+		})
+		cells[i] = strings.NewReplacer("**", "", "__", "", "`", "").Replace(cell)
+	}
+	return cells
+}
+
+// formatTable aligns the columns of a table.
+func formatTable(rows [][]string) []string {
+	var widths []int
+	for _, row := range rows {
+		for i, cell := range row {
+			if i == len(widths) {
+				widths = append(widths, 0)
+			}
+			if n := utf8.RuneCountInString(cell); n > widths[i] {
+				widths[i] = n
+			}
+		}
+	}
+
+	lines := make([]string, 0, len(rows)+1)
+	for r, row := range rows {
+		cells := make([]string, len(widths))
+		for i := range widths {
+			cell := ""
+			if i < len(row) {
+				cell = row[i]
+			}
+			cells[i] = cell + strings.Repeat(" ", widths[i]-utf8.RuneCountInString(cell))
+		}
+		lines = append(lines, slackEscaper.Replace(strings.TrimRight(strings.Join(cells, " | "), " ")))
+
+		if r == 0 {
+			separators := make([]string, len(widths))
+			for i, w := range widths {
+				separators[i] = strings.Repeat("-", w)
+			}
+			lines = append(lines, strings.Join(separators, "-+-"))
+		}
+	}
+	return lines
+}
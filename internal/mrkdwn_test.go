@@ -0,0 +1,72 @@
+// Copyright (c) Spectro Cloud
+// SPDX-License-Identifier: Apache-2.0
+
+package internal
+
+import (
+	"flag"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// Run the tests with the -update flag to regenerate the golden files after changing the converter.
+var updateGolden = flag.Bool("update", false, "update the golden files")
+
+func TestMarkdownToMrkdwnGolden(t *testing.T) {
+	inputs, err := filepath.Glob(filepath.Join("testdata", "mrkdwn", "*.md"))
+	if err != nil || len(inputs) == 0 {
+		t.Fatalf("no golden file inputs found: %v", err)
+	}
+
+	for _, input := range inputs {
+		name := strings.TrimSuffix(filepath.Base(input), ".md")
+		t.Run(name, func(t *testing.T) {
+			markdown, err := os.ReadFile(input)
+			if err != nil {
+				t.Fatalf("unable to read the input: %v", err)
+			}
+
+			got := MarkdownToMrkdwn(string(markdown)) + "\n"
+			golden := strings.TrimSuffix(input, ".md") + ".golden"
+
+			if *updateGolden {
+				if err := os.WriteFile(golden, []byte(got), 0644); err != nil {
+					t.Fatalf("unable to update the golden file: %v", err)
+				}
+			}
+
+			want, err := os.ReadFile(golden)
+			if err != nil {
+				t.Fatalf("unable to read the golden file: %v", err)
+			}
+
+			if got != string(want) {
+				t.Errorf("unexpected output for %s:\n--- got ---\n%s\n--- want ---\n%s", input, got, want)
+			}
+		})
+	}
+}
+
+func TestMarkdownToMrkdwnInline(t *testing.T) {
+	tests := []struct {
+		markdown string
+		expected string
+	}{
+		{"", ""},
+		{"plain text", "plain text"},
+		{"**bold** and *italic*", "*bold* and _italic_"},
+		{"[text](https://example.com)", "<https://example.com|text>"},
+		{"[](https://example.com)", "<https://example.com>"},
+		{"a < b && c > d", "a &lt; b &amp;&amp; c &gt; d"},
+		{"2 * 3 * 4", "2 * 3 * 4"},
+		{"### Heading ###", "*Heading*"},
+	}
+
+	for _, tc := range tests {
+		if got := MarkdownToMrkdwn(tc.markdown); got != tc.expected {
+			t.Errorf("MarkdownToMrkdwn(%q) = %q, want %q", tc.markdown, got, tc.expected)
+		}
+	}
+}
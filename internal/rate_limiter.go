@@ -0,0 +1,177 @@
+// Copyright (c) Spectro Cloud
+// SPDX-License-Identifier: Apache-2.0
+
+package internal
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/rs/zerolog/log"
+)
+
+// tokenBucketScript checks and consumes a token from every bucket in KEYS atomically.
+// A token is only consumed if every bucket has one available, so a request denied by
+// the channel limit doesn't use up the user's allowance.
+// ARGV holds the capacity and the refill interval in milliseconds for each key.
+// The script returns whether the request is allowed and the milliseconds until the next token.
+const tokenBucketScript = `
+local time = redis.call('TIME')
+local now = tonumber(time[1]) * 1000 + math.floor(tonumber(time[2]) / 1000)
+local tokens = {}
+local wait = 0
+
+for i, key in ipairs(KEYS) do
+	local capacity = tonumber(ARGV[i * 2 - 1])
+	local refill = tonumber(ARGV[i * 2])
+	local bucket = redis.call('HMGET', key, 'tokens', 'ts')
+	local available = tonumber(bucket[1])
+	local ts = tonumber(bucket[2])
+	if available == nil or ts == nil then
+		available = capacity
+		ts = now
+	end
+	available = math.min(capacity, available + math.max(0, now - ts) / refill)
+	if available < 1 then
+		wait = math.max(wait, math.ceil((1 - available) * refill))
+	end
+	tokens[i] = available
+end
+
+local allowed = 0
+if wait == 0 then
+	allowed = 1
+end
+
+for i, key in ipairs(KEYS) do
+	local capacity = tonumber(ARGV[i * 2 - 1])
+	local refill = tonumber(ARGV[i * 2])
+	local available = tokens[i]
+	if allowed == 1 then
+		available = available - 1
+	end
+	redis.call('HSET', key, 'tokens', tostring(available), 'ts', now)
+	redis.call('PEXPIRE', key, math.ceil(capacity * refill))
+end
+
+return {allowed, wait}
+`
+
+// RateLimit is the number of requests allowed within a period.
+// Requests are refilled continuously, so a limit of 5 per minute allows a burst of 5
+// requests followed by one request every 12 seconds.
+type RateLimit struct {
+	Requests int
+	Period   time.Duration
+}
+
+// Enabled returns true if the rate limit should be enforced.
+func (r RateLimit) Enabled() bool {
+	return r.Requests > 0 && r.Period > 0
+}
+
+// refillInterval returns the time it takes to refill a single request.
+// The token bucket script works in milliseconds, so the interval is at least a millisecond.
+func (r RateLimit) refillInterval() time.Duration {
+	return max(r.Period/time.Duration(r.Requests), time.Millisecond)
+}
+
+// ParseRateLimit parses a rate limit in the format <requests>/<period>, such as "5/1m".
+// An empty string or zero requests disables the rate limit.
+func ParseRateLimit(s string) (RateLimit, error) {
+	s = strings.TrimSpace(s)
+	if s == "" || s == "0" {
+		return RateLimit{}, nil
+	}
+
+	requestsRaw, periodRaw, found := strings.Cut(s, "/")
+	if !found {
+		return RateLimit{}, fmt.Errorf("invalid rate limit %q: expected the format <requests>/<period>, such as 5/1m", s)
+	}
+
+	requests, err := strconv.Atoi(strings.TrimSpace(requestsRaw))
+	if err != nil || requests < 0 {
+		return RateLimit{}, fmt.Errorf("invalid rate limit %q: the number of requests must be a positive number", s)
+	}
+
+	period, err := time.ParseDuration(strings.TrimSpace(periodRaw))
+	if err != nil || period <= 0 {
+		return RateLimit{}, fmt.Errorf("invalid rate limit %q: the period must be a positive duration", s)
+	}
+
+	limit := RateLimit{Requests: requests, Period: period}
+	if limit.Enabled() && period/time.Duration(requests) < time.Millisecond {
+		return RateLimit{}, fmt.Errorf("invalid rate limit %q: at most one request per millisecond is supported", s)
+	}
+
+	return limit, nil
+}
+
+// RateLimitConfig is the rate limit applied to each user, channel and workspace.
+type RateLimitConfig struct {
+	User      RateLimit
+	Channel   RateLimit
+	Workspace RateLimit
+}
+
+// RateLimiter is a token bucket rate limiter backed by the cache.
+type RateLimiter struct {
+	cache  Cache
+	config RateLimitConfig
+}
+
+// NewRateLimiter returns a new RateLimiter.
+func NewRateLimiter(cache Cache, config RateLimitConfig) *RateLimiter {
+	return &RateLimiter{cache, config}
+}
+
+// Allow consumes a token for the user, channel and workspace of the event.
+// It returns false and the time until the next request is allowed if any of the limits is exceeded.
+func (l *RateLimiter) Allow(ctx context.Context, event *SlackEvent) (bool, time.Duration, error) {
+	var (
+		keys []string
+		args []interface{}
+	)
+
+	add := func(limit RateLimit, key string) {
+		if !limit.Enabled() {
+			return
+		}
+		keys = append(keys, key)
+		args = append(args, limit.Requests, limit.refillInterval().Milliseconds())
+	}
+
+	add(l.config.User, fmt.Sprintf("docs_bot:rate_limit:user:%s:%s", event.TeamID, event.UserID))
+	add(l.config.Channel, fmt.Sprintf("docs_bot:rate_limit:channel:%s:%s", event.TeamID, event.ChannelID))
+	add(l.config.Workspace, fmt.Sprintf("docs_bot:rate_limit:workspace:%s", event.TeamID))
+
+	if len(keys) == 0 {
+		return true, 0, nil
+	}
+
+	result, err := l.cache.EvalScript(ctx, tokenBucketScript, keys, args...)
+	if err != nil {
+		return false, 0, err
+	}
+
+	values, ok := result.([]interface{})
+	if !ok || len(values) != 2 {
+		return false, 0, fmt.Errorf("unexpected rate limit result: %v", result)
+	}
+
+	allowed, okAllowed := values[0].(int64)
+	waitMs, okWait := values[1].(int64)
+	if !okAllowed || !okWait {
+		return false, 0, fmt.Errorf("unexpected rate limit result: %v", result)
+	}
+
+	if allowed != 1 {
+		log.Debug().Str("user_id", event.UserID).Str("channel_id", event.ChannelID).Int64("wait_ms", waitMs).Msg("Rate limit exceeded.")
+		return false, time.Duration(waitMs) * time.Millisecond, nil
+	}
+
+	return true, 0, nil
+}
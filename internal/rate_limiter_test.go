@@ -0,0 +1,97 @@
+// Copyright (c) Spectro Cloud
+// SPDX-License-Identifier: Apache-2.0
+
+package internal
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"spectrocloud.com/spectromate/mock"
+)
+
+func TestParseRateLimit(t *testing.T) {
+	limit, err := ParseRateLimit("5/1m")
+	require.NoError(t, err)
+	assert.Equal(t, RateLimit{Requests: 5, Period: time.Minute}, limit)
+	assert.True(t, limit.Enabled())
+	assert.Equal(t, 12*time.Second, limit.refillInterval())
+
+	limit, err = ParseRateLimit(" 100 / 1h ")
+	require.NoError(t, err)
+	assert.Equal(t, RateLimit{Requests: 100, Period: time.Hour}, limit)
+
+	for _, disabled := range []string{"", "0", "0/1m"} {
+		limit, err = ParseRateLimit(disabled)
+		require.NoError(t, err)
+		assert.False(t, limit.Enabled(), "Expected %q to disable the rate limit", disabled)
+	}
+
+	for _, invalid := range []string{"5", "five/1m", "5/forever", "-1/1m", "5/-1m", "2000/1s"} {
+		_, err = ParseRateLimit(invalid)
+		assert.Error(t, err, "Expected %q to be invalid", invalid)
+	}
+
+	limit, err = ParseRateLimit("1000/1s")
+	require.NoError(t, err)
+	assert.Equal(t, time.Millisecond, limit.refillInterval())
+
+	// Limits that weren't parsed still refill at most one request per millisecond.
+	assert.Equal(t, time.Millisecond, RateLimit{Requests: 5000, Period: time.Second}.refillInterval())
+}
+
+func TestRateLimiterAllow(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	cache := mock.NewMockCache(ctrl)
+	ctx := context.Background()
+	event := &SlackEvent{TeamID: "T123", ChannelID: "C123", UserID: "U123"}
+
+	limiter := NewRateLimiter(cache, RateLimitConfig{
+		User:      RateLimit{Requests: 5, Period: time.Minute},
+		Workspace: RateLimit{Requests: 60, Period: time.Minute},
+	})
+
+	keys := []string{"docs_bot:rate_limit:user:T123:U123", "docs_bot:rate_limit:workspace:T123"}
+
+	// Allowed request
+	cache.EXPECT().EvalScript(ctx, tokenBucketScript, keys, 5, int64(12000), 60, int64(1000)).Return([]interface{}{int64(1), int64(0)}, nil)
+	allowed, wait, err := limiter.Allow(ctx, event)
+	require.NoError(t, err)
+	assert.True(t, allowed)
+	assert.Equal(t, time.Duration(0), wait)
+
+	// Denied request
+	cache.EXPECT().EvalScript(ctx, tokenBucketScript, keys, 5, int64(12000), 60, int64(1000)).Return([]interface{}{int64(0), int64(4200)}, nil)
+	allowed, wait, err = limiter.Allow(ctx, event)
+	require.NoError(t, err)
+	assert.False(t, allowed)
+	assert.Equal(t, 4200*time.Millisecond, wait)
+
+	// Cache error
+	cache.EXPECT().EvalScript(ctx, tokenBucketScript, keys, 5, int64(12000), 60, int64(1000)).Return(nil, fmt.Errorf("Redis client error"))
+	_, _, err = limiter.Allow(ctx, event)
+	assert.Error(t, err)
+
+	// Unexpected result
+	cache.EXPECT().EvalScript(ctx, tokenBucketScript, keys, 5, int64(12000), 60, int64(1000)).Return("OK", nil)
+	_, _, err = limiter.Allow(ctx, event)
+	assert.Error(t, err)
+}
+
+func TestRateLimiterDisabled(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	// No cache calls are expected when every limit is disabled.
+	limiter := NewRateLimiter(mock.NewMockCache(ctrl), RateLimitConfig{})
+	allowed, _, err := limiter.Allow(context.Background(), &SlackEvent{UserID: "U123"})
+	require.NoError(t, err)
+	assert.True(t, allowed)
+}
@@ -0,0 +1,132 @@
+// Copyright (c) Spectro Cloud
+// SPDX-License-Identifier: Apache-2.0
+
+package internal
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+)
+
+// setRatingScript stores the rating of a user for an answer and returns the previous rating of the user,
+// followed by the number of positive and negative ratings of the answer.
+// The previous rating is an empty string if the user didn't rate the answer.
+// KEYS[1] is the rating key, ARGV[1] the user ID, ARGV[2] the rating, and ARGV[3] the TTL in seconds.
+const setRatingScript = `
+local previous = redis.call('HGET', KEYS[1], ARGV[1])
+redis.call('HSET', KEYS[1], ARGV[1], ARGV[2])
+redis.call('EXPIRE', KEYS[1], ARGV[3])
+local positive, negative = 0, 0
+for _, value in ipairs(redis.call('HVALS', KEYS[1])) do
+	if value == '1' then
+		positive = positive + 1
+	elseif value == '-1' then
+		negative = negative + 1
+	end
+end
+return {previous or '', positive, negative}
+`
+
+// RatingCounts is the number of users who rated an answer positively and negatively.
+type RatingCounts struct {
+	Positive int
+	Negative int
+}
+
+// Total returns the number of users who rated the answer.
+func (c RatingCounts) Total() int {
+	return c.Positive + c.Negative
+}
+
+// Score returns the aggregated rating of the answer: positive if most users rated it positively,
+// negative if most users rated it negatively, and NoFeedbackScore on a tie or without ratings.
+func (c RatingCounts) Score() MendableRatingScore {
+	switch {
+	case c.Positive > c.Negative:
+		return PositiveFeedbackScore
+	case c.Negative > c.Positive:
+		return NegativeFeedbackScore
+	default:
+		return NoFeedbackScore
+	}
+}
+
+// Replace returns the counts with one rating changed from one score to another.
+// It's used to compute the counts before a user changed their rating.
+func (c RatingCounts) Replace(from, to MendableRatingScore) RatingCounts {
+	add := func(score MendableRatingScore, n int) {
+		switch score {
+		case PositiveFeedbackScore:
+			c.Positive += n
+		case NegativeFeedbackScore:
+			c.Negative += n
+		}
+	}
+	add(from, -1)
+	add(to, 1)
+	return c
+}
+
+// String returns the counts with the rating emojis, such as ":thumbsup: 3 / :thumbsdown: 1".
+func (c RatingCounts) String() string {
+	return fmt.Sprintf("%s %d / %s %d", RatingEmoji(PositiveFeedbackScore), c.Positive, RatingEmoji(NegativeFeedbackScore), c.Negative)
+}
+
+// ratingKey returns the cache key of the ratings of the answer, keyed by user ID.
+func ratingKey(messageID string) string {
+	return fmt.Sprintf("docs_bot:rating:%s", messageID)
+}
+
+// ParseRatingScore parses a rating stored in the cache. An empty or invalid value is NoFeedbackScore.
+func ParseRatingScore(value string) MendableRatingScore {
+	score, err := strconv.Atoi(value)
+	if err != nil {
+		return NoFeedbackScore
+	}
+	switch MendableRatingScore(score) {
+	case PositiveFeedbackScore, NegativeFeedbackScore:
+		return MendableRatingScore(score)
+	default:
+		return NoFeedbackScore
+	}
+}
+
+// SetUserRating stores the current rating of the user for the answer. It returns the previous rating of the user
+// and the rating counts of the answer, including the new rating.
+// A user has a single rating per answer. NoFeedbackScore records a retracted rating.
+// The ratings of the answer expire after the TTL.
+func SetUserRating(ctx context.Context, cache Cache, messageID, userID string, score MendableRatingScore, ttl time.Duration) (MendableRatingScore, RatingCounts, error) {
+	if cache == nil {
+		return NoFeedbackScore, RatingCounts{}, ErrCacheUnavailable
+	}
+
+	result, err := cache.EvalScript(ctx, setRatingScript, []string{ratingKey(messageID)}, userID, int(score), int64(ttl.Seconds()))
+	if err != nil {
+		return NoFeedbackScore, RatingCounts{}, err
+	}
+
+	values, ok := result.([]interface{})
+	if !ok || len(values) != 3 {
+		return NoFeedbackScore, RatingCounts{}, fmt.Errorf("unexpected rating script result: %v", result)
+	}
+
+	previous, _ := values[0].(string)
+	positive, _ := values[1].(int64)
+	negative, _ := values[2].(int64)
+
+	return ParseRatingScore(previous), RatingCounts{Positive: int(positive), Negative: int(negative)}, nil
+}
+
+// RatingEmoji returns the emoji of the rating, or an empty string for NoFeedbackScore.
+func RatingEmoji(score MendableRatingScore) string {
+	switch score {
+	case PositiveFeedbackScore:
+		return ":thumbsup:"
+	case NegativeFeedbackScore:
+		return ":thumbsdown:"
+	default:
+		return ""
+	}
+}
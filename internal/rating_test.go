@@ -0,0 +1,80 @@
+// Copyright (c) Spectro Cloud
+// SPDX-License-Identifier: Apache-2.0
+
+package internal
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"spectrocloud.com/spectromate/mock"
+)
+
+func TestSetUserRating(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	cache := mock.NewMockCache(ctrl)
+	ctx := context.Background()
+	keys := []string{"docs_bot:rating:123"}
+
+	// First rating
+	cache.EXPECT().EvalScript(ctx, setRatingScript, keys, "U123", 1, int64(3600)).Return([]interface{}{"", int64(1), int64(0)}, nil)
+	previous, counts, err := SetUserRating(ctx, cache, "123", "U123", PositiveFeedbackScore, time.Hour)
+	assert.NoError(t, err)
+	assert.Equal(t, NoFeedbackScore, previous)
+	assert.Equal(t, RatingCounts{Positive: 1}, counts)
+
+	// Changed rating, with another user's rating
+	cache.EXPECT().EvalScript(ctx, setRatingScript, keys, "U123", -1, int64(3600)).Return([]interface{}{"1", int64(1), int64(1)}, nil)
+	previous, counts, err = SetUserRating(ctx, cache, "123", "U123", NegativeFeedbackScore, time.Hour)
+	assert.NoError(t, err)
+	assert.Equal(t, PositiveFeedbackScore, previous)
+	assert.Equal(t, RatingCounts{Positive: 1, Negative: 1}, counts)
+
+	// Removed rating
+	cache.EXPECT().EvalScript(ctx, setRatingScript, keys, "U123", 0, int64(3600)).Return([]interface{}{"-1", int64(1), int64(0)}, nil)
+	previous, _, err = SetUserRating(ctx, cache, "123", "U123", NoFeedbackScore, time.Hour)
+	assert.NoError(t, err)
+	assert.Equal(t, NegativeFeedbackScore, previous)
+
+	// Unexpected result
+	cache.EXPECT().EvalScript(ctx, setRatingScript, keys, "U123", 1, int64(3600)).Return("1", nil)
+	_, _, err = SetUserRating(ctx, cache, "123", "U123", PositiveFeedbackScore, time.Hour)
+	assert.Error(t, err)
+
+	// The cache is unavailable
+	cache.EXPECT().EvalScript(ctx, setRatingScript, keys, "U123", 1, int64(3600)).Return(nil, ErrCacheUnavailable)
+	_, _, err = SetUserRating(ctx, cache, "123", "U123", PositiveFeedbackScore, time.Hour)
+	assert.ErrorIs(t, err, ErrCacheUnavailable)
+
+	_, _, err = SetUserRating(ctx, nil, "123", "U123", PositiveFeedbackScore, time.Hour)
+	assert.ErrorIs(t, err, ErrCacheUnavailable)
+}
+
+func TestRatingCounts(t *testing.T) {
+	counts := RatingCounts{Positive: 3, Negative: 1}
+	assert.Equal(t, 4, counts.Total())
+	assert.Equal(t, PositiveFeedbackScore, counts.Score())
+	assert.Equal(t, ":thumbsup: 3 / :thumbsdown: 1", counts.String())
+
+	// The counts before a user changed their rating from negative to positive.
+	before := counts.Replace(PositiveFeedbackScore, NegativeFeedbackScore)
+	assert.Equal(t, RatingCounts{Positive: 2, Negative: 2}, before)
+	assert.Equal(t, NoFeedbackScore, before.Score())
+
+	// The counts before a user rated the answer for the first time.
+	assert.Equal(t, RatingCounts{Positive: 2, Negative: 1}, counts.Replace(PositiveFeedbackScore, NoFeedbackScore))
+	assert.Equal(t, NegativeFeedbackScore, RatingCounts{Negative: 1}.Score())
+}
+
+func TestParseRatingScore(t *testing.T) {
+	assert.Equal(t, PositiveFeedbackScore, ParseRatingScore("1"))
+	assert.Equal(t, NegativeFeedbackScore, ParseRatingScore("-1"))
+	assert.Equal(t, NoFeedbackScore, ParseRatingScore("0"))
+	assert.Equal(t, NoFeedbackScore, ParseRatingScore(""))
+	assert.Equal(t, NoFeedbackScore, ParseRatingScore("5"))
+}
@@ -0,0 +1,217 @@
+// Copyright (c) Spectro Cloud
+// SPDX-License-Identifier: Apache-2.0
+
+package internal
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// detector finds one kind of sensitive information in a text.
+// Secrets, such as keys and tokens, are also removed from the logs, unlike personal data.
+type detector struct {
+	name        string
+	pattern     *regexp.Regexp
+	replacement string
+	secret      bool
+}
+
+// builtinDetectors are checked in order, so the detectors matching the longest values come first.
+// For example, a private key can contain text that looks like a token.
+var builtinDetectors = []detector{
+	{
+		name:    "private_key",
+		pattern: regexp.MustCompile(`-----BEGIN [A-Z ]*PRIVATE KEY-----[\s\S]*?-----END [A-Z ]*PRIVATE KEY-----`),
+		secret:  true,
+	},
+	{
+		name:    "jwt",
+		pattern: regexp.MustCompile(`\beyJ[A-Za-z0-9_-]{5,}\.eyJ[A-Za-z0-9_-]{5,}\.[A-Za-z0-9_-]+`),
+		secret:  true,
+	},
+	{
+		name:    "aws_key",
+		pattern: regexp.MustCompile(`\b(?:AKIA|ASIA)[0-9A-Z]{16}\b`),
+		secret:  true,
+	},
+	{
+		name:    "token",
+		pattern: regexp.MustCompile(`\b(?:xox[abposr]-[A-Za-z0-9-]{10,}|gh[pousr]_[A-Za-z0-9]{36,}|glpat-[A-Za-z0-9_-]{20,})|(?i:\bbearer\s+[A-Za-z0-9._~+/-]{20,}=*)`),
+		secret:  true,
+	},
+	{
+		// Keeps the name of the setting so the question still makes sense, such as "token: [REDACTED:secret]".
+		name:        "secret",
+		pattern:     regexp.MustCompile(`(?i)(\b(?:aws_secret_access_key|api[_-]?key|access[_-]?token|auth[_-]?token|token|secret|password|passwd|client-key-data|client-certificate-data)["']?\s*[:=]\s*["']?)[^\s"',\\]{6,}`),
+		replacement: "${1}[REDACTED:secret]",
+		secret:      true,
+	},
+	{
+		name:    "email",
+		pattern: regexp.MustCompile(`\b[A-Za-z0-9._%+-]+@[A-Za-z0-9.-]+\.[A-Za-z]{2,}\b`),
+	},
+	{
+		name:    "ip",
+		pattern: regexp.MustCompile(`\b(?:(?:25[0-5]|2[0-4]\d|1?\d?\d)\.){3}(?:25[0-5]|2[0-4]\d|1?\d?\d)\b|\b(?:[0-9A-Fa-f]{1,4}:){7}[0-9A-Fa-f]{1,4}\b|\b(?:[0-9A-Fa-f]{1,4}:){1,6}(?::[0-9A-Fa-f]{1,4}){1,6}\b`),
+	},
+}
+
+// RedactionDetectors returns the names of the built-in detectors.
+func RedactionDetectors() []string {
+	names := make([]string, 0, len(builtinDetectors))
+	for _, d := range builtinDetectors {
+		names = append(names, d.name)
+	}
+	return names
+}
+
+// Redactor replaces sensitive information, such as keys, tokens and personal data, with a placeholder
+// naming the detector, such as [REDACTED:email]. A nil Redactor returns the text unchanged.
+type Redactor struct {
+	detectors []detector
+}
+
+// NewRedactor returns a Redactor using the built-in detectors, except the disabled ones, and the custom patterns.
+// Text matching a custom pattern is replaced with [REDACTED:custom].
+func NewRedactor(patterns, disabled []string) (*Redactor, error) {
+	r := &Redactor{}
+
+	var skip []string
+	for _, name := range disabled {
+		name = strings.ToLower(strings.TrimSpace(name))
+		if name == "" {
+			continue
+		}
+		if !containsString(RedactionDetectors(), name) {
+			return nil, fmt.Errorf("unknown redaction detector %q. Available detectors: %s", name, strings.Join(RedactionDetectors(), ", "))
+		}
+		skip = append(skip, name)
+	}
+
+	for _, d := range builtinDetectors {
+		if !containsString(skip, d.name) {
+			r.detectors = append(r.detectors, d)
+		}
+	}
+
+	for _, p := range patterns {
+		pattern, err := regexp.Compile(p)
+		if err != nil {
+			return nil, fmt.Errorf("invalid redaction pattern %q: %w", p, err)
+		}
+		r.detectors = append(r.detectors, detector{name: "custom", pattern: pattern})
+	}
+
+	return r, nil
+}
+
+// Secrets returns a Redactor using only the enabled detectors of secrets, such as keys and tokens.
+// Logs keep email addresses, IP addresses, and custom patterns, which are needed to troubleshoot requests.
+func (r *Redactor) Secrets() *Redactor {
+	if r == nil {
+		return nil
+	}
+
+	secrets := &Redactor{}
+	for _, d := range r.detectors {
+		if d.secret {
+			secrets.detectors = append(secrets.detectors, d)
+		}
+	}
+	return secrets
+}
+
+// ParseRedactionPatterns parses the custom redaction patterns from a JSON array of regular expressions.
+func ParseRedactionPatterns(raw string) ([]string, error) {
+	if strings.TrimSpace(raw) == "" {
+		return nil, nil
+	}
+
+	var patterns []string
+	if err := json.Unmarshal([]byte(raw), &patterns); err != nil {
+		return nil, fmt.Errorf("invalid redaction patterns: %w", err)
+	}
+	return patterns, nil
+}
+
+// Redact returns the text with the sensitive information replaced, and the sorted names of the detectors that matched.
+func (r *Redactor) Redact(text string) (string, []string) {
+	if r == nil || text == "" {
+		return text, nil
+	}
+
+	var found []string
+	for _, d := range r.detectors {
+		if !d.pattern.MatchString(text) {
+			continue
+		}
+
+		replacement := d.replacement
+		if replacement == "" {
+			replacement = "[REDACTED:" + d.name + "]"
+		}
+		text = d.pattern.ReplaceAllString(text, replacement)
+
+		if !containsString(found, d.name) {
+			found = append(found, d.name)
+		}
+	}
+
+	sort.Strings(found)
+	return text, found
+}
+
+// RedactHistory returns a copy of the history with the sensitive information replaced in every prompt and response.
+func (r *Redactor) RedactHistory(history []HistoryItems) ([]HistoryItems, []string) {
+	if r == nil {
+		return history, nil
+	}
+
+	var found []string
+	redacted := make([]HistoryItems, 0, len(history))
+	for _, item := range history {
+		prompt, promptFound := r.Redact(item.Prompt)
+		response, responseFound := r.Redact(item.Response)
+		for _, name := range append(promptFound, responseFound...) {
+			if !containsString(found, name) {
+				found = append(found, name)
+			}
+		}
+		redacted = append(redacted, HistoryItems{Prompt: prompt, Response: response})
+	}
+
+	sort.Strings(found)
+	return redacted, found
+}
+
+// RedactingWriter redacts every log line before writing it to the underlying writer.
+// Use the redactor returned by Redactor.Secrets to only remove secrets from the logs.
+// The placeholders don't contain quotes, so JSON log lines remain valid.
+type RedactingWriter struct {
+	w        io.Writer
+	redactor *Redactor
+}
+
+// NewRedactingWriter returns a new RedactingWriter.
+func NewRedactingWriter(w io.Writer, redactor *Redactor) *RedactingWriter {
+	return &RedactingWriter{w, redactor}
+}
+
+// Write redacts and writes the log line. The length of the original line is returned
+// so the logger doesn't report a short write.
+func (w *RedactingWriter) Write(p []byte) (int, error) {
+	redacted, found := w.redactor.Redact(string(p))
+	if len(found) == 0 {
+		return w.w.Write(p)
+	}
+
+	if _, err := io.WriteString(w.w, redacted); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
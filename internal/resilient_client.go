@@ -0,0 +1,191 @@
+// Copyright (c) Spectro Cloud
+// SPDX-License-Identifier: Apache-2.0
+
+package internal
+
+import (
+	"context"
+	"errors"
+	"io"
+	"math/rand"
+	"net/http"
+	"time"
+
+	"github.com/rs/zerolog/log"
+)
+
+// mendableClient is the HTTP client shared by all Mendable API calls.
+// Sharing the client lets the circuit breaker see every failure.
+var mendableClient = NewResilientClient(
+	DefaultHTTPClient(),
+	NewCircuitBreaker("mendable", DefaultCircuitBreakerThreshold, DefaultCircuitBreakerCooldown),
+	DefaultRetryAttempts,
+	DefaultRetryBaseDelay,
+	DefaultRetryMaxDelay,
+)
+
+// MendableCircuitOpen returns true if the Mendable circuit breaker is rejecting requests.
+func MendableCircuitOpen() bool {
+	return mendableClient.Breaker().State() == CircuitOpen
+}
+
+// ResilientClient is an HTTP client that retries transient failures with jittered exponential backoff
+// and stops sending requests while the circuit breaker is open.
+type ResilientClient struct {
+	client      *http.Client
+	breaker     *CircuitBreaker
+	maxAttempts int
+	baseDelay   time.Duration
+	maxDelay    time.Duration
+}
+
+// NewResilientClient returns a new ResilientClient.
+func NewResilientClient(client *http.Client, breaker *CircuitBreaker, maxAttempts int, baseDelay, maxDelay time.Duration) *ResilientClient {
+	if maxAttempts < 1 {
+		maxAttempts = 1
+	}
+	return &ResilientClient{client, breaker, maxAttempts, baseDelay, maxDelay}
+}
+
+// Breaker returns the circuit breaker of the client.
+func (c *ResilientClient) Breaker() *CircuitBreaker {
+	return c.breaker
+}
+
+// MarkIdempotent marks the request as safe to retry after a network error.
+// This follows the net/http convention of an Idempotency-Key header with a nil value, which is never sent.
+func MarkIdempotent(req *http.Request) {
+	req.Header["Idempotency-Key"] = nil
+}
+
+// Do sends the request and retries it on transient failures.
+// Responses with a 429 or 502-504 status code are always retried. Network errors are only retried
+// for idempotent requests, because the server may have already processed the request.
+// The Retry-After header is respected as long as it's within the maximum delay and the request deadline.
+// ErrCircuitOpen is returned without sending the request if the circuit breaker is open.
+// The circuit breaker records the final outcome of the request once, so the retries of a single request
+// don't count as separate failures, and a probe request keeps its retries.
+func (c *ResilientClient) Do(req *http.Request) (resp *http.Response, err error) {
+	ctx := req.Context()
+
+	if !c.breaker.Allow() {
+		return nil, ErrCircuitOpen
+	}
+	defer func() {
+		c.record(ctx, resp, err)
+	}()
+
+	for attempt := 1; ; attempt++ {
+		if attempt > 1 && req.Body != nil {
+			if req.GetBody == nil {
+				return nil, errors.New("unable to retry a request without GetBody")
+			}
+			body, err := req.GetBody()
+			if err != nil {
+				return nil, err
+			}
+			req.Body = body
+		}
+
+		resp, err = c.client.Do(req)
+
+		retriable, retryAfter := c.shouldRetry(req, resp, err)
+		if !retriable || attempt >= c.maxAttempts {
+			return resp, err
+		}
+
+		// Give up early if the server asks us to wait longer than we are willing to.
+		// The caller can surface the Retry-After value to the user instead.
+		if retryAfter > c.maxDelay {
+			return resp, err
+		}
+
+		delay := backoffDelay(attempt, c.baseDelay, c.maxDelay)
+		if retryAfter > delay {
+			delay = retryAfter
+		}
+
+		if deadline, ok := ctx.Deadline(); ok && time.Until(deadline) < delay {
+			return resp, err
+		}
+
+		if resp != nil {
+			_, _ = io.Copy(io.Discard, resp.Body)
+			resp.Body.Close()
+		}
+
+		log.Debug().Err(err).Int("attempt", attempt).Dur("delay", delay).Str("url", req.URL.String()).Msg("Retrying request.")
+
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return nil, ctx.Err()
+		case <-timer.C:
+		}
+	}
+}
+
+// record reports the outcome of a request to the circuit breaker.
+// Requests cancelled by the caller and rate limited requests are neither failures nor successes,
+// so a rate limited probe doesn't close the circuit while the dependency still refuses requests.
+func (c *ResilientClient) record(ctx context.Context, resp *http.Response, err error) {
+	switch {
+	case err != nil && ctx.Err() == context.Canceled:
+		c.breaker.Ignore()
+	case err != nil:
+		c.breaker.Failure()
+	case resp.StatusCode == http.StatusTooManyRequests:
+		c.breaker.Ignore()
+	case resp.StatusCode >= http.StatusInternalServerError:
+		c.breaker.Failure()
+	default:
+		c.breaker.Success()
+	}
+}
+
+// shouldRetry returns true if the request can be retried and the delay requested by the server.
+func (c *ResilientClient) shouldRetry(req *http.Request, resp *http.Response, err error) (bool, time.Duration) {
+	if err != nil {
+		if req.Context().Err() != nil {
+			return false, 0
+		}
+		return isIdempotent(req), 0
+	}
+
+	switch resp.StatusCode {
+	case http.StatusTooManyRequests, http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return true, parseRetryAfter(resp.Header.Get("Retry-After"), time.Now())
+	default:
+		return false, 0
+	}
+}
+
+// isIdempotent returns true if the request can be safely sent more than once.
+func isIdempotent(req *http.Request) bool {
+	switch req.Method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodTrace, http.MethodPut, http.MethodDelete:
+		return true
+	}
+
+	_, ok := req.Header["Idempotency-Key"]
+	return ok
+}
+
+// backoffDelay returns the exponential backoff delay for the attempt with jitter applied.
+// The delay is between half and the full exponential delay, capped at maxDelay.
+func backoffDelay(attempt int, baseDelay, maxDelay time.Duration) time.Duration {
+	delay := baseDelay
+	for i := 1; i < attempt && delay < maxDelay; i++ {
+		delay *= 2
+	}
+	if delay > maxDelay {
+		delay = maxDelay
+	}
+	if delay <= 0 {
+		return 0
+	}
+
+	half := delay / 2
+	return half + time.Duration(rand.Int63n(int64(half)+1))
+}
@@ -0,0 +1,220 @@
+// Copyright (c) Spectro Cloud
+// SPDX-License-Identifier: Apache-2.0
+
+package internal
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// useTestMendableClient replaces the shared Mendable client with one that has a fresh
+// circuit breaker and short retry delays for the duration of the test.
+func useTestMendableClient(t *testing.T) *ResilientClient {
+	t.Helper()
+	previous := mendableClient
+	mendableClient = NewResilientClient(DefaultHTTPClient(), NewCircuitBreaker("test", 3, time.Minute), 3, time.Millisecond, 5*time.Millisecond)
+	t.Cleanup(func() { mendableClient = previous })
+	return mendableClient
+}
+
+func newTestRequest(t *testing.T, url string) *http.Request {
+	t.Helper()
+	req, err := http.NewRequestWithContext(context.Background(), "POST", url, bytes.NewBufferString(`{"question":"test"}`))
+	require.NoError(t, err)
+	return req
+}
+
+func TestResilientClientRetriesTransientStatus(t *testing.T) {
+	var calls int32
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		assert.Equal(t, `{"question":"test"}`, string(body), "Expected the body to be resent on every attempt")
+		if atomic.AddInt32(&calls, 1) < 3 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer ts.Close()
+
+	client := NewResilientClient(DefaultHTTPClient(), NewCircuitBreaker("test", 5, time.Minute), 3, time.Millisecond, 5*time.Millisecond)
+	resp, err := client.Do(newTestRequest(t, ts.URL))
+	require.NoError(t, err)
+	defer resp.Body.Close()
+
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, int32(3), atomic.LoadInt32(&calls))
+	assert.Equal(t, CircuitClosed, client.Breaker().State())
+}
+
+func TestResilientClientDoesNotRetryClientErrors(t *testing.T) {
+	var calls int32
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		w.WriteHeader(http.StatusUnauthorized)
+	}))
+	defer ts.Close()
+
+	client := NewResilientClient(DefaultHTTPClient(), NewCircuitBreaker("test", 5, time.Minute), 3, time.Millisecond, 5*time.Millisecond)
+	resp, err := client.Do(newTestRequest(t, ts.URL))
+	require.NoError(t, err)
+	defer resp.Body.Close()
+
+	assert.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+	assert.Equal(t, int32(1), atomic.LoadInt32(&calls))
+}
+
+func TestResilientClientRespectsRetryAfter(t *testing.T) {
+	var calls int32
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		w.Header().Set("Retry-After", "120")
+		w.WriteHeader(http.StatusTooManyRequests)
+	}))
+	defer ts.Close()
+
+	// The Retry-After delay exceeds the maximum delay, so the client gives up after the first attempt.
+	client := NewResilientClient(DefaultHTTPClient(), NewCircuitBreaker("test", 5, time.Minute), 3, time.Millisecond, 5*time.Millisecond)
+	resp, err := client.Do(newTestRequest(t, ts.URL))
+	require.NoError(t, err)
+	defer resp.Body.Close()
+
+	assert.Equal(t, http.StatusTooManyRequests, resp.StatusCode)
+	assert.Equal(t, int32(1), atomic.LoadInt32(&calls))
+}
+
+func TestResilientClientNetworkErrors(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	url := ts.URL
+	ts.Close()
+
+	client := NewResilientClient(DefaultHTTPClient(), NewCircuitBreaker("test", 10, time.Minute), 3, time.Millisecond, 5*time.Millisecond)
+
+	// Non-idempotent requests are not retried after a network error.
+	_, err := client.Do(newTestRequest(t, url))
+	require.Error(t, err)
+
+	// Idempotent requests are retried until the attempts are exhausted.
+	req := newTestRequest(t, url)
+	MarkIdempotent(req)
+	_, err = client.Do(req)
+	require.Error(t, err)
+
+	// Each request is a single failure, however many times it was sent.
+	client.breaker.mu.Lock()
+	failures := client.breaker.failures
+	client.breaker.mu.Unlock()
+	assert.Equal(t, 2, failures)
+}
+
+func TestResilientClientRecordsFinalOutcome(t *testing.T) {
+	var calls int32
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer ts.Close()
+
+	// Two requests of three attempts each stay below the threshold of five failures.
+	client := NewResilientClient(DefaultHTTPClient(), NewCircuitBreaker("test", 5, time.Minute), 3, time.Millisecond, 5*time.Millisecond)
+	for i := 0; i < 2; i++ {
+		resp, err := client.Do(newTestRequest(t, ts.URL))
+		require.NoError(t, err)
+		resp.Body.Close()
+	}
+	assert.Equal(t, int32(6), atomic.LoadInt32(&calls))
+	assert.Equal(t, CircuitClosed, client.Breaker().State())
+}
+
+func TestResilientClientRateLimitedProbe(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Retry-After", "120")
+		w.WriteHeader(http.StatusTooManyRequests)
+	}))
+	defer ts.Close()
+
+	now := time.Now()
+	breaker := NewCircuitBreaker("test", 1, time.Minute)
+	breaker.now = func() time.Time { return now }
+	breaker.Failure()
+	now = now.Add(time.Minute)
+
+	// A rate limited probe neither closes nor opens the circuit, and the next request is a new probe.
+	client := NewResilientClient(DefaultHTTPClient(), breaker, 3, time.Millisecond, 5*time.Millisecond)
+	resp, err := client.Do(newTestRequest(t, ts.URL))
+	require.NoError(t, err)
+	resp.Body.Close()
+
+	assert.Equal(t, CircuitHalfOpen, breaker.State())
+	assert.True(t, breaker.Allow())
+}
+
+func TestResilientClientCircuitBreaker(t *testing.T) {
+	var calls int32
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer ts.Close()
+
+	client := NewResilientClient(DefaultHTTPClient(), NewCircuitBreaker("test", 2, time.Minute), 1, time.Millisecond, 5*time.Millisecond)
+
+	for i := 0; i < 2; i++ {
+		resp, err := client.Do(newTestRequest(t, ts.URL))
+		require.NoError(t, err)
+		resp.Body.Close()
+	}
+	assert.Equal(t, CircuitOpen, client.Breaker().State())
+
+	_, err := client.Do(newTestRequest(t, ts.URL))
+	assert.True(t, errors.Is(err, ErrCircuitOpen))
+	assert.Equal(t, int32(2), atomic.LoadInt32(&calls), "Expected no request to be sent while the circuit is open")
+}
+
+func TestCircuitBreakerHalfOpen(t *testing.T) {
+	now := time.Now()
+	breaker := NewCircuitBreaker("test", 1, time.Minute)
+	breaker.now = func() time.Time { return now }
+
+	breaker.Failure()
+	assert.Equal(t, CircuitOpen, breaker.State())
+	assert.False(t, breaker.Allow())
+
+	// Once the cooldown passed a single probe is allowed.
+	now = now.Add(time.Minute)
+	assert.Equal(t, CircuitHalfOpen, breaker.State())
+	assert.True(t, breaker.Allow())
+	assert.False(t, breaker.Allow())
+
+	// A failed probe opens the circuit again.
+	breaker.Failure()
+	assert.Equal(t, CircuitOpen, breaker.State())
+
+	// A successful probe closes the circuit.
+	now = now.Add(time.Minute)
+	assert.True(t, breaker.Allow())
+	breaker.Success()
+	assert.Equal(t, CircuitClosed, breaker.State())
+	assert.True(t, breaker.Allow())
+}
+
+func TestBackoffDelay(t *testing.T) {
+	for attempt := 1; attempt <= 6; attempt++ {
+		delay := backoffDelay(attempt, 100*time.Millisecond, time.Second)
+		assert.LessOrEqual(t, delay, time.Second)
+		assert.GreaterOrEqual(t, delay, 50*time.Millisecond)
+	}
+
+	assert.Equal(t, time.Duration(0), backoffDelay(1, 0, time.Second))
+}
@@ -0,0 +1,175 @@
+// Copyright (c) Spectro Cloud
+// SPDX-License-Identifier: Apache-2.0
+
+package internal
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// scopeValuePattern limits product and version values to characters used in product names and version numbers.
+var scopeValuePattern = regexp.MustCompile(`^[a-z0-9][a-z0-9._-]{0,63}$`)
+
+// DocsScope limits a question to the documentation of a product and docs version.
+// Both fields are optional. An empty scope searches all the documentation.
+type DocsScope struct {
+	Product string `json:"product,omitempty"`
+	Version string `json:"version,omitempty"`
+}
+
+// NewDocsScope returns a normalized scope. Product names are lowercase and a leading "v" is removed from the version.
+// An error is returned if a value contains unsupported characters.
+func NewDocsScope(product, version string) (DocsScope, error) {
+	scope := DocsScope{
+		Product: strings.ToLower(strings.TrimSpace(product)),
+		Version: strings.TrimPrefix(strings.ToLower(strings.TrimSpace(version)), "v"),
+	}
+
+	if scope.Product != "" && !scopeValuePattern.MatchString(scope.Product) {
+		return DocsScope{}, fmt.Errorf("invalid product %q", product)
+	}
+
+	if scope.Version != "" && !scopeValuePattern.MatchString(scope.Version) {
+		return DocsScope{}, fmt.Errorf("invalid version %q", version)
+	}
+
+	return scope, nil
+}
+
+// IsEmpty returns true if the scope doesn't limit the question.
+func (s DocsScope) IsEmpty() bool {
+	return s.Product == "" && s.Version == ""
+}
+
+// Merge returns the scope with the empty fields filled in from the defaults.
+// The default version is only used if the product matches the default product, because
+// versions are specific to a product.
+func (s DocsScope) Merge(defaults DocsScope) DocsScope {
+	merged := s
+	if merged.Product == "" {
+		merged.Product = defaults.Product
+	}
+	if merged.Version == "" && merged.Product == defaults.Product {
+		merged.Version = defaults.Version
+	}
+	return merged
+}
+
+// Label returns a short description of the scope for the answer header, such as "palette v4.2".
+func (s DocsScope) Label() string {
+	switch {
+	case s.Product != "" && s.Version != "":
+		return fmt.Sprintf("%s v%s", s.Product, s.Version)
+	case s.Product != "":
+		return s.Product
+	case s.Version != "":
+		return "v" + s.Version
+	default:
+		return ""
+	}
+}
+
+// Metadata returns the scope as metadata for the answer backend.
+// Nil is returned for an empty scope so the field is omitted from the request.
+func (s DocsScope) Metadata() map[string]string {
+	if s.IsEmpty() {
+		return nil
+	}
+
+	metadata := map[string]string{}
+	if s.Product != "" {
+		metadata["product"] = s.Product
+	}
+	if s.Version != "" {
+		metadata["version"] = s.Version
+	}
+	return metadata
+}
+
+// DocsScopeConfig contains the default scope of each channel, how links are rewritten for a version,
+// and how many sources are displayed with an answer.
+type DocsScopeConfig struct {
+	// Channels maps a channel ID to the scope used when the user doesn't provide one.
+	Channels map[string]DocsScope
+	// BaseURL is the URL of the latest documentation.
+	BaseURL string
+	// VersionedURL is a template for the URL of versioned documentation. The {version} placeholder is replaced
+	// with the version, and the {version_slug} placeholder with the version using dashes instead of dots.
+	// Links aren't rewritten if the template is empty.
+	VersionedURL string
+	// MaxSources is the maximum number of sources displayed with an answer. All sources are displayed if 0.
+	MaxSources int
+}
+
+// ParseChannelScopes parses the per-channel default scopes from a JSON object, such as
+// {"C0123456789": {"product": "palette", "version": "4.2"}}.
+func ParseChannelScopes(raw string) (map[string]DocsScope, error) {
+	channels := map[string]DocsScope{}
+	if strings.TrimSpace(raw) == "" {
+		return channels, nil
+	}
+
+	var parsed map[string]DocsScope
+	if err := json.Unmarshal([]byte(raw), &parsed); err != nil {
+		return nil, fmt.Errorf("invalid channel scopes: %w", err)
+	}
+
+	for channel, scope := range parsed {
+		normalized, err := NewDocsScope(scope.Product, scope.Version)
+		if err != nil {
+			return nil, fmt.Errorf("invalid scope for channel %s: %w", channel, err)
+		}
+		channels[channel] = normalized
+	}
+
+	return channels, nil
+}
+
+// ChannelScope returns the default scope of the channel.
+func (c DocsScopeConfig) ChannelScope(channelID string) DocsScope {
+	return c.Channels[channelID]
+}
+
+// RewriteLinks points documentation links to the versioned documentation of the scope.
+// Links to other sites, and all links when the scope has no version, are returned unchanged.
+func (c DocsScopeConfig) RewriteLinks(scope DocsScope, links []string) []string {
+	if scope.Version == "" || c.VersionedURL == "" || c.BaseURL == "" {
+		return links
+	}
+
+	base := strings.TrimSuffix(c.BaseURL, "/")
+	versioned := strings.NewReplacer(
+		"{version}", scope.Version,
+		"{version_slug}", strings.ReplaceAll(scope.Version, ".", "-"),
+	).Replace(strings.TrimSuffix(c.VersionedURL, "/"))
+
+	rewritten := make([]string, 0, len(links))
+	for _, link := range links {
+		if link == base || strings.HasPrefix(link, base+"/") {
+			link = versioned + strings.TrimPrefix(link, base)
+		}
+		rewritten = append(rewritten, link)
+	}
+
+	return rewritten
+}
+
+// RewriteSources returns the most relevant sources, up to the maximum, with their links rewritten like RewriteLinks.
+func (c DocsScopeConfig) RewriteSources(scope DocsScope, sources []Source) []Source {
+	sources = LimitSources(sources, c.MaxSources)
+
+	links := make([]string, len(sources))
+	for i, source := range sources {
+		links[i] = source.URL
+	}
+
+	rewritten := make([]Source, len(sources))
+	for i, link := range c.RewriteLinks(scope, links) {
+		rewritten[i] = sources[i]
+		rewritten[i].URL = link
+	}
+	return rewritten
+}
@@ -0,0 +1,139 @@
+// Copyright (c) Spectro Cloud
+// SPDX-License-Identifier: Apache-2.0
+
+package internal
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewDocsScope(t *testing.T) {
+	scope, err := NewDocsScope(" Palette ", "v4.2")
+	require.NoError(t, err)
+	assert.Equal(t, DocsScope{Product: "palette", Version: "4.2"}, scope)
+
+	scope, err = NewDocsScope("", "")
+	require.NoError(t, err)
+	assert.True(t, scope.IsEmpty())
+
+	_, err = NewDocsScope("palette; rm -rf", "")
+	assert.Error(t, err)
+
+	_, err = NewDocsScope("palette", "4.2/../..")
+	assert.Error(t, err)
+}
+
+func TestDocsScopeMerge(t *testing.T) {
+	defaults := DocsScope{Product: "palette", Version: "4.2"}
+
+	assert.Equal(t, defaults, DocsScope{}.Merge(defaults))
+	assert.Equal(t, DocsScope{Product: "palette", Version: "4.1"}, DocsScope{Version: "4.1"}.Merge(defaults))
+	assert.Equal(t, DocsScope{Product: "palette", Version: "4.2"}, DocsScope{Product: "palette"}.Merge(defaults))
+	// The default version belongs to the default product, so it's not applied to another product.
+	assert.Equal(t, DocsScope{Product: "vertex"}, DocsScope{Product: "vertex"}.Merge(defaults))
+}
+
+func TestDocsScopeLabelAndMetadata(t *testing.T) {
+	tests := []struct {
+		scope    DocsScope
+		label    string
+		metadata map[string]string
+	}{
+		{DocsScope{}, "", nil},
+		{DocsScope{Product: "palette"}, "palette", map[string]string{"product": "palette"}},
+		{DocsScope{Version: "4.2"}, "v4.2", map[string]string{"version": "4.2"}},
+		{DocsScope{Product: "palette", Version: "4.2"}, "palette v4.2", map[string]string{"product": "palette", "version": "4.2"}},
+	}
+
+	for _, tc := range tests {
+		assert.Equal(t, tc.label, tc.scope.Label())
+		assert.Equal(t, tc.metadata, tc.scope.Metadata())
+	}
+}
+
+func TestParseChannelScopes(t *testing.T) {
+	channels, err := ParseChannelScopes(`{"C0123": {"product": "Palette", "version": "v4.2"}, "C0456": {"product": "vertex"}}`)
+	require.NoError(t, err)
+	assert.Equal(t, DocsScope{Product: "palette", Version: "4.2"}, channels["C0123"])
+	assert.Equal(t, DocsScope{Product: "vertex"}, channels["C0456"])
+
+	channels, err = ParseChannelScopes("")
+	require.NoError(t, err)
+	assert.Empty(t, channels)
+
+	_, err = ParseChannelScopes(`{"C0123": "palette"}`)
+	assert.Error(t, err)
+
+	_, err = ParseChannelScopes(`{"C0123": {"product": "pal ette"}}`)
+	assert.Error(t, err)
+}
+
+func TestRewriteLinks(t *testing.T) {
+	config := DocsScopeConfig{
+		BaseURL:      "https://docs.spectrocloud.com",
+		VersionedURL: "https://version-{version_slug}.legacy.docs.spectrocloud.com",
+	}
+	links := []string{
+		"https://docs.spectrocloud.com/clusters/edge",
+		"https://docs.spectrocloud.com",
+		"https://docs.spectrocloud.community/clusters",
+		"https://github.com/spectrocloud/palette",
+	}
+
+	assert.Equal(t, []string{
+		"https://version-4-2.legacy.docs.spectrocloud.com/clusters/edge",
+		"https://version-4-2.legacy.docs.spectrocloud.com",
+		"https://docs.spectrocloud.community/clusters",
+		"https://github.com/spectrocloud/palette",
+	}, config.RewriteLinks(DocsScope{Product: "palette", Version: "4.2"}, links))
+
+	// Links are unchanged without a version or a template.
+	assert.Equal(t, links, config.RewriteLinks(DocsScope{Product: "palette"}, links))
+	assert.Equal(t, links, DocsScopeConfig{BaseURL: config.BaseURL}.RewriteLinks(DocsScope{Version: "4.2"}, links))
+}
+
+func TestSendDocsQueryMetadata(t *testing.T) {
+	useTestMendableClient(t)
+
+	var received MendableRequestPayload
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&received))
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"answer": {"text": "An answer."}, "message_id": 1, "sources": []}`))
+	}))
+	defer ts.Close()
+
+	scope := DocsScope{Product: "palette", Version: "4.2"}
+	_, err := SendDocsQuery(context.Background(), MendableRequestPayload{Question: "test_question", Metadata: scope.Metadata()}, ts.URL, "1.0.0")
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"product": "palette", "version": "4.2"}, received.Metadata)
+}
+
+func TestRewriteSources(t *testing.T) {
+	config := DocsScopeConfig{
+		BaseURL:      "https://docs.spectrocloud.com",
+		VersionedURL: "https://version-{version_slug}.legacy.docs.spectrocloud.com",
+		MaxSources:   2,
+	}
+	sources := []Source{
+		{URL: "https://docs.spectrocloud.com/clusters#deploy", Title: "Clusters", Section: "Deploy"},
+		{URL: "https://github.com/spectrocloud/palette", Title: "Palette"},
+		{URL: "https://docs.spectrocloud.com/edge", Title: "Edge"},
+	}
+
+	assert.Equal(t, []Source{
+		{URL: "https://version-4-2.legacy.docs.spectrocloud.com/clusters#deploy", Title: "Clusters", Section: "Deploy"},
+		{URL: "https://github.com/spectrocloud/palette", Title: "Palette"},
+	}, config.RewriteSources(DocsScope{Version: "4.2"}, sources))
+
+	// The sources are only limited without a version, and the input isn't modified.
+	assert.Equal(t, sources[:2], config.RewriteSources(DocsScope{}, sources))
+	assert.Equal(t, "https://docs.spectrocloud.com/clusters#deploy", sources[0].URL)
+}
@@ -0,0 +1,180 @@
+// Copyright (c) Spectro Cloud
+// SPDX-License-Identifier: Apache-2.0
+
+package internal
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/rs/zerolog/log"
+	"spectrocloud.com/spectromate/internal/blockkit"
+)
+
+// SlackAPIError is returned when the Slack Web API responds with ok set to false.
+type SlackAPIError struct {
+	Method string
+	Code   string
+}
+
+func (e *SlackAPIError) Error() string {
+	return fmt.Sprintf("slack API method %s failed: %s", e.Method, e.Code)
+}
+
+// SlackAPI is a minimal client for the Slack Web API authenticated with a bot token.
+type SlackAPI struct {
+	token   string
+	baseURL string
+	client  *http.Client
+}
+
+// NewSlackAPI returns a new Slack Web API client.
+// Nil is returned if the token is empty so callers can check if the Web API is available.
+func NewSlackAPI(token, baseURL string) *SlackAPI {
+	if token == "" {
+		return nil
+	}
+	return &SlackAPI{token, strings.TrimSuffix(baseURL, "/") + "/", DefaultHTTPClient()}
+}
+
+// SlackUserInfo is the subset of the users.info response used by SpectroMate.
+type SlackUserInfo struct {
+	ID             string `json:"id"`
+	Name           string `json:"name"`
+	IsAdmin        bool   `json:"is_admin"`
+	IsOwner        bool   `json:"is_owner"`
+	IsPrimaryOwner bool   `json:"is_primary_owner"`
+}
+
+// SlackConversationInfo is the subset of the conversations.info response used by SpectroMate.
+type SlackConversationInfo struct {
+	ID        string `json:"id"`
+	Name      string `json:"name"`
+	Creator   string `json:"creator"`
+	IsPrivate bool   `json:"is_private"`
+}
+
+// slackAPIResponse contains the fields included in every Slack Web API response.
+type slackAPIResponse struct {
+	OK    bool   `json:"ok"`
+	Error string `json:"error"`
+}
+
+// UserInfo returns information about a user.
+func (a *SlackAPI) UserInfo(ctx context.Context, userID string) (SlackUserInfo, error) {
+	var response struct {
+		User SlackUserInfo `json:"user"`
+	}
+	err := a.get(ctx, "users.info", url.Values{"user": {userID}}, &response)
+	return response.User, err
+}
+
+// ConversationInfo returns information about a channel.
+func (a *SlackAPI) ConversationInfo(ctx context.Context, channelID string) (SlackConversationInfo, error) {
+	var response struct {
+		Channel SlackConversationInfo `json:"channel"`
+	}
+	err := a.get(ctx, "conversations.info", url.Values{"channel": {channelID}}, &response)
+	return response.Channel, err
+}
+
+// OpenView opens a modal for the user who triggered an interaction.
+// The trigger ID of the interaction expires after 3 seconds, so the modal must be opened right away.
+func (a *SlackAPI) OpenView(ctx context.Context, triggerID string, view *blockkit.View) error {
+	payload, err := view.JSON()
+	if err != nil {
+		return err
+	}
+
+	body, err := json.Marshal(map[string]interface{}{"trigger_id": triggerID, "view": json.RawMessage(payload)})
+	if err != nil {
+		return err
+	}
+
+	return a.call(ctx, http.MethodPost, "views.open", body, nil)
+}
+
+// PostMessage posts a message in a channel, or in the thread of the message with the timestamp threadTS if it's not empty.
+// Posting to a user ID sends a direct message from the app to the user.
+// It returns the timestamp of the posted message, which identifies the message in the channel.
+func (a *SlackAPI) PostMessage(ctx context.Context, channel, threadTS string, message *blockkit.Message) (string, error) {
+	if err := message.Validate(); err != nil {
+		return "", err
+	}
+
+	body, err := json.Marshal(struct {
+		Channel  string           `json:"channel"`
+		ThreadTS string           `json:"thread_ts,omitempty"`
+		Text     string           `json:"text,omitempty"`
+		Blocks   []blockkit.Block `json:"blocks,omitempty"`
+	}{channel, threadTS, message.Text, message.Blocks})
+	if err != nil {
+		return "", err
+	}
+
+	var response struct {
+		TS string `json:"ts"`
+	}
+	err = a.call(ctx, http.MethodPost, "chat.postMessage", body, &response)
+	return response.TS, err
+}
+
+// get calls a read method of the Web API. Read methods only accept query parameters.
+func (a *SlackAPI) get(ctx context.Context, method string, params url.Values, out interface{}) error {
+	return a.call(ctx, http.MethodGet, method+"?"+params.Encode(), nil, out)
+}
+
+// call sends the request and decodes the response into out.
+// A SlackAPIError is returned if the response is not ok.
+func (a *SlackAPI) call(ctx context.Context, httpMethod, path string, body []byte, out interface{}) error {
+	ctx, cancel := context.WithTimeout(ctx, DefaultSlackRequestTimeout)
+	defer cancel()
+
+	method, _, _ := strings.Cut(path, "?")
+
+	req, err := http.NewRequestWithContext(ctx, httpMethod, a.baseURL+path, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "Bearer "+a.token)
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json; charset=utf-8")
+	}
+
+	resp, err := a.client.Do(req)
+	if err != nil {
+		log.Debug().Err(err).Str("method", method).Msg("Error calling the Slack API.")
+		return err
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("slack API method %s returned status code %d", method, resp.StatusCode)
+	}
+
+	var result slackAPIResponse
+	if err := json.Unmarshal(data, &result); err != nil {
+		return fmt.Errorf("slack API method %s returned an invalid response: %w", method, err)
+	}
+
+	if !result.OK {
+		return &SlackAPIError{Method: method, Code: result.Error}
+	}
+
+	if out == nil {
+		return nil
+	}
+
+	return json.Unmarshal(data, out)
+}
@@ -0,0 +1,82 @@
+// Copyright (c) Spectro Cloud
+// SPDX-License-Identifier: Apache-2.0
+
+package internal
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"spectrocloud.com/spectromate/internal/blockkit"
+)
+
+func TestSlackAPIOpenView(t *testing.T) {
+	var body map[string]interface{}
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, http.MethodPost, r.Method)
+		assert.Equal(t, "/views.open", r.URL.Path)
+		assert.Equal(t, "Bearer xoxb-test", r.Header.Get("Authorization"))
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+		if body["trigger_id"] == "expired" {
+			_, _ = w.Write([]byte(`{"ok": false, "error": "expired_trigger_id"}`))
+			return
+		}
+		_, _ = w.Write([]byte(`{"ok": true}`))
+	}))
+	defer ts.Close()
+
+	api := NewSlackAPI("xoxb-test", ts.URL)
+	ctx := context.Background()
+	view := blockkit.Modal("follow_up", "Ask a follow-up", blockkit.Section(blockkit.Markdown("text")))
+
+	require.NoError(t, api.OpenView(ctx, "123.456", view))
+	assert.Equal(t, "123.456", body["trigger_id"])
+	assert.Equal(t, "follow_up", body["view"].(map[string]interface{})["callback_id"])
+
+	err := api.OpenView(ctx, "expired", view)
+	var apiErr *SlackAPIError
+	require.ErrorAs(t, err, &apiErr)
+	assert.Equal(t, "expired_trigger_id", apiErr.Code)
+
+	// Invalid modals aren't sent.
+	assert.ErrorIs(t, api.OpenView(ctx, "123.456", blockkit.Modal("follow_up", "")), blockkit.ErrInvalid)
+}
+
+func TestSlackAPIPostMessage(t *testing.T) {
+	var body map[string]interface{}
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/chat.postMessage", r.URL.Path)
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+		if body["channel"] == "CMISSING" {
+			_, _ = w.Write([]byte(`{"ok": false, "error": "channel_not_found"}`))
+			return
+		}
+		_, _ = w.Write([]byte(`{"ok": true, "channel": "C123", "ts": "1700000000.000100"}`))
+	}))
+	defer ts.Close()
+
+	api := NewSlackAPI("xoxb-test", ts.URL)
+	ctx := context.Background()
+	message := blockkit.NewMessage(false, blockkit.Section(blockkit.Markdown("text"))).WithText("fallback")
+
+	messageTS, err := api.PostMessage(ctx, "C123", "", message)
+	require.NoError(t, err)
+	assert.Equal(t, "1700000000.000100", messageTS)
+	assert.Equal(t, "fallback", body["text"])
+	assert.NotContains(t, body, "thread_ts")
+	assert.NotContains(t, body, "response_type")
+
+	_, err = api.PostMessage(ctx, "C123", "1700000000.000100", message)
+	require.NoError(t, err)
+	assert.Equal(t, "1700000000.000100", body["thread_ts"])
+
+	_, err = api.PostMessage(ctx, "CMISSING", "", message)
+	var apiErr *SlackAPIError
+	require.ErrorAs(t, err, &apiErr)
+	assert.Equal(t, "channel_not_found", apiErr.Code)
+}
@@ -22,6 +22,7 @@ import (
 	"github.com/avast/retry-go"
 	"github.com/gorilla/schema"
 	"github.com/rs/zerolog/log"
+	"spectrocloud.com/spectromate/internal/blockkit"
 )
 
 // SourceValidation validates the request signature by comparing the signing secret value.
@@ -171,7 +172,7 @@ func ReplyStatus200(responseURL string, writer http.ResponseWriter, isPrivate bo
 }
 
 // replyWithAnswer replies to the Slack event using the response URL provided.
-func ReplyWithAnswer(responseURL string, payload []byte, isPrivate bool) error {
+func ReplyWithAnswer(ctx context.Context, responseURL string, payload []byte, isPrivate bool) error {
 
 	if responseURL == "" {
 		err := errors.New("response URL is empty")
@@ -184,8 +185,10 @@ func ReplyWithAnswer(responseURL string, payload []byte, isPrivate bool) error {
 	// This is to prevent the function from failing if Slack is slow to respond.
 	err := retry.Do(
 		func() error {
+			reqCtx, cancel := context.WithTimeout(ctx, DefaultSlackRequestTimeout)
+			defer cancel()
 			client := DefaultHTTPClient()
-			req, err := http.NewRequest("POST", responseURL, bytes.NewBuffer(payload))
+			req, err := http.NewRequestWithContext(reqCtx, "POST", responseURL, bytes.NewBuffer(payload))
 			if err != nil {
 				log.Debug().Err(err).Msg("error creating the reply back HTTP request")
 				LogError(err)
@@ -212,7 +215,7 @@ func ReplyWithAnswer(responseURL string, payload []byte, isPrivate bool) error {
 				return err
 			}
 			return nil
-		}, retry.Attempts(3), retry.Delay(3*time.Second), retry.LastErrorOnly(true),
+		}, retry.Attempts(3), retry.Delay(3*time.Second), retry.LastErrorOnly(true), retry.Context(ctx),
 	)
 	if err != nil {
 		log.Debug().Err(err).Msg("error encountered while sending the Slack answer reply back HTTP request")
@@ -223,7 +226,13 @@ func ReplyWithAnswer(responseURL string, payload []byte, isPrivate bool) error {
 	return nil
 }
 
-func ReplyWithErrorMessage(responseURL string, isPrivate bool) error {
+// ReplyWithErrorMessage replies to the Slack event with the default user error message.
+func ReplyWithErrorMessage(ctx context.Context, responseURL string, isPrivate bool) error {
+	return ReplyWithMessage(ctx, responseURL, DefaultUserErrorMessage, isPrivate)
+}
+
+// ReplyWithMessage replies to the Slack event with a plain markdown message using the response URL provided.
+func ReplyWithMessage(ctx context.Context, responseURL, message string, isPrivate bool) error {
 	if responseURL == "" {
 		err := errors.New("response URL is empty")
 		log.Debug().Err(err).Msg("error encountered while sending the Slack 200 OK reply back HTTP request")
@@ -231,7 +240,7 @@ func ReplyWithErrorMessage(responseURL string, isPrivate bool) error {
 		return err
 	}
 
-	clientMessage, err := errorMessagePayload(DefaultUserErrorMessage, isPrivate)
+	clientMessage, err := MessagePayload(message, isPrivate)
 	if err != nil {
 		LogError(err)
 		log.Error().Err(err).Msg("error creating the user error message markdown payload.")
@@ -242,8 +251,10 @@ func ReplyWithErrorMessage(responseURL string, isPrivate bool) error {
 	// This is to prevent the function from failing if Slack is slow to respond.
 	err = retry.Do(
 		func() error {
+			reqCtx, cancel := context.WithTimeout(ctx, DefaultSlackRequestTimeout)
+			defer cancel()
 			client := DefaultHTTPClient()
-			req, err := http.NewRequest("POST", responseURL, bytes.NewBuffer(clientMessage))
+			req, err := http.NewRequestWithContext(reqCtx, "POST", responseURL, bytes.NewBuffer(clientMessage))
 			if err != nil {
 				log.Debug().Err(err).Msg("error creating the reply back HTTP request")
 				LogError(err)
@@ -270,7 +281,7 @@ func ReplyWithErrorMessage(responseURL string, isPrivate bool) error {
 				return err
 			}
 			return nil
-		}, retry.Attempts(5), retry.Delay(2*time.Second), retry.LastErrorOnly(true),
+		}, retry.Attempts(5), retry.Delay(2*time.Second), retry.LastErrorOnly(true), retry.Context(ctx),
 	)
 	if err != nil {
 		log.Debug().Err(err).Msg("error encountered while sending the Slack answer reply back HTTP request")
@@ -283,20 +294,9 @@ func ReplyWithErrorMessage(responseURL string, isPrivate bool) error {
 
 func waitMessagePayload(title, content string, isPrivate bool) ([]byte, error) {
 
-	payload := SlackPayload{
-		ResponseType: "ephemeral",
-		Blocks: []SlackBlock{
-			{
-				Type: "section",
-				Text: &SlackTextObject{
-					Type: "mrkdwn",
-					Text: content,
-				},
-			},
-		},
-	}
+	payload := blockkit.NewMessage(true, blockkit.Section(blockkit.Markdown(content)))
 
-	payloadBytes, err := json.Marshal(payload)
+	payloadBytes, err := payload.JSON()
 	if err != nil {
 		log.Debug().Err(err).Msg("error marshalling the Slack payload")
 		LogError(err)
@@ -306,31 +306,15 @@ func waitMessagePayload(title, content string, isPrivate bool) ([]byte, error) {
 	return payloadBytes, nil
 }
 
-func errorMessagePayload(content string, isPrivate bool) ([]byte, error) {
+// MessagePayload returns a Slack payload with a single markdown section.
+// Private messages are only visible to the user who issued the command.
+func MessagePayload(content string, isPrivate bool) ([]byte, error) {
 
-	var responseType string
-	if isPrivate {
-		responseType = "ephemeral"
-	} else {
-		responseType = "in_channel"
-	}
-
-	payload := SlackPayload{
-		ResponseType: responseType,
-		Blocks: []SlackBlock{
-			{
-				Type: "section",
-				Text: &SlackTextObject{
-					Type: "mrkdwn",
-					Text: content,
-				},
-			},
-		},
-	}
+	payload := blockkit.NewMessage(isPrivate, blockkit.Section(blockkit.Markdown(content)))
 
-	payloadBytes, err := json.Marshal(payload)
+	payloadBytes, err := payload.JSON()
 	if err != nil {
-		log.Debug().Err(err).Msg("error marshalling the user error message payload")
+		log.Debug().Err(err).Msg("error marshalling the message payload")
 		LogError(err)
 		return []byte{}, err
 	}
@@ -139,3 +139,30 @@ func TestGetSlackEvent(t *testing.T) {
 		t.Errorf("Expected Text to be 'test_text', but got '%s'", event.Text)
 	}
 }
+
+func TestDecodeSlackActionEventForm(t *testing.T) {
+	payload := `{
+		"type": "block_actions",
+		"user": {"id": "U123"},
+		"message": {"blocks": [
+			{"type": "section", "block_id": "answer_question", "text": {"type": "mrkdwn", "text": ":question: How?"}},
+			{"type": "context", "block_id": "answer_rating_counts", "elements": [{"type": "mrkdwn", "text": ":thumbsup: 3 / :thumbsdown: 1"}]}
+		]},
+		"actions": [{"action_id": "ask_model_positive_feedback", "type": "button", "value": "123", "text": {"type": "plain_text", "text": ":thumbsup:", "emoji": true}}]
+	}`
+
+	event, err := DecodeSlackActionEventForm(payload)
+	if err != nil {
+		t.Fatalf("DecodeSlackActionEventForm returned an unexpected error: %v", err)
+	}
+
+	if got := event.Message.Blocks[0].Text.Text; got != ":question: How?" {
+		t.Errorf("unexpected section text: %q", got)
+	}
+	if got := event.Message.Blocks[1].Elements[0].Text.Text; got != ":thumbsup: 3 / :thumbsdown: 1" {
+		t.Errorf("unexpected context element text: %q", got)
+	}
+	if got := event.Actions[0].Text; got.Type != "plain_text" || !got.Emoji {
+		t.Errorf("unexpected button text: %+v", got)
+	}
+}
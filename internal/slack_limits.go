@@ -0,0 +1,184 @@
+// Copyright (c) Spectro Cloud
+// SPDX-License-Identifier: Apache-2.0
+
+package internal
+
+import (
+	"strings"
+	"unicode/utf8"
+
+	"spectrocloud.com/spectromate/internal/blockkit"
+)
+
+// TruncateText shortens the text to the limit, in characters, and ends it with an ellipsis if it was shortened.
+func TruncateText(text string, limit int) string {
+	if utf8.RuneCountInString(text) <= limit {
+		return text
+	}
+	runes := []rune(text)
+	return strings.TrimSpace(string(runes[:limit-1])) + "…"
+}
+
+// SplitMarkdown splits the text into chunks no longer than the limit, in characters.
+// Chunks end at paragraph boundaries where possible. Code blocks are kept whole, and a code block
+// longer than the limit is split at line boundaries and each part is fenced again so it renders as code.
+// Lines and words longer than the limit are split as a last resort.
+func SplitMarkdown(text string, limit int) []string {
+	var (
+		chunks  []string
+		current string
+	)
+
+	for _, block := range markdownBlocks(strings.TrimSpace(text)) {
+		for _, piece := range splitMarkdownBlock(block, limit) {
+			if current != "" && utf8.RuneCountInString(current)+2+utf8.RuneCountInString(piece) > limit {
+				chunks = append(chunks, current)
+				current = ""
+			}
+			if current != "" {
+				current += "\n\n"
+			}
+			current += piece
+		}
+	}
+
+	if current != "" {
+		chunks = append(chunks, current)
+	}
+
+	return chunks
+}
+
+// markdownBlocks returns the paragraphs and fenced code blocks of the text.
+// Blank lines inside a code block don't end the block.
+func markdownBlocks(text string) []string {
+	var (
+		blocks  []string
+		lines   []string
+		inFence bool
+	)
+
+	flush := func() {
+		if len(lines) > 0 {
+			blocks = append(blocks, strings.Join(lines, "\n"))
+			lines = nil
+		}
+	}
+
+	for _, line := range strings.Split(text, "\n") {
+		isFence := strings.HasPrefix(strings.TrimSpace(line), "```")
+		switch {
+		case isFence && !inFence:
+			flush()
+			inFence = true
+			lines = append(lines, line)
+		case isFence && inFence:
+			lines = append(lines, line)
+			inFence = false
+			flush()
+		case !inFence && strings.TrimSpace(line) == "":
+			flush()
+		default:
+			lines = append(lines, line)
+		}
+	}
+	flush()
+
+	return blocks
+}
+
+// splitMarkdownBlock splits a paragraph or code block into pieces no longer than the limit.
+func splitMarkdownBlock(block string, limit int) []string {
+	if utf8.RuneCountInString(block) <= limit {
+		return []string{block}
+	}
+
+	lines := strings.Split(block, "\n")
+	if !strings.HasPrefix(strings.TrimSpace(lines[0]), "```") {
+		return packPieces(lines, "\n", limit)
+	}
+
+	// Split the content of the code block and fence every part again.
+	open, content := lines[0], lines[1:]
+	closing := "```"
+	if len(content) > 0 && strings.HasPrefix(strings.TrimSpace(content[len(content)-1]), "```") {
+		content = content[:len(content)-1]
+	}
+
+	innerLimit := limit - utf8.RuneCountInString(open) - utf8.RuneCountInString(closing) - 2
+	pieces := packPieces(content, "\n", innerLimit)
+	for i, piece := range pieces {
+		pieces[i] = open + "\n" + piece + "\n" + closing
+	}
+	return pieces
+}
+
+// packPieces joins the parts with the separator into pieces no longer than the limit.
+// A part longer than the limit is split into words, and a word longer than the limit is split into characters.
+func packPieces(parts []string, sep string, limit int) []string {
+	var (
+		pieces  []string
+		current string
+		started bool
+	)
+
+	for _, part := range parts {
+		if utf8.RuneCountInString(part) > limit {
+			if started {
+				pieces = append(pieces, current)
+				current, started = "", false
+			}
+			if sep == " " {
+				pieces = append(pieces, splitRunes(part, limit)...)
+			} else {
+				pieces = append(pieces, packPieces(strings.Split(part, " "), " ", limit)...)
+			}
+			continue
+		}
+
+		if started && utf8.RuneCountInString(current)+utf8.RuneCountInString(sep)+utf8.RuneCountInString(part) > limit {
+			pieces = append(pieces, current)
+			current, started = "", false
+		}
+		if started {
+			current += sep
+		}
+		current += part
+		started = true
+	}
+
+	if started {
+		pieces = append(pieces, current)
+	}
+
+	return pieces
+}
+
+// splitRunes splits the text into pieces of at most limit characters.
+func splitRunes(text string, limit int) []string {
+	var pieces []string
+	runes := []rune(text)
+	for len(runes) > limit {
+		pieces = append(pieces, string(runes[:limit]))
+		runes = runes[limit:]
+	}
+	return append(pieces, string(runes))
+}
+
+// MarkdownSections returns the text as section blocks that fit within the Slack section limit.
+func MarkdownSections(text string) []blockkit.Block {
+	var blocks []blockkit.Block
+	for _, chunk := range SplitMarkdown(text, blockkit.MaxSectionTextLength) {
+		blocks = append(blocks, blockkit.Section(blockkit.Markdown(chunk)))
+	}
+	return blocks
+}
+
+// AnswerSections returns the answer as section blocks, like MarkdownSections, with the answer block IDs.
+func AnswerSections(text string) []blockkit.Block {
+	var blocks []blockkit.Block
+	for i, chunk := range SplitMarkdown(text, blockkit.MaxSectionTextLength) {
+		blocks = append(blocks, blockkit.Section(blockkit.Markdown(chunk)).WithBlockID(AnswerTextBlockID(i)))
+	}
+	return blocks
+}
@@ -0,0 +1,85 @@
+// Copyright (c) Spectro Cloud
+// SPDX-License-Identifier: Apache-2.0
+
+package internal
+
+import (
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+func TestSplitMarkdown(t *testing.T) {
+	code := "```yaml\n" + strings.Repeat("key: value\n", 30) + "```"
+
+	tests := []struct {
+		name   string
+		text   string
+		limit  int
+		chunks int
+	}{
+		{"empty", "", 100, 0},
+		{"fits", "A short answer.", 100, 1},
+		{"paragraphs", strings.Repeat("A paragraph of forty characters........\n\n", 5), 100, 3},
+		{"long line", strings.Repeat("word ", 100), 100, 5},
+		{"long word", strings.Repeat("x", 250), 100, 3},
+		{"multibyte characters", strings.Repeat("é", 250), 100, 3},
+		{"long code block", code, 100, 4},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			chunks := SplitMarkdown(tc.text, tc.limit)
+			if len(chunks) != tc.chunks {
+				t.Errorf("unexpected number of chunks: got %d, want %d", len(chunks), tc.chunks)
+			}
+			for i, chunk := range chunks {
+				if utf8.RuneCountInString(chunk) > tc.limit {
+					t.Errorf("chunk %d is longer than the limit: %d characters", i, utf8.RuneCountInString(chunk))
+				}
+			}
+		})
+	}
+}
+
+func TestSplitMarkdownCodeBlocks(t *testing.T) {
+	text := "Apply the manifest.\n\n```yaml\nkind: Pod\n\nmetadata:\n  name: test\n```\n\nThen verify it."
+
+	// The blank line inside the code block doesn't end the block.
+	chunks := SplitMarkdown(text, 50)
+	for _, chunk := range chunks {
+		if strings.Count(chunk, "```")%2 != 0 {
+			t.Errorf("the chunk has an unclosed code block: %q", chunk)
+		}
+	}
+
+	// A code block longer than the limit is fenced again in every chunk.
+	long := "```bash\n" + strings.Repeat("echo hello world\n", 20) + "```"
+	for _, chunk := range SplitMarkdown(long, 100) {
+		if !strings.HasPrefix(chunk, "```bash\n") || !strings.HasSuffix(chunk, "\n```") {
+			t.Errorf("the chunk isn't a fenced code block: %q", chunk)
+		}
+	}
+}
+
+func TestTruncateText(t *testing.T) {
+	if got := TruncateText("short", 10); got != "short" {
+		t.Errorf("TruncateText changed a short text: %q", got)
+	}
+	got := TruncateText(strings.Repeat("a", 20), 10)
+	if utf8.RuneCountInString(got) > 10 || !strings.HasSuffix(got, "…") {
+		t.Errorf("TruncateText returned an unexpected text: %q", got)
+	}
+}
+
+func TestMarkdownSections(t *testing.T) {
+	blocks := MarkdownSections(strings.Repeat("A long paragraph of text.\n\n", 500))
+	if len(blocks) < 2 {
+		t.Fatalf("expected the text to be split into several sections, got %d", len(blocks))
+	}
+	for i, block := range blocks {
+		if err := block.Validate(); err != nil {
+			t.Errorf("section %d exceeds the Slack limits: %v", i, err)
+		}
+	}
+}
@@ -0,0 +1,220 @@
+// Copyright (c) Spectro Cloud
+// SPDX-License-Identifier: Apache-2.0
+
+package internal
+
+import (
+	"net/url"
+	"path"
+	"regexp"
+	"sort"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+var (
+	snippetLinkPattern   = regexp.MustCompile(`!?\[([^\]]*)\]\([^)]*\)`)
+	snippetMarkupPattern = regexp.MustCompile("[*`~>|#]+")
+	slugPattern          = regexp.MustCompile(`[^a-z0-9]+`)
+)
+
+// Source is a documentation page used to answer a question.
+type Source struct {
+	// URL is the link to the page, including the anchor of the section, if any.
+	URL string
+	// Title is the title of the page.
+	Title string
+	// Section is the heading of the section linked by the anchor of the URL, if any.
+	Section string
+	// Score is the relevance of the source to the question. Sources are ordered by relevance.
+	Score float64
+	// Snippet is the beginning of the content of the source, without Markdown.
+	Snippet string
+}
+
+// NewSources returns the sources of an answer, ordered by relevance. Sources with the same canonical URL are
+// only returned once, with the anchor and snippet of the most relevant one. Links to the home page of the
+// documentation are skipped because they don't help the user.
+func NewSources(list []MendableSources) []Source {
+	home := CanonicalURL(PublicDocumentationURL)
+	sources := make([]Source, 0, len(list))
+	indexes := make(map[string]int)
+
+	for _, item := range list {
+		link := strings.TrimSpace(item.Link)
+		canonical := CanonicalURL(link)
+		if link == "" || canonical == home {
+			continue
+		}
+
+		// Mendable doesn't always set the relevance score, the search score is used instead.
+		score := item.RelevancyScore
+		if score == 0 {
+			score = item.Score
+		}
+
+		source := Source{
+			URL:     link,
+			Title:   sourceTitle(link, item.Content),
+			Section: sourceSection(link, item.Content),
+			Score:   score,
+			Snippet: sourceSnippet(item.Content, DefaultSourceSnippetLength),
+		}
+
+		if i, exists := indexes[canonical]; exists {
+			if source.Score > sources[i].Score {
+				sources[i] = source
+			}
+			continue
+		}
+		indexes[canonical] = len(sources)
+		sources = append(sources, source)
+	}
+
+	sort.SliceStable(sources, func(i, j int) bool {
+		return sources[i].Score > sources[j].Score
+	})
+
+	return sources
+}
+
+// Label returns the title of the source, followed by the section if the link has one.
+func (s Source) Label() string {
+	if s.Section == "" || s.Section == s.Title {
+		return s.Title
+	}
+	return s.Title + " › " + s.Section
+}
+
+// Mrkdwn returns the source as a Slack link labeled with the title and section, followed by the snippet.
+func (s Source) Mrkdwn() string {
+	label := strings.ReplaceAll(TruncateText(s.Label(), DefaultSourceLabelLength), "|", "¦")
+	text := "<" + slackEscaper.Replace(s.URL) + "|" + slackEscaper.Replace(label) + ">"
+	if s.Snippet != "" {
+		text += "\n" + slackEscaper.Replace(s.Snippet)
+	}
+	return text
+}
+
+// LimitSources returns the most relevant sources, up to the limit. All sources are returned if the limit is 0.
+func LimitSources(sources []Source, limit int) []Source {
+	if limit <= 0 || len(sources) <= limit {
+		return sources
+	}
+	return sources[:limit]
+}
+
+// CanonicalURL returns the link without the anchor and trailing slash, and with a lowercase scheme and host.
+// Links to the same page have the same canonical URL.
+func CanonicalURL(link string) string {
+	parsed, err := url.Parse(strings.TrimSpace(link))
+	if err != nil {
+		return strings.TrimSuffix(link, "/")
+	}
+
+	parsed.Fragment, parsed.RawFragment = "", ""
+	parsed.Scheme = strings.ToLower(parsed.Scheme)
+	parsed.Host = strings.ToLower(parsed.Host)
+	parsed.Path = strings.TrimSuffix(parsed.Path, "/")
+	parsed.RawPath = strings.TrimSuffix(parsed.RawPath, "/")
+
+	return parsed.String()
+}
+
+// sourceTitle returns the first top-level heading of the content, or the title derived from the last
+// segment of the URL path, such as "Deploy a cluster" for /clusters/deploy-a-cluster.
+func sourceTitle(link, content string) string {
+	for _, line := range strings.Split(content, "\n") {
+		trimmed := strings.TrimSpace(line)
+		if strings.HasPrefix(trimmed, "# ") {
+			if title := headingText(trimmed); title != "" {
+				return title
+			}
+		}
+	}
+
+	parsed, err := url.Parse(link)
+	if err != nil {
+		return link
+	}
+	if segment := path.Base(strings.TrimSuffix(parsed.Path, "/")); segment != "." && segment != "/" && segment != "" {
+		return humanize(segment)
+	}
+	return parsed.Host
+}
+
+// sourceSection returns the heading of the section linked by the anchor of the URL. The heading is looked up
+// in the content and derived from the anchor if the content doesn't have it.
+func sourceSection(link, content string) string {
+	parsed, err := url.Parse(link)
+	if err != nil || parsed.Fragment == "" {
+		return ""
+	}
+
+	anchor := slugify(parsed.Fragment)
+	for _, line := range strings.Split(content, "\n") {
+		if heading := headingText(line); heading != "" && slugify(heading) == anchor {
+			return heading
+		}
+	}
+
+	return humanize(parsed.Fragment)
+}
+
+// sourceSnippet returns the beginning of the content as plain text, up to the limit, in characters.
+// Headings are skipped, because the title and section are displayed separately.
+func sourceSnippet(content string, limit int) string {
+	var words []string
+	for _, line := range strings.Split(content, "\n") {
+		if mdHeadingPattern.MatchString(line) || mdRulePattern.MatchString(line) {
+			continue
+		}
+		line = snippetLinkPattern.ReplaceAllString(line, "$1")
+		line = mdBulletPattern.ReplaceAllString(line, "$2")
+		line = snippetMarkupPattern.ReplaceAllString(line, " ")
+		words = append(words, strings.Fields(line)...)
+	}
+
+	snippet := strings.Join(words, " ")
+	if utf8.RuneCountInString(snippet) <= limit {
+		return snippet
+	}
+
+	// The snippet ends at a word boundary if there is one.
+	truncated := string([]rune(snippet)[:limit-1])
+	if i := strings.LastIndex(truncated, " "); i > limit/2 {
+		truncated = truncated[:i]
+	}
+	return strings.TrimRightFunc(truncated, func(r rune) bool { return unicode.IsSpace(r) || unicode.IsPunct(r) }) + "…"
+}
+
+// headingText returns the text of a Markdown heading without the leading hashes and formatting.
+func headingText(line string) string {
+	match := mdHeadingPattern.FindStringSubmatch(line)
+	if match == nil {
+		return ""
+	}
+	text := snippetLinkPattern.ReplaceAllString(match[1], "$1")
+	return strings.Join(strings.Fields(snippetMarkupPattern.ReplaceAllString(text, " ")), " ")
+}
+
+// humanize returns a URL path segment or anchor as text, such as "Install the cli" for install-the-cli.
+// Only the first letter is capitalized, because the original capitalization is unknown.
+func humanize(segment string) string {
+	if unescaped, err := url.PathUnescape(segment); err == nil {
+		segment = unescaped
+	}
+	segment = strings.TrimSuffix(strings.TrimSuffix(segment, ".html"), ".md")
+	text := strings.Join(strings.FieldsFunc(segment, func(r rune) bool { return r == '-' || r == '_' }), " ")
+	if text == "" {
+		return ""
+	}
+	first, size := utf8.DecodeRuneInString(text)
+	return string(unicode.ToUpper(first)) + text[size:]
+}
+
+// slugify returns the anchor generated for a heading, such as install-the-cli for "Install the CLI".
+func slugify(text string) string {
+	return strings.Trim(slugPattern.ReplaceAllString(strings.ToLower(text), "-"), "-")
+}
@@ -0,0 +1,65 @@
+// Copyright (c) Spectro Cloud
+// SPDX-License-Identifier: Apache-2.0
+
+package internal
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewSources(t *testing.T) {
+	sources := NewSources([]MendableSources{
+		{Link: "https://docs.spectrocloud.com/clusters/", Content: "# Clusters\n\nCreate a cluster.", RelevancyScore: 0.4},
+		{Link: "https://docs.spectrocloud.com/registries#add-a-registry", Content: "## Add a Registry\n\nUse the **Registries** page.", RelevancyScore: 0.6},
+		{Link: "https://docs.spectrocloud.com/clusters#deploy", Content: "Deploy the [cluster](/clusters/deploy).", RelevancyScore: 0.9},
+		{Link: "https://docs.spectrocloud.com/", Content: "Home", RelevancyScore: 1},
+		{Link: "https://docs.spectrocloud.com/edge", Content: "Edge hosts.", Score: 0.5},
+	})
+
+	// The duplicate clusters page keeps the anchor of the most relevant source, and the home page is skipped.
+	assert.Equal(t, []Source{
+		{URL: "https://docs.spectrocloud.com/clusters#deploy", Title: "Clusters", Section: "Deploy", Score: 0.9, Snippet: "Deploy the cluster."},
+		{URL: "https://docs.spectrocloud.com/registries#add-a-registry", Title: "Registries", Section: "Add a Registry", Score: 0.6, Snippet: "Use the Registries page."},
+		{URL: "https://docs.spectrocloud.com/edge", Title: "Edge", Score: 0.5, Snippet: "Edge hosts."},
+	}, sources)
+
+	assert.Len(t, LimitSources(sources, 2), 2)
+	assert.Len(t, LimitSources(sources, 0), 3)
+	assert.Len(t, LimitSources(sources, 10), 3)
+}
+
+func TestCanonicalURL(t *testing.T) {
+	tests := map[string]string{
+		"https://docs.spectrocloud.com/clusters/":         "https://docs.spectrocloud.com/clusters",
+		"https://Docs.SpectroCloud.com/clusters#deploy":   "https://docs.spectrocloud.com/clusters",
+		"https://docs.spectrocloud.com/clusters/#deploy":  "https://docs.spectrocloud.com/clusters",
+		"https://docs.spectrocloud.com/":                  "https://docs.spectrocloud.com",
+		"https://docs.spectrocloud.com/search?q=edge#top": "https://docs.spectrocloud.com/search?q=edge",
+	}
+
+	for link, expected := range tests {
+		assert.Equal(t, expected, CanonicalURL(link), link)
+	}
+}
+
+func TestSourceTitleAndSection(t *testing.T) {
+	assert.Equal(t, "Deploy a cluster", sourceTitle("https://docs.spectrocloud.com/clusters/deploy-a-cluster/", ""))
+	assert.Equal(t, "Palette", sourceTitle("https://docs.spectrocloud.com/palette", "## Install\n# Palette"))
+	assert.Equal(t, "docs.spectrocloud.com", sourceTitle("https://docs.spectrocloud.com", ""))
+
+	assert.Equal(t, "Install the CLI", sourceSection("https://docs.spectrocloud.com/cli#install-the-cli", "### Install the `CLI`"))
+	assert.Equal(t, "Install the cli", sourceSection("https://docs.spectrocloud.com/cli#install-the-cli", ""))
+	assert.Empty(t, sourceSection("https://docs.spectrocloud.com/cli", "## Install"))
+}
+
+func TestSourceSnippet(t *testing.T) {
+	content := "# Title\n\n- Use the `palette` CLI.\n- Read the [guide](https://docs.spectrocloud.com/guide).\n\n---\n\n> Note"
+	assert.Equal(t, "Use the palette CLI. Read the guide. Note", sourceSnippet(content, 100))
+
+	snippet := sourceSnippet(strings.Repeat("word ", 100), 40)
+	assert.True(t, strings.HasSuffix(snippet, "word…"), snippet)
+	assert.LessOrEqual(t, len([]rune(snippet)), 40)
+}
@@ -3,6 +3,8 @@
 
 package internal
 
+import "encoding/json"
+
 /*
  * Mendable API types
  */
@@ -21,6 +23,8 @@ type MendableRatingScore int8
 const (
 	PositiveFeedbackScore MendableRatingScore = 1
 	NegativeFeedbackScore MendableRatingScore = -1
+	// NoFeedbackScore clears the rating of an answer.
+	NoFeedbackScore MendableRatingScore = 0
 )
 
 type MendableRequestPayload struct {
@@ -29,6 +33,8 @@ type MendableRequestPayload struct {
 	History        []HistoryItems `json:"history"`
 	ShouldStream   bool           `json:"shouldStream"`
 	ConversationID int64          `json:"conversation_id"`
+	// Metadata limits the answer to the documentation matching the metadata, such as a product and version.
+	Metadata map[string]string `json:"metadata,omitempty"`
 }
 
 type HistoryItems struct {
@@ -59,7 +65,7 @@ type MendableQueryResponse struct {
 	MessageID      string
 	Question       string
 	Answer         string
-	Links          []string
+	Sources        []Source
 	Confidence     string
 }
 
@@ -81,8 +87,15 @@ type SlackEvent struct {
 	TriggerID           string `schema:"trigger_id"`
 	APIAppID            string `schema:"api_app_id"`
 	IsEnterpriseInstall bool   `schema:"is_enterprise_install"`
+	EnterpriseID        string `schema:"enterprise_id"`
+	EnterpriseName      string `schema:"enterprise_name"`
+	// ConversationID is set by answer actions to continue the conversation of the answer.
+	// It's never decoded from the requests of Slack.
+	ConversationID string `schema:"-"`
 }
 
+// SlackPayload, SlackBlock and the types below decode the messages sent to Slack.
+// Messages are built with the blockkit package, which validates them against the Slack limits.
 type SlackPayload struct {
 	ResponseType    string       `json:"response_type,omitempty"`
 	DeleteOriginal  bool         `json:"delete_original,omitempty"`
@@ -95,6 +108,8 @@ type SlackBlock struct {
 	Fields   []SlackTextObject `json:"fields,omitempty"`
 	Elements []SlackElements   `json:"elements,omitempty"`
 	Text     *SlackTextObject  `json:"text,omitempty"`
+	// Accessory is the button displayed next to the text of a section.
+	Accessory *SlackElements `json:"accessory,omitempty"`
 }
 
 type SlackTextObject struct {
@@ -148,13 +163,76 @@ type SlackActionEvent struct {
 	Container           Container    `json:"container"`
 	TriggerID           string       `json:"trigger_id"`
 	Team                Team         `json:"team"`
-	Enterprise          interface{}  `json:"enterprise"`
+	Enterprise          *Enterprise  `json:"enterprise"`
 	IsEnterpriseInstall bool         `json:"is_enterprise_install"`
 	Channel             Channel      `json:"channel"`
 	Message             SlackMessage `json:"message"`
 	State               State        `json:"state"`
 	ResponseURL         string       `json:"response_url"`
 	Actions             []Action     `json:"actions"`
+	View                SlackView    `json:"view"`
+}
+
+// Types of the requests of the Slack Events API.
+const (
+	// SlackEventURLVerification is sent when the request URL of the Events API is configured.
+	SlackEventURLVerification string = "url_verification"
+	// SlackEventCallback wraps the events the app is subscribed to.
+	SlackEventCallback string = "event_callback"
+)
+
+// SlackEventRequest is a request of the Slack Events API.
+type SlackEventRequest struct {
+	Type      string            `json:"type"`
+	Challenge string            `json:"challenge"`
+	TeamID    string            `json:"team_id"`
+	EventID   string            `json:"event_id"`
+	Event     SlackMessageEvent `json:"event"`
+}
+
+// SlackMessageEvent is a message event of the Slack Events API.
+type SlackMessageEvent struct {
+	Type     string `json:"type"`
+	Subtype  string `json:"subtype"`
+	Channel  string `json:"channel"`
+	User     string `json:"user"`
+	BotID    string `json:"bot_id"`
+	Text     string `json:"text"`
+	TS       string `json:"ts"`
+	ThreadTS string `json:"thread_ts"`
+}
+
+// IsThreadReply returns true if the event is a new reply posted by a user in a thread.
+// Messages posted by bots, edits, and the parent message of the thread are ignored.
+func (e SlackMessageEvent) IsThreadReply() bool {
+	return e.Type == "message" && e.Subtype == "" && e.BotID == "" && e.User != "" && e.ThreadTS != "" && e.ThreadTS != e.TS
+}
+
+// SlackInteractionViewSubmission is the type of the interaction sent when a user submits a dialog.
+const SlackInteractionViewSubmission string = "view_submission"
+
+// SlackView is the dialog submitted by a view_submission interaction.
+type SlackView struct {
+	ID              string    `json:"id"`
+	CallbackID      string    `json:"callback_id"`
+	PrivateMetadata string    `json:"private_metadata"`
+	State           ViewState `json:"state"`
+}
+
+// ViewState contains the values of the inputs of a dialog, keyed by block ID and action ID.
+type ViewState struct {
+	Values map[string]map[string]ViewStateValue `json:"values"`
+}
+
+// ViewStateValue is the value of a dialog input.
+type ViewStateValue struct {
+	Type  string `json:"type"`
+	Value string `json:"value"`
+}
+
+// Value returns the value of the input with the block ID and action ID, or an empty string if the input wasn't filled in.
+func (s ViewState) Value(blockID, actionID string) string {
+	return s.Values[blockID][actionID].Value
 }
 
 type SlackUser struct {
@@ -176,6 +254,11 @@ type Team struct {
 	Domain string `json:"domain"`
 }
 
+type Enterprise struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+}
+
 type Channel struct {
 	ID   string `json:"id"`
 	Name string `json:"name"`
@@ -204,6 +287,19 @@ type ActionsText struct {
 	Verbatim bool   `json:"verbatim,omitempty"`
 }
 
+// UnmarshalJSON decodes a text object. The elements of context blocks are decoded as actions,
+// and their text is a string, which is decoded as a text object without a type.
+func (t *ActionsText) UnmarshalJSON(data []byte) error {
+	var text string
+	if err := json.Unmarshal(data, &text); err == nil {
+		*t = ActionsText{Text: text}
+		return nil
+	}
+
+	type actionsText ActionsText
+	return json.Unmarshal(data, (*actionsText)(t))
+}
+
 type State struct {
 	Values map[string]interface{} `json:"values"`
 }
@@ -215,5 +311,6 @@ type Action struct {
 	Text     ActionsText `json:"text,omitempty"`
 	Value    string      `json:"value,omitempty"`
 	Style    string      `json:"style,omitempty"`
+	URL      string      `json:"url,omitempty"`
 	ActionTS string      `json:"action_ts,omitempty"`
 }
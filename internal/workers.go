@@ -0,0 +1,44 @@
+// Copyright (c) Spectro Cloud
+// SPDX-License-Identifier: Apache-2.0
+
+package internal
+
+import (
+	"sync/atomic"
+)
+
+// WorkerTracker keeps count of the Go routines handling commands and actions in the background.
+// The count is used to report the worker backlog in the readiness check.
+type WorkerTracker struct {
+	active atomic.Int64
+	limit  int64
+}
+
+// NewWorkerTracker returns a new WorkerTracker. The limit is the backlog size at which the server reports it's not ready.
+func NewWorkerTracker(limit int) *WorkerTracker {
+	return &WorkerTracker{limit: int64(limit)}
+}
+
+// Go runs the function in a tracked Go routine.
+func (w *WorkerTracker) Go(fn func()) {
+	w.active.Add(1)
+	go func() {
+		defer w.active.Add(-1)
+		fn()
+	}()
+}
+
+// Active returns the number of Go routines currently running.
+func (w *WorkerTracker) Active() int64 {
+	return w.active.Load()
+}
+
+// Limit returns the backlog limit.
+func (w *WorkerTracker) Limit() int64 {
+	return w.limit
+}
+
+// Saturated returns true if the backlog has reached the limit.
+func (w *WorkerTracker) Saturated() bool {
+	return w.limit > 0 && w.Active() >= w.limit
+}
@@ -8,6 +8,7 @@ import (
 	"crypto/tls"
 	"fmt"
 	"net/http"
+	"os"
 	"strings"
 
 	"github.com/rs/zerolog/log"
@@ -15,22 +16,37 @@ import (
 	_ "go.uber.org/automaxprocs"
 	"spectrocloud.com/spectromate/endpoints"
 	"spectrocloud.com/spectromate/internal"
+	"spectrocloud.com/spectromate/slackCmds"
 )
 
 var (
-	globalRedisPort      int64
-	globalRedisURL       string
-	globalRedisClient    internal.Cache
-	globalRedisPassword  string
-	globalRedisUser      string
-	globalRedisTLS       string
-	globalTraceLevel     string
-	globalHost           string
-	globalPort           string
-	globalHostURL        string = globalHost + ":" + globalPort
-	globalSigningSecret  string
-	globalMendableAPIKey string
-	Version              string
+	globalRedisPort          int64
+	globalRedisURL           string
+	globalRedisClient        internal.Cache
+	globalRedisPassword      string
+	globalRedisUser          string
+	globalRedisTLS           string
+	globalTraceLevel         string
+	globalHost               string
+	globalPort               string
+	globalHostURL            string = globalHost + ":" + globalPort
+	globalSigningSecret      string
+	globalMendableAPIKey     string
+	globalConversation       internal.ConversationConfig
+	globalDocsScopes         internal.DocsScopeConfig
+	globalAnswerBackends     internal.AnswerBackends
+	globalSlackBotToken      string
+	globalSlackAdminUsers    []string
+	globalEscalation         internal.EscalationConfig
+	globalConfidence         internal.ConfidenceGate
+	globalAccessAllow        internal.AccessList
+	globalAccessDeny         internal.AccessList
+	globalAuditSink          string
+	globalAuditTextMode      internal.AuditTextMode
+	globalRedactor           *internal.Redactor
+	globalRateLimits         internal.RateLimitConfig
+	globalWorkerBacklogLimit int
+	Version                  string
 )
 
 func init() {
@@ -50,6 +66,82 @@ func init() {
 	globalRedisPassword = internal.Getenv("REDIS_PASSWORD", "")
 	globalRedisUser = internal.Getenv("REDIS_USER", "")
 	reditConnectionString := fmt.Sprintf("%s:%d", globalRedisURL, globalRedisPort)
+	globalConversation = internal.ConversationConfig{
+		TTL:             internal.StringToDuration(internal.Getenv("CONVERSATION_TTL", ""), internal.DefaultCacheExpirationPeriod),
+		MaxHistoryItems: int(internal.StringToInt64(internal.Getenv("MAX_HISTORY_ITEMS", fmt.Sprint(internal.DefaultMaxHistoryItems)))),
+		MaxHistoryChars: int(internal.StringToInt64(internal.Getenv("MAX_HISTORY_CHARS", fmt.Sprint(internal.DefaultMaxHistoryChars)))),
+		MaxQuestions:    int(internal.StringToInt64(internal.Getenv("MAX_QUESTIONS_PER_CONVERSATION", fmt.Sprint(internal.DefaultMaxQuestionsPerConversation)))),
+	}
+
+	channelScopes, err := internal.ParseChannelScopes(internal.Getenv("DOCS_CHANNEL_SCOPES", ""))
+	if err != nil {
+		log.Fatal().Err(err).Msg("The environment variable DOCS_CHANNEL_SCOPES is invalid. Exiting...")
+	}
+	globalDocsScopes = internal.DocsScopeConfig{
+		Channels:     channelScopes,
+		BaseURL:      internal.PublicDocumentationURL,
+		VersionedURL: internal.Getenv("DOCS_VERSIONED_URL", ""),
+		MaxSources:   int(internal.StringToInt64(internal.Getenv("MAX_SOURCES", fmt.Sprint(internal.DefaultMaxSources)))),
+	}
+
+	globalAnswerBackends, err = internal.ParseAnswerBackends(internal.Getenv("MENDABLE_BACKENDS", ""))
+	if err != nil {
+		log.Fatal().Err(err).Msg("The environment variable MENDABLE_BACKENDS is invalid. Exiting...")
+	}
+	globalSlackBotToken = internal.Getenv("SLACK_BOT_TOKEN", "")
+	globalSlackAdminUsers = strings.Split(internal.Getenv("SLACK_ADMIN_USERS", ""), ",")
+	globalEscalation = internal.EscalationConfig{Channel: internal.Getenv("SUPPORT_CHANNEL", "")}
+	if globalEscalation.Enabled() && globalSlackBotToken == "" {
+		log.Warn().Msg("The environment variable SUPPORT_CHANNEL requires SLACK_BOT_TOKEN. Escalation is disabled.")
+		globalEscalation = internal.EscalationConfig{}
+	}
+
+	globalConfidence.Threshold, err = internal.ParseConfidenceThreshold(internal.Getenv("MIN_ANSWER_CONFIDENCE", ""))
+	if err != nil {
+		log.Fatal().Err(err).Msg("The environment variable MIN_ANSWER_CONFIDENCE is invalid. Exiting...")
+	}
+	globalConfidence.Mode, err = internal.ParseLowConfidenceMode(internal.Getenv("LOW_CONFIDENCE_MODE", string(internal.LowConfidenceCaveat)))
+	if err != nil {
+		log.Fatal().Err(err).Msg("The environment variable LOW_CONFIDENCE_MODE is invalid. Exiting...")
+	}
+
+	globalAccessAllow = internal.AccessList{
+		Users:       internal.ParseIDList(internal.Getenv("ACCESS_ALLOW_USERS", "")),
+		Channels:    internal.ParseIDList(internal.Getenv("ACCESS_ALLOW_CHANNELS", "")),
+		Teams:       internal.ParseIDList(internal.Getenv("ACCESS_ALLOW_TEAMS", "")),
+		Enterprises: internal.ParseIDList(internal.Getenv("ACCESS_ALLOW_ENTERPRISES", "")),
+	}
+	globalAccessDeny = internal.AccessList{
+		Users:       internal.ParseIDList(internal.Getenv("ACCESS_DENY_USERS", "")),
+		Channels:    internal.ParseIDList(internal.Getenv("ACCESS_DENY_CHANNELS", "")),
+		Teams:       internal.ParseIDList(internal.Getenv("ACCESS_DENY_TEAMS", "")),
+		Enterprises: internal.ParseIDList(internal.Getenv("ACCESS_DENY_ENTERPRISES", "")),
+	}
+
+	redactionPatterns, err := internal.ParseRedactionPatterns(internal.Getenv("REDACTION_PATTERNS", ""))
+	if err != nil {
+		log.Fatal().Err(err).Msg("The environment variable REDACTION_PATTERNS is invalid. Exiting...")
+	}
+	globalRedactor, err = internal.NewRedactor(redactionPatterns, strings.Split(internal.Getenv("REDACTION_DISABLED_DETECTORS", ""), ","))
+	if err != nil {
+		log.Fatal().Err(err).Msg("The redaction settings are invalid. Exiting...")
+	}
+	// Secrets are removed from every log line, including the questions logged at debug level.
+	// Email and IP addresses are kept, because they're needed to troubleshoot requests.
+	log.Logger = log.Output(internal.NewRedactingWriter(os.Stderr, globalRedactor.Secrets()))
+
+	globalAuditSink = internal.Getenv("AUDIT_SINK", "")
+	globalAuditTextMode, err = internal.ParseAuditTextMode(internal.Getenv("AUDIT_QUESTION_TEXT", string(internal.AuditTextHash)))
+	if err != nil {
+		log.Fatal().Err(err).Msg("The environment variable AUDIT_QUESTION_TEXT is invalid. Exiting...")
+	}
+
+	globalWorkerBacklogLimit = int(internal.StringToInt64(internal.Getenv("WORKER_BACKLOG_LIMIT", fmt.Sprint(internal.DefaultWorkerBacklogLimit))))
+	globalRateLimits = internal.RateLimitConfig{
+		User:      parseRateLimit("RATE_LIMIT_USER", internal.DefaultUserRateLimit),
+		Channel:   parseRateLimit("RATE_LIMIT_CHANNEL", internal.DefaultChannelRateLimit),
+		Workspace: parseRateLimit("RATE_LIMIT_WORKSPACE", internal.DefaultWorkspaceRateLimit),
+	}
 
 	if globalSigningSecret == "" {
 		log.Fatal().Msg("The required environment variable SLACK_SIGNING_SECRET is not set. Exiting...")
@@ -71,35 +163,79 @@ func init() {
 		globalRedisUser,
 		globalRedisPassword,
 		tlsConfig)
-	log.Debug().Msg("Checking database connection...")
-	err := rdb.Ping()
-	if err != nil {
-		log.Debug().Msg("Redis is not available")
-		log.Fatal().Err(err).Msg("Error connecting to redis")
-	}
 	globalRedisClient = rdb
 }
 
 func main() {
 	ctx := context.Background()
 	rdb := globalRedisClient
-	healthRoute := endpoints.NewHealthHandlerContext(ctx, Version)
-	slackRoute := endpoints.NewSlackHandlerContext(ctx, globalSigningSecret, globalMendableAPIKey, rdb, Version)
-	slackActionsRoute := endpoints.NewActionsHandlerContext(ctx, globalSigningSecret, globalMendableAPIKey, Version)
+	// The server starts even if Redis is unavailable. The readiness endpoint reports
+	// not ready until the connection is established in the background.
+	log.Debug().Msg("Checking database connection...")
+	go internal.MonitorCache(ctx, rdb, internal.DefaultCacheMonitorInterval, internal.DefaultCacheRetryDelay)
+	workers := internal.NewWorkerTracker(globalWorkerBacklogLimit)
+	backendCheck := internal.NewCachedCheck(internal.DefaultHealthCheckCacheTTL, internal.MendableReachable)
+	healthRoute := endpoints.NewHealthHandlerContext(ctx, Version, rdb, backendCheck, workers)
+	rateLimiter := internal.NewRateLimiter(rdb, globalRateLimits)
+	auditSink, err := internal.NewAuditSink(globalAuditSink, rdb)
+	if err != nil {
+		log.Fatal().Err(err).Msg("The environment variable AUDIT_SINK is invalid. Exiting...")
+	}
+	auditLogger := internal.NewAuditLogger(auditSink, globalAuditTextMode, globalRedactor)
+	accessPolicy := internal.NewAccessPolicy(globalAccessAllow, globalAccessDeny)
+	slackAPI := internal.NewSlackAPI(globalSlackBotToken, internal.SlackAPIURL)
+	commands := slackCmds.NewDefaultRegistry(internal.NewConfigAuthorizer(globalSlackAdminUsers, slackAPI), globalAnswerBackends)
+	deps := endpoints.Dependencies{
+		SigningSecret:  globalSigningSecret,
+		MendableAPIKey: globalMendableAPIKey,
+		Cache:          rdb,
+		Version:        Version,
+		Conversation:   globalConversation,
+		Scopes:         globalDocsScopes,
+		Backends:       globalAnswerBackends,
+		RateLimiter:    rateLimiter,
+		Workers:        workers,
+		Commands:       commands,
+		Access:         accessPolicy,
+		Audit:          auditLogger,
+		Redactor:       globalRedactor,
+		SlackAPI:       slackAPI,
+		Escalation:     globalEscalation,
+		Confidence:     globalConfidence,
+	}
+	slackRoute := endpoints.NewSlackHandlerContext(ctx, deps)
+	slackActionsRoute := endpoints.NewActionsHandlerContext(ctx, deps, slackRoute.RunCommand)
+	slackEventsRoute := endpoints.NewEventsHandlerContext(ctx, deps)
 
-	http.HandleFunc(internal.ApiPrefixV1+"health", healthRoute.HealthHTTPHandler)
-	http.HandleFunc(internal.ApiPrefixV1+"slack", slackRoute.SlackHTTPHandler)
-	http.HandleFunc(internal.ApiPrefixV1+"slack/actions", slackActionsRoute.ActionsHTTPHandler)
+	// The routes are served by a dedicated mux, so handlers registered on the default mux by imported
+	// packages aren't exposed publicly.
+	mux := http.NewServeMux()
+	mux.HandleFunc(internal.ApiPrefixV1+"health", healthRoute.HealthHTTPHandler)
+	mux.HandleFunc(internal.ApiPrefixV1+"health/live", healthRoute.HealthHTTPHandler)
+	mux.HandleFunc(internal.ApiPrefixV1+"health/ready", healthRoute.ReadyHTTPHandler)
+	mux.HandleFunc(internal.ApiPrefixV1+"slack", slackRoute.SlackHTTPHandler)
+	mux.HandleFunc(internal.ApiPrefixV1+"slack/actions", slackActionsRoute.ActionsHTTPHandler)
+	mux.HandleFunc(internal.ApiPrefixV1+"slack/events", slackEventsRoute.EventsHTTPHandler)
 
 	log.Info().Msgf("Server is configured for port %s and listing on %s", globalPort, globalHostURL)
 	log.Info().Msgf("API Server version:  %s", Version)
 	log.Info().Msgf("Redis is configured for %s:%d", globalRedisURL, globalRedisPort)
+	log.Info().Msgf("Conversations expire after %s and are limited to %d questions", globalConversation.CacheTTL(), globalConversation.MaxQuestions)
 	log.Info().Msgf("Trace level set to: %s", globalTraceLevel)
 	log.Info().Msg("Starting server...")
 	http.DefaultClient = internal.DefaultHTTPClient()
-	err := http.ListenAndServe(globalHostURL, nil)
+	err = http.ListenAndServe(globalHostURL, mux)
 	if err != nil {
 		log.Fatal().Err(err).Msg("There's an error with the server")
 	}
 
 }
+
+// parseRateLimit reads a rate limit from the environment variable and exits if the value is invalid.
+func parseRateLimit(key, fallback string) internal.RateLimit {
+	limit, err := internal.ParseRateLimit(internal.Getenv(key, fallback))
+	if err != nil {
+		log.Fatal().Err(err).Msgf("The environment variable %s is invalid. Exiting...", key)
+	}
+	return limit
+}
@@ -35,6 +35,54 @@ func (m *MockCache) EXPECT() *MockCacheMockRecorder {
 	return m.recorder
 }
 
+// AppendStream mocks base method.
+func (m *MockCache) AppendStream(ctx context.Context, stream string, maxLen int64, values map[string]interface{}) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AppendStream", ctx, stream, maxLen, values)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// AppendStream indicates an expected call of AppendStream.
+func (mr *MockCacheMockRecorder) AppendStream(ctx, stream, maxLen, values interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AppendStream", reflect.TypeOf((*MockCache)(nil).AppendStream), ctx, stream, maxLen, values)
+}
+
+// DeleteKey mocks base method.
+func (m *MockCache) DeleteKey(ctx context.Context, key string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteKey", ctx, key)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteKey indicates an expected call of DeleteKey.
+func (mr *MockCacheMockRecorder) DeleteKey(ctx, key interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteKey", reflect.TypeOf((*MockCache)(nil).DeleteKey), ctx, key)
+}
+
+// EvalScript mocks base method.
+func (m *MockCache) EvalScript(ctx context.Context, script string, keys []string, args ...interface{}) (interface{}, error) {
+	m.ctrl.T.Helper()
+	varargs := []interface{}{ctx, script, keys}
+	for _, a := range args {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "EvalScript", varargs...)
+	ret0, _ := ret[0].(interface{})
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// EvalScript indicates an expected call of EvalScript.
+func (mr *MockCacheMockRecorder) EvalScript(ctx, script, keys interface{}, args ...interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	varargs := append([]interface{}{ctx, script, keys}, args...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "EvalScript", reflect.TypeOf((*MockCache)(nil).EvalScript), varargs...)
+}
+
 // ExpireKey mocks base method.
 func (m *MockCache) ExpireKey(ctx context.Context, key string, t time.Duration) error {
 	m.ctrl.T.Helper()
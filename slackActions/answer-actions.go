@@ -0,0 +1,300 @@
+// Copyright (c) Spectro Cloud
+// SPDX-License-Identifier: Apache-2.0
+
+package slackActions
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/rs/zerolog/log"
+	"spectrocloud.com/spectromate/internal"
+	"spectrocloud.com/spectromate/internal/blockkit"
+	"spectrocloud.com/spectromate/slackCmds"
+)
+
+// CommandRunner runs a slash command on behalf of an answer action.
+// The text of the event is the command and its arguments, such as `ask --private how do I enable Prometheus?`.
+type CommandRunner func(ctx context.Context, event *internal.SlackEvent) error
+
+// followUpMetadata is stored in the private metadata of the follow-up dialog,
+// so the question is asked in the conversation and channel of the answer.
+type followUpMetadata struct {
+	ChannelID   string `json:"channel_id"`
+	ResponseURL string `json:"response_url"`
+	Private     bool   `json:"private,omitempty"`
+	Product     string `json:"product,omitempty"`
+	Version     string `json:"version,omitempty"`
+	// ConversationID is the conversation of the answer, if it's known.
+	ConversationID string `json:"conversation_id,omitempty"`
+}
+
+// ShareAnswerHandler posts a private answer in the channel, with the user who shared it.
+// The shared answer keeps the message ID of the private answer, so both collect the same ratings.
+// The returned error is the error reported to the user, if any.
+func ShareAnswerHandler(action *SlackActionFeedback) (shareErr error) {
+
+	var globalErr *error
+
+	// The error message is only displayed to the user sharing the answer.
+	defer func() {
+		errorEval(action.ctx, globalErr, action, true)
+		if globalErr != nil {
+			shareErr = *globalErr
+		}
+		globalErr = nil
+	}()
+
+	messageID := action.action.Actions[0].Value
+	state, found := lookupAnswer(action, messageID)
+	if !found {
+		answerUnavailable(action, messageID)
+		return
+	}
+
+	state.Private = false
+	state.Escalate = action.escalation.Enabled() && state.Uncertain()
+	payloads, err := slackCmds.AnswerPayloads(state, fmt.Sprintf(internal.DefaultSharedAnswerMessage, action.action.User.ID))
+	if err != nil {
+		log.Info().Err(err).Msg("Error creating the shared answer payload.")
+		globalErr = &err
+		return
+	}
+
+	for _, payload := range payloads {
+		err = internal.ReplyWithAnswer(action.ctx, action.action.ResponseURL, payload, false)
+		if err != nil {
+			log.Info().Err(err).Msg("Error when attempting to share the answer in the channel.")
+			internal.LogError(err)
+			globalErr = &err
+			return
+		}
+	}
+
+	log.Debug().Str("message_id", messageID).Str("user_id", action.action.User.ID).Msg("Shared the answer in the channel.")
+	return
+}
+
+// ShowRestHandler sends the sections of a long answer that didn't fit in the answer messages, privately to the user.
+// The sections are read from the stored answer, because they aren't part of any message.
+// The returned error is the error reported to the user, if any.
+func ShowRestHandler(action *SlackActionFeedback) (showErr error) {
+
+	var globalErr *error
+
+	defer func() {
+		errorEval(action.ctx, globalErr, action, true)
+		if globalErr != nil {
+			showErr = *globalErr
+		}
+		globalErr = nil
+	}()
+
+	messageID, index, err := internal.ParseShowRestValue(action.action.Actions[0].Value)
+	if err != nil {
+		log.Debug().Err(err).Msg("Invalid value of the show rest action.")
+		globalErr = &err
+		return
+	}
+
+	state, found, err := internal.GetAnswerState(action.ctx, action.cache, messageID)
+	if err != nil {
+		log.Warn().Err(err).Str("message_id", messageID).Msg("Unable to read the answer state.")
+		internal.RecordDegradation(internal.DegradationCacheRead)
+	}
+	if !found {
+		answerUnavailable(action, messageID)
+		return
+	}
+	if state.Title == "" {
+		state.Title = "Docs Answer"
+	}
+
+	payloads, err := slackCmds.AnswerRestPayloads(state, index)
+	if err != nil {
+		log.Info().Err(err).Msg("Error creating the rest of the answer payload.")
+		globalErr = &err
+		return
+	}
+	if len(payloads) == 0 {
+		answerUnavailable(action, messageID)
+		return
+	}
+
+	for _, payload := range payloads {
+		err = internal.ReplyWithAnswer(action.ctx, action.action.ResponseURL, payload, true)
+		if err != nil {
+			log.Info().Err(err).Msg("Error when attempting to return the rest of the answer back to Slack.")
+			internal.LogError(err)
+			globalErr = &err
+			return
+		}
+	}
+
+	log.Debug().Str("message_id", messageID).Int("index", index).Msg("Sent the rest of the answer.")
+	return
+}
+
+// RegenerateAnswerHandler asks the question of the answer again, in the same conversation and with the same docs scope.
+// The new answer is posted with the same visibility as the answer.
+func RegenerateAnswerHandler(action *SlackActionFeedback, run CommandRunner) error {
+	messageID := action.action.Actions[0].Value
+	state, found := lookupAnswer(action, messageID)
+	if !found || state.Query() == "" {
+		answerUnavailable(action, messageID)
+		return nil
+	}
+
+	text := askCommandText(state.Query(), action.action.Container.IsEphemeral, state.Product, state.Version)
+	event := commandEvent(action.action, action.action.Channel.ID, action.action.ResponseURL, text)
+	event.ConversationID = state.ConversationID
+	return run(action.ctx, event)
+}
+
+// FollowUpHandler opens the dialog to ask a follow-up question about the answer.
+// The dialog requires the Slack Web API, so the user is asked to use the slash command if there is no bot token.
+func FollowUpHandler(action *SlackActionFeedback, api *internal.SlackAPI) error {
+	if api == nil {
+		log.Debug().Msg("The Slack Web API is unavailable. Unable to open the follow-up dialog.")
+		return internal.ReplyWithMessage(action.ctx, action.action.ResponseURL, internal.DefaultFollowUpUnavailableMessage, true)
+	}
+
+	// The question and docs scope of the answer are optional, the follow-up is asked in the same conversation either way.
+	messageID := action.action.Actions[0].Value
+	state, _ := lookupAnswer(action, messageID)
+
+	view, err := followUpView(state, followUpMetadata{
+		ChannelID:   action.action.Channel.ID,
+		ResponseURL: action.action.ResponseURL,
+		Private:     action.action.Container.IsEphemeral,
+		Product:     state.Product,
+		Version:     state.Version,
+
+		ConversationID: state.ConversationID,
+	})
+	if err != nil {
+		log.Info().Err(err).Msg("Error creating the follow-up dialog.")
+		return err
+	}
+
+	err = api.OpenView(action.ctx, action.action.TriggerID, view)
+	if err != nil {
+		log.Info().Err(err).Str("message_id", messageID).Msg("Error opening the follow-up dialog.")
+		internal.LogError(err)
+		replyErr := internal.ReplyWithMessage(action.ctx, action.action.ResponseURL, internal.DefaultFollowUpUnavailableMessage, true)
+		if replyErr != nil {
+			log.Info().Err(replyErr).Msg("Error when attempting to return the follow-up message back to Slack.")
+		}
+		return err
+	}
+
+	return nil
+}
+
+// FollowUpSubmissionHandler asks the question submitted in the follow-up dialog, in the conversation of the answer.
+// Dialogs have no response URL, so the answer is sent to the response URL of the answer, which Slack accepts for 30 minutes.
+func FollowUpSubmissionHandler(action *SlackActionFeedback, run CommandRunner) error {
+	var metadata followUpMetadata
+	if err := json.Unmarshal([]byte(action.action.View.PrivateMetadata), &metadata); err != nil {
+		log.Info().Err(err).Msg("Error reading the private metadata of the follow-up dialog.")
+		return err
+	}
+
+	question := strings.TrimSpace(action.action.View.State.Value(internal.FollowUpInputBlockID, internal.FollowUpInputActionID))
+	text := askCommandText(question, metadata.Private, metadata.Product, metadata.Version)
+	event := commandEvent(action.action, metadata.ChannelID, metadata.ResponseURL, text)
+	event.ConversationID = metadata.ConversationID
+	return run(action.ctx, event)
+}
+
+// followUpView returns the follow-up dialog. The question of the answer is displayed above the input if it's known.
+func followUpView(state internal.AnswerState, metadata followUpMetadata) (*blockkit.View, error) {
+	data, err := json.Marshal(metadata)
+	if err != nil {
+		return nil, err
+	}
+
+	view := blockkit.Modal(internal.FollowUpViewCallbackID, "Ask a follow-up").
+		WithSubmit("Ask").
+		WithClose("Cancel").
+		WithPrivateMetadata(string(data))
+	if state.Query() != "" {
+		view.Add(blockkit.Section(blockkit.Markdown(internal.TruncateText("*Previous question:* "+state.Query(), blockkit.MaxSectionTextLength))))
+	}
+	view.Add(blockkit.Input("Follow-up question", blockkit.PlainTextInput(internal.FollowUpInputActionID, "Ask about the docs", true)).WithBlockID(internal.FollowUpInputBlockID))
+
+	return view, view.Validate()
+}
+
+// lookupAnswer returns the answer with the message ID. The content of the answer is looked up by message ID.
+// If the state expired or can't be read, the content is read from the answer blocks by block ID.
+func lookupAnswer(action *SlackActionFeedback, messageID string) (internal.AnswerState, bool) {
+	state, found, err := internal.GetAnswerState(action.ctx, action.cache, messageID)
+	if err != nil {
+		log.Warn().Err(err).Str("message_id", messageID).Msg("Unable to read the answer state. Reading the answer from the message.")
+		internal.RecordDegradation(internal.DegradationCacheRead)
+	}
+	if !found {
+		state, found = internal.AnswerStateFromBlocks(messageID, action.action.Message.Blocks)
+	}
+	if found && state.Title == "" {
+		state.Title = "Docs Answer"
+	}
+	return state, found
+}
+
+// answerUnavailable tells the user the answer of the action can't be found.
+func answerUnavailable(action *SlackActionFeedback, messageID string) {
+	log.Debug().Str("message_id", messageID).Msg("Unable to find the answer of the action.")
+	err := internal.ReplyWithMessage(action.ctx, action.action.ResponseURL, internal.DefaultAnswerUnavailableMessage, true)
+	if err != nil {
+		log.Info().Err(err).Msg("Error when attempting to return the answer unavailable message back to Slack.")
+		internal.LogError(err)
+	}
+}
+
+// askCommandText returns the text of the ask command asking the question with the visibility and docs scope.
+// The question follows the -- terminator, so a question such as "what does --new do?" isn't parsed as flags.
+func askCommandText(question string, private bool, product, version string) string {
+	parts := []string{"ask"}
+	if private {
+		parts = append(parts, "--private")
+	}
+	if product != "" {
+		parts = append(parts, "--product="+product)
+	}
+	if version != "" {
+		parts = append(parts, "--version="+version)
+	}
+	return strings.Join(append(parts, "--", question), " ")
+}
+
+// commandEvent returns the slash command event of an answer action, as if the user typed the command in the channel.
+func commandEvent(action *internal.SlackActionEvent, channelID, responseURL, text string) *internal.SlackEvent {
+	if channelID == "" {
+		channelID = action.Container.ChannelID
+	}
+
+	event := &internal.SlackEvent{
+		TeamID:              action.Team.ID,
+		TeamDomain:          action.Team.Domain,
+		ChannelID:           channelID,
+		UserID:              action.User.ID,
+		UserName:            action.User.Username,
+		Command:             "/docs",
+		Text:                text,
+		ResponseURL:         responseURL,
+		TriggerID:           action.TriggerID,
+		APIAppID:            action.ApiAppID,
+		IsEnterpriseInstall: action.IsEnterpriseInstall,
+	}
+	if action.Channel.ID == channelID {
+		event.ChannelName = action.Channel.Name
+	}
+	if action.Enterprise != nil {
+		event.EnterpriseID, event.EnterpriseName = action.Enterprise.ID, action.Enterprise.Name
+	}
+	return event
+}
@@ -0,0 +1,179 @@
+// Copyright (c) Spectro Cloud
+// SPDX-License-Identifier: Apache-2.0
+
+package slackActions
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"spectrocloud.com/spectromate/internal"
+	"spectrocloud.com/spectromate/mock"
+	"spectrocloud.com/spectromate/slackCmds"
+)
+
+func TestShowRestHandler(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	slack := newRecorder(t)
+	cache := mock.NewMockCache(ctrl)
+
+	state := testAnswer()
+	state.Title = "Docs Answer"
+	state.Answer = strings.Repeat(strings.Repeat("word ", 100)+"\n\n", 100)
+	index := len(internal.AnswerSections(state.Answer)) - 2
+
+	// The rest of the answer is sent privately from the section of the button.
+	cache.EXPECT().GetHashMap(gomock.Any(), "docs_bot:answer:123").Return(true, answerValues(t, state), nil)
+	feedback := newTestFeedback(cache, slack.URL, "", internal.ActionsAskShowRestID, false, internal.ChannelConfig{})
+	feedback.action.Actions[0].Value = internal.ShowRestValue("123", index)
+	require.NoError(t, ShowRestHandler(feedback))
+
+	replies := slack.requests()
+	require.Len(t, replies, 1)
+	assert.Equal(t, "ephemeral", replies[0]["response_type"])
+	blocks := replies[0]["blocks"].([]interface{})
+	assert.Len(t, blocks, 3)
+	assert.Equal(t, internal.AnswerTextBlockID(index), blocks[1].(map[string]interface{})["block_id"])
+
+	// The user is told if the answer expired.
+	cache.EXPECT().GetHashMap(gomock.Any(), "docs_bot:answer:123").Return(false, nil, nil)
+	require.NoError(t, ShowRestHandler(feedback))
+
+	replies = slack.requests()
+	require.Len(t, replies, 2)
+	assert.Contains(t, replies[1]["blocks"].([]interface{})[0].(map[string]interface{})["text"].(map[string]interface{})["text"], internal.DefaultAnswerUnavailableMessage)
+}
+
+// runner returns a command runner recording the events it runs.
+func runner(events *[]*internal.SlackEvent) CommandRunner {
+	return func(ctx context.Context, event *internal.SlackEvent) error {
+		*events = append(*events, event)
+		return nil
+	}
+}
+
+func TestRegenerateAnswerHandler(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	slack := newRecorder(t)
+	cache := mock.NewMockCache(ctrl)
+
+	state := testAnswer()
+	state.Question = ":question: what does --new do?"
+	state.Product, state.Version = "palette", "4.2"
+	state.ConversationID = "789"
+
+	// The question is asked again with the visibility of the answer, in its conversation and docs scope.
+	cache.EXPECT().GetHashMap(gomock.Any(), "docs_bot:answer:123").Return(true, answerValues(t, state), nil)
+	var events []*internal.SlackEvent
+	feedback := newTestFeedback(cache, slack.URL, "", internal.ActionsAskRegenerateID, true, internal.ChannelConfig{})
+	require.NoError(t, RegenerateAnswerHandler(feedback, runner(&events)))
+
+	require.Len(t, events, 1)
+	assert.Equal(t, "ask --private --product=palette --version=4.2 -- what does --new do?", events[0].Text)
+	assert.Equal(t, "789", events[0].ConversationID)
+	assert.Equal(t, "U456", events[0].UserID)
+	assert.Equal(t, "C123", events[0].ChannelID)
+	assert.Equal(t, slack.URL, events[0].ResponseURL)
+
+	// The user is told if the answer can't be found, and no question is asked.
+	cache.EXPECT().GetHashMap(gomock.Any(), "docs_bot:answer:123").Return(false, nil, nil)
+	require.NoError(t, RegenerateAnswerHandler(feedback, runner(&events)))
+	assert.Len(t, events, 1)
+	require.Len(t, slack.requests(), 1)
+	assert.Equal(t, internal.DefaultAnswerUnavailableMessage, blockText(slack.requests()[0]["blocks"].([]interface{})[0].(map[string]interface{})))
+}
+
+func TestFollowUpHandlers(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	slack := newRecorder(t)
+	api := newRecorder(t)
+	api.response = `{"ok": true}`
+	cache := mock.NewMockCache(ctrl)
+
+	state := testAnswer()
+	state.Product = "palette"
+	state.ConversationID = "789"
+
+	// The dialog keeps the channel, visibility, docs scope and conversation of the answer in its private metadata.
+	cache.EXPECT().GetHashMap(gomock.Any(), "docs_bot:answer:123").Return(true, answerValues(t, state), nil)
+	feedback := newTestFeedback(cache, slack.URL, "", internal.ActionsAskFollowUpID, false, internal.ChannelConfig{})
+	feedback.action.TriggerID = "trigger"
+	require.NoError(t, FollowUpHandler(feedback, internal.NewSlackAPI("xoxb-test", api.URL)))
+
+	opened := api.requests()
+	require.Len(t, opened, 1)
+	assert.Equal(t, "trigger", opened[0]["trigger_id"])
+	view := opened[0]["view"].(map[string]interface{})
+	assert.Equal(t, internal.FollowUpViewCallbackID, view["callback_id"])
+
+	// The submitted question is asked in the conversation of the answer.
+	submission := newTestFeedback(cache, "", "", "", false, internal.ChannelConfig{})
+	submission.action.Type = internal.SlackInteractionViewSubmission
+	submission.action.Actions = nil
+	submission.action.Channel = internal.Channel{}
+	submission.action.View = internal.SlackView{
+		CallbackID:      internal.FollowUpViewCallbackID,
+		PrivateMetadata: view["private_metadata"].(string),
+		State: internal.ViewState{Values: map[string]map[string]internal.ViewStateValue{
+			internal.FollowUpInputBlockID: {internal.FollowUpInputActionID: {Type: "plain_text_input", Value: " and --private? "}},
+		}},
+	}
+	var events []*internal.SlackEvent
+	require.NoError(t, FollowUpSubmissionHandler(submission, runner(&events)))
+
+	require.Len(t, events, 1)
+	assert.Equal(t, "ask --product=palette -- and --private?", events[0].Text)
+	assert.Equal(t, "789", events[0].ConversationID)
+	assert.Equal(t, "C123", events[0].ChannelID)
+	assert.Equal(t, slack.URL, events[0].ResponseURL)
+
+	// Without a bot token, the user is asked to use the slash command.
+	require.NoError(t, FollowUpHandler(feedback, nil))
+	require.Len(t, slack.requests(), 1)
+	assert.Equal(t, internal.DefaultFollowUpUnavailableMessage, blockText(slack.requests()[0]["blocks"].([]interface{})[0].(map[string]interface{})))
+}
+
+func TestShareAnswerHandler(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	slack := newRecorder(t)
+	cache := mock.NewMockCache(ctrl)
+
+	state := testAnswer()
+	state.Private = true
+	cache.EXPECT().GetHashMap(gomock.Any(), "docs_bot:answer:123").Return(true, answerValues(t, state), nil)
+	require.NoError(t, ShareAnswerHandler(newTestFeedback(cache, slack.URL, "", internal.ActionsAskShareID, true, internal.ChannelConfig{})))
+
+	// The answer is posted in the channel with the user who shared it, and can't be shared again.
+	replies := slack.requests()
+	require.Len(t, replies, 1)
+	assert.Equal(t, "in_channel", replies[0]["response_type"])
+	assert.Equal(t, "Shared by <@U456>.", blockText(blockByID(replies[0], internal.AnswerBlockIDByline)))
+	assert.NotContains(t, actionIDs(blockByID(replies[0], internal.AnswerBlockIDMoreActions)), internal.ActionsAskShareID)
+	assert.Equal(t, "123", blockByID(replies[0], internal.AnswerBlockIDActions)["elements"].([]interface{})[0].(map[string]interface{})["value"])
+}
+
+func TestAskCommandText(t *testing.T) {
+	text := askCommandText("what does --new do?", true, "palette", "4.2")
+	assert.Equal(t, "ask --private --product=palette --version=4.2 -- what does --new do?", text)
+
+	// The command runner parses the text with the flags of the ask command.
+	tokens := slackCmds.Tokenize(text)
+	cmd, ok := slackCmds.NewDefaultRegistry(nil, nil).Lookup(tokens[0])
+	require.True(t, ok)
+	options := slackCmds.NewAskOptions(slackCmds.ParseArgs(tokens[1:], cmd.Flags))
+	assert.Equal(t, slackCmds.AskOptions{Query: "what does --new do?", Private: true, Product: "palette", Version: "4.2"}, options)
+
+	assert.Equal(t, "ask -- how do I install Palette?", askCommandText("how do I install Palette?", false, "", ""))
+}
@@ -0,0 +1,111 @@
+// Copyright (c) Spectro Cloud
+// SPDX-License-Identifier: Apache-2.0
+
+package slackActions
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/rs/zerolog/log"
+	"spectrocloud.com/spectromate/internal"
+)
+
+// EscalateHandler posts the question, answer, sources, and confidence level of an answer in the support channel,
+// so the support team can follow up in the thread of the message. The user is notified in a direct message
+// when someone replies in the thread. A user escalates an answer once.
+// The returned error is the error reported to the user, if any.
+func EscalateHandler(action *SlackActionFeedback, api *internal.SlackAPI) (escalateErr error) {
+
+	var globalErr *error
+
+	// The escalation is only acknowledged to the user who escalated the question.
+	defer func() {
+		errorEval(action.ctx, globalErr, action, true)
+		if globalErr != nil {
+			escalateErr = *globalErr
+		}
+		globalErr = nil
+	}()
+
+	if api == nil || !action.escalation.Enabled() {
+		err := errors.New("escalation is not configured")
+		log.Debug().Err(err).Msg("Unable to escalate the question.")
+		reply(action, internal.DefaultEscalationUnavailableMessage)
+		return err
+	}
+
+	messageID := action.action.Actions[0].Value
+	userID := action.action.User.ID
+	supportChannel := action.escalation.Channel
+
+	// The escalation is claimed before the question is posted, so clicking twice doesn't post the question twice.
+	// Without the cache, the question is posted without the check.
+	claimed, existing, err := internal.ClaimEscalation(action.ctx, action.cache, messageID, userID, supportChannel, internal.DefaultEscalationExpirationPeriod)
+	if err != nil {
+		log.Warn().Err(err).Str("message_id", messageID).Msg("Unable to claim the escalation of the question.")
+		internal.RecordDegradation(internal.DegradationCacheWrite)
+	} else if !claimed {
+		reply(action, fmt.Sprintf(internal.DefaultAlreadyEscalatedMessage, existing))
+		return
+	}
+
+	// release removes the claim, so the user can escalate the question again.
+	release := func() {
+		if !claimed {
+			return
+		}
+		if err := internal.ReleaseEscalation(action.ctx, action.cache, messageID, userID); err != nil {
+			log.Warn().Err(err).Str("message_id", messageID).Msg("Unable to release the claim of the escalation.")
+		}
+	}
+
+	state, found := lookupAnswer(action, messageID)
+	if !found {
+		release()
+		answerUnavailable(action, messageID)
+		return
+	}
+
+	channelID := action.action.Channel.ID
+	if channelID == "" {
+		channelID = action.action.Container.ChannelID
+	}
+
+	threadTS, err := api.PostMessage(action.ctx, supportChannel, "", internal.EscalationMessage(state, userID, channelID))
+	if err != nil {
+		log.Info().Err(err).Str("support_channel", supportChannel).Msg("Error posting the escalation in the support channel.")
+		internal.LogError(err)
+		release()
+		globalErr = &err
+		return
+	}
+
+	// The question is escalated even if it can't be stored, but the replies can't be sent to the user.
+	err = internal.StoreEscalation(action.ctx, action.cache, internal.Escalation{
+		MessageID:      messageID,
+		UserID:         userID,
+		ChannelID:      channelID,
+		SupportChannel: supportChannel,
+		ThreadTS:       threadTS,
+		CreatedAt:      time.Now(),
+	}, internal.DefaultEscalationExpirationPeriod)
+	if err != nil {
+		log.Warn().Err(err).Str("message_id", messageID).Msg("Unable to store the escalation. The replies won't be sent to the user.")
+		internal.RecordDegradation(internal.DegradationCacheWrite)
+	}
+
+	log.Info().Str("message_id", messageID).Str("user_id", userID).Str("support_channel", supportChannel).Msg("Escalated the question to the support channel.")
+	reply(action, fmt.Sprintf(internal.DefaultEscalatedMessage, supportChannel))
+	return
+}
+
+// reply sends a private message to the user who triggered the action.
+func reply(action *SlackActionFeedback, message string) {
+	err := internal.ReplyWithMessage(action.ctx, action.action.ResponseURL, message, true)
+	if err != nil {
+		log.Info().Err(err).Msg("Error when attempting to return the message back to Slack.")
+		internal.LogError(err)
+	}
+}
@@ -0,0 +1,87 @@
+// Copyright (c) Spectro Cloud
+// SPDX-License-Identifier: Apache-2.0
+
+package slackActions
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"spectrocloud.com/spectromate/internal"
+	"spectrocloud.com/spectromate/mock"
+)
+
+func TestEscalateHandler(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	slack := newRecorder(t)
+	api := newRecorder(t)
+	api.response = `{"ok": true, "ts": "1700000000.000100"}`
+	cache := mock.NewMockCache(ctrl)
+
+	feedback := newTestFeedback(cache, slack.URL, "", internal.ActionsAskEscalateID, true, internal.ChannelConfig{})
+	feedback.escalation = internal.EscalationConfig{Channel: "CSUPPORT"}
+
+	// The question is posted in the support channel, and the thread is stored so replies reach the user.
+	answerKey := "docs_bot:escalation:answer:123:U456"
+	cache.EXPECT().EvalScript(gomock.Any(), gomock.Any(), []string{answerKey}, "CSUPPORT", gomock.Any()).Return([]interface{}{int64(1), "CSUPPORT"}, nil)
+	cache.EXPECT().GetHashMap(gomock.Any(), "docs_bot:answer:123").Return(true, answerValues(t, testAnswer()), nil)
+	var stored []string
+	cache.EXPECT().StoreHashMap(gomock.Any(), gomock.Any(), gomock.Any()).DoAndReturn(
+		func(_ context.Context, key string, item map[string]interface{}) error {
+			stored = append(stored, key)
+			assert.Equal(t, "1700000000.000100", item["thread_ts"])
+			return nil
+		}).Times(2)
+	cache.EXPECT().ExpireKey(gomock.Any(), gomock.Any(), internal.DefaultEscalationExpirationPeriod).Return(nil).Times(2)
+	require.NoError(t, EscalateHandler(feedback, internal.NewSlackAPI("xoxb-test", api.URL)))
+
+	posted := api.requests()
+	require.Len(t, posted, 1)
+	assert.Equal(t, "CSUPPORT", posted[0]["channel"])
+	assert.Equal(t, []string{"docs_bot:escalation:thread:CSUPPORT:1700000000.000100", "docs_bot:escalation:answer:123:U456"}, stored)
+	require.Len(t, slack.requests(), 1)
+	assert.Equal(t, fmt.Sprintf(internal.DefaultEscalatedMessage, "CSUPPORT"), blockText(slack.requests()[0]["blocks"].([]interface{})[0].(map[string]interface{})))
+
+	// Escalating the question again doesn't post it twice.
+	cache.EXPECT().EvalScript(gomock.Any(), gomock.Any(), []string{answerKey}, "CSUPPORT", gomock.Any()).Return([]interface{}{int64(0), "CSUPPORT"}, nil)
+	require.NoError(t, EscalateHandler(feedback, internal.NewSlackAPI("xoxb-test", api.URL)))
+	assert.Len(t, api.requests(), 1)
+	require.Len(t, slack.requests(), 2)
+	assert.Equal(t, fmt.Sprintf(internal.DefaultAlreadyEscalatedMessage, "CSUPPORT"), blockText(slack.requests()[1]["blocks"].([]interface{})[0].(map[string]interface{})))
+
+	// Without a support channel, the user is told the support team can't be reached.
+	feedback.escalation = internal.EscalationConfig{}
+	assert.Error(t, EscalateHandler(feedback, internal.NewSlackAPI("xoxb-test", api.URL)))
+	require.Len(t, slack.requests(), 3)
+	assert.Equal(t, internal.DefaultEscalationUnavailableMessage, blockText(slack.requests()[2]["blocks"].([]interface{})[0].(map[string]interface{})))
+}
+
+func TestEscalateHandlerPostFailure(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	slack := newRecorder(t)
+	api := newRecorder(t)
+	api.response = `{"ok": false, "error": "not_in_channel"}`
+	cache := mock.NewMockCache(ctrl)
+
+	feedback := newTestFeedback(cache, slack.URL, "", internal.ActionsAskEscalateID, true, internal.ChannelConfig{})
+	feedback.escalation = internal.EscalationConfig{Channel: "CSUPPORT"}
+
+	// The claim is released when the question can't be posted, so the user can try again.
+	answerKey := "docs_bot:escalation:answer:123:U456"
+	cache.EXPECT().EvalScript(gomock.Any(), gomock.Any(), []string{answerKey}, "CSUPPORT", gomock.Any()).Return([]interface{}{int64(1), "CSUPPORT"}, nil)
+	cache.EXPECT().GetHashMap(gomock.Any(), "docs_bot:answer:123").Return(true, answerValues(t, testAnswer()), nil)
+	cache.EXPECT().DeleteKey(gomock.Any(), answerKey).Return(nil)
+	assert.Error(t, EscalateHandler(feedback, internal.NewSlackAPI("xoxb-test", api.URL)))
+
+	// The user is told the escalation failed.
+	require.Len(t, slack.requests(), 1)
+	assert.Equal(t, internal.DefaultUserErrorMessage, blockText(slack.requests()[0]["blocks"].([]interface{})[0].(map[string]interface{})))
+}
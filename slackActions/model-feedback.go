@@ -5,26 +5,65 @@ package slackActions
 
 import (
 	"context"
-	"encoding/json"
+	"fmt"
 	"strconv"
 
 	"github.com/rs/zerolog/log"
 	"spectrocloud.com/spectromate/internal"
+	"spectrocloud.com/spectromate/internal/blockkit"
 )
 
 type SlackActionFeedback struct {
 	ctx            context.Context
 	action         *internal.SlackActionEvent
 	mendableAPIKey string
+	defaultAPIKey  string
+	backends       internal.AnswerBackends
+	ratingURL      string
 	version        string
+	cache          internal.Cache
+	escalation     internal.EscalationConfig
 }
 
-// SlackActionFeedback returns a new SlackActionFeedback.
-func NewSlackActionFeedback(ctx context.Context, action *internal.SlackActionEvent, mendableAPIKey, version string) *SlackActionFeedback {
-	return &SlackActionFeedback{ctx, action, mendableAPIKey, version}
+// ActionDependencies are the settings and services shared by the answer actions.
+type ActionDependencies struct {
+	// MendableAPIKey is the API key of the default answer backend.
+	MendableAPIKey string
+	Backends       internal.AnswerBackends
+	Version        string
+	Cache          internal.Cache
+	Escalation     internal.EscalationConfig
 }
 
-func ModelFeedbackHandler(action *SlackActionFeedback, ratingScore internal.MendableRatingScore) {
+// NewSlackActionFeedback returns a new SlackActionFeedback for the action in the channel.
+// The API key of the answer backend of the channel is used unless the answer recorded its backend.
+func NewSlackActionFeedback(ctx context.Context, action *internal.SlackActionEvent, channel internal.ChannelConfig, deps ActionDependencies) *SlackActionFeedback {
+	return &SlackActionFeedback{
+		ctx:            ctx,
+		action:         action,
+		mendableAPIKey: deps.Backends.APIKey(channel.Backend, deps.MendableAPIKey),
+		defaultAPIKey:  deps.MendableAPIKey,
+		backends:       deps.Backends,
+		ratingURL:      internal.MendableRatingFeedbackURL,
+		version:        deps.Version,
+		cache:          deps.Cache,
+		escalation:     deps.Escalation,
+	}
+}
+
+// backendAPIKey returns the API key of the backend that answered the question. The backend of the channel is used
+// if the answer didn't record it.
+func (action *SlackActionFeedback) backendAPIKey(state internal.AnswerState, found bool) string {
+	if !found || state.Backend == "" {
+		return action.mendableAPIKey
+	}
+	return action.backends.APIKey(state.Backend, action.defaultAPIKey)
+}
+
+// ModelFeedbackHandler sends the rating to the answer backend and updates the rating buttons of the answer.
+// Users can change their rating, or remove it with NoFeedbackScore.
+// The returned error is the error reported to the user, if any.
+func ModelFeedbackHandler(action *SlackActionFeedback, ratingScore internal.MendableRatingScore) (feedbackErr error) {
 
 	var (
 		globalErr *error
@@ -39,6 +78,9 @@ func ModelFeedbackHandler(action *SlackActionFeedback, ratingScore internal.Mend
 	// A pointer value is used to workaround defer's default behavior of evaulating the arguments immediately.
 	defer func() {
 		errorEval(action.ctx, globalErr, action, isPrivate)
+		if globalErr != nil {
+			feedbackErr = *globalErr
+		}
 		globalErr = nil
 	}()
 
@@ -53,27 +95,53 @@ func ModelFeedbackHandler(action *SlackActionFeedback, ratingScore internal.Mend
 		return
 	}
 
-	err = internal.SendModelRating(action.ctx, messageID, ratingScore, action.mendableAPIKey, internal.MendableRatingFeedbackURL, action.version)
-	if err != nil {
-		log.Debug().Err(err).Msg("error sending model feedback.")
-		internal.LogError(err)
-		globalErr = &err
-		return
+	// The answer is looked up first, because the rating is sent to the backend that answered the question.
+	state, found := lookupAnswer(action, messageIDRaw)
+
+	// Each user has a single rating per answer, and public answers collect the ratings of every user in the channel.
+	// The aggregated rating is sent to the backend when it changes, so rating an answer twice doesn't count twice.
+	// The rating of the user is sent instead if the ratings can't be stored.
+	previousScore, counts, storeErr := internal.SetUserRating(action.ctx, action.cache, messageIDRaw, action.action.User.ID, ratingScore, internal.DefaultAnswerStateExpirationPeriod)
+	if storeErr != nil {
+		log.Warn().Err(storeErr).Str("message_id", messageIDRaw).Msg("Unable to store the rating of the user. Sending the rating without checking for duplicates.")
+		internal.RecordDegradation(internal.DegradationCacheWrite)
 	}
 
+	backendScore := counts.Score()
+	if storeErr != nil || backendScore != counts.Replace(ratingScore, previousScore).Score() {
+		if storeErr != nil {
+			backendScore = ratingScore
+		}
+		err = internal.SendModelRating(action.ctx, messageID, backendScore, action.backendAPIKey(state, found), action.ratingURL, action.version)
+		if err != nil {
+			log.Debug().Err(err).Msg("error sending model feedback.")
+			internal.LogError(err)
+			globalErr = &err
+			// Restore the previous rating so the user can try again.
+			if storeErr == nil {
+				_, _, restoreErr := internal.SetUserRating(action.ctx, action.cache, messageIDRaw, action.action.User.ID, previousScore, internal.DefaultAnswerStateExpirationPeriod)
+				if restoreErr != nil {
+					log.Warn().Err(restoreErr).Str("message_id", messageIDRaw).Msg("Unable to restore the previous rating of the user.")
+				}
+			}
+			return
+		}
+	}
+
+	responseMessage := ratingMessage(previousScore, ratingScore)
+
 	log.Debug().Interface("message", action)
-	// prevent panic if the message is missing any of the expected blocks
-	if len(action.action.Message.Blocks) < 7 {
-		log.Debug().Interface("message", action.action.Message).Msg("message is missing blocks.")
+	if !found {
+		log.Debug().Interface("message", action.action.Message).Msg("unable to find the answer of the message.")
 
-		slackReplyPayload, err := replyWithEmptyMessage(isPrivate, ratingScore)
+		slackReplyPayload, err := replyWithEmptyMessage(isPrivate, responseMessage)
 		if err != nil {
 			log.Info().Err(err).Msg("Error creating the rating markdown payload.")
 			globalErr = &err
 			return
 		}
 
-		err = internal.ReplyWithAnswer(action.action.ResponseURL, slackReplyPayload, isPrivate)
+		err = internal.ReplyWithAnswer(action.ctx, action.action.ResponseURL, slackReplyPayload, isPrivate)
 		if err != nil {
 			log.Info().Err(err).Msg("Error when attempting to return the answer back to Slack.")
 			internal.LogError(err)
@@ -84,28 +152,29 @@ func ModelFeedbackHandler(action *SlackActionFeedback, ratingScore internal.Mend
 		return
 	}
 
-	var originalAnswer, orginalQuestion, orignalSourceLinks string
-
-	if len(action.action.Message.Blocks) > 4 && action.action.Message.Blocks[4].Text.Text != "" {
-		originalAnswer = action.action.Message.Blocks[4].Text.Text
-	}
-
-	if len(action.action.Message.Blocks) > 2 && action.action.Message.Blocks[2].Text.Text != "" {
-		orginalQuestion = action.action.Message.Blocks[2].Text.Text
+	// A private answer shared in the channel has the message ID of the private answer.
+	state.Private = isPrivate
+	// Unanswered, low confidence, and negatively rated questions can be escalated to the support team.
+	state.Escalate = action.escalation.Enabled() && (state.Uncertain() || counts.Negative > 0 || ratingScore == internal.NegativeFeedbackScore)
+	if state.Escalate && ratingScore == internal.NegativeFeedbackScore {
+		responseMessage += " " + internal.DefaultEscalationHintMessage
 	}
 
-	if len(action.action.Message.Blocks) > 6 && action.action.Message.Blocks[6].Text.Text != "" {
-		orignalSourceLinks = action.action.Message.Blocks[6].Text.Text
+	// Public answers are shared by everyone in the channel, so they show the rating counts instead of the rating
+	// of the user, and the response to the rating is sent privately to the user.
+	view := ratingView{Rating: ratingScore, Message: responseMessage}
+	if !isPrivate {
+		view = ratingView{Counts: counts}
 	}
 
-	slackReplyPayload, err := rateFeedbackMarkdownPayload(originalAnswer, orginalQuestion, orignalSourceLinks, isPrivate, ratingScore)
+	slackReplyPayload, err := rateFeedbackMarkdownPayload(state, internal.AnswerBylineFromBlocks(action.action.Message.Blocks), view)
 	if err != nil {
 		log.Info().Err(err).Msg("Error creating the rating markdown payload.")
 		globalErr = &err
 		return
 	}
 
-	err = internal.ReplyWithAnswer(action.action.ResponseURL, slackReplyPayload, isPrivate)
+	err = internal.ReplyWithAnswer(action.ctx, action.action.ResponseURL, slackReplyPayload, isPrivate)
 	if err != nil {
 		log.Info().Err(err).Msg("Error when attempting to return the answer back to Slack.")
 		internal.LogError(err)
@@ -114,56 +183,27 @@ func ModelFeedbackHandler(action *SlackActionFeedback, ratingScore internal.Mend
 		return
 	}
 
+	if !isPrivate {
+		err = internal.ReplyWithMessage(action.ctx, action.action.ResponseURL, responseMessage, true)
+		if err != nil {
+			log.Info().Err(err).Msg("Error when attempting to return the rating response back to Slack.")
+			internal.LogError(err)
+		}
+	}
+
 	log.Debug().Msg("Successfully sent the answer back to Slack.")
 
+	return
 }
 
-func replyWithEmptyMessage(isPrivate bool, rating internal.MendableRatingScore) ([]byte, error) {
-	var (
-		responseType    string
-		responseMessage string
+func replyWithEmptyMessage(isPrivate bool, responseMessage string) ([]byte, error) {
+	payload := blockkit.NewMessage(isPrivate,
+		blockkit.Header("Docs Answer"),
+		blockkit.Divider(),
+		blockkit.Section(blockkit.Markdown(responseMessage)),
 	)
 
-	if isPrivate {
-		responseType = "ephemeral"
-	} else {
-		responseType = "in_channel"
-	}
-
-	if rating == internal.PositiveFeedbackScore {
-		responseMessage = internal.DefaultPositiveRatingMessage
-	}
-
-	if rating == internal.NegativeFeedbackScore {
-		responseMessage = internal.DefaultNegativeRatingMessage
-	}
-
-	payload := internal.SlackPayload{
-		ResponseType:    responseType,
-		DeleteOriginal:  false,
-		ReplaceOriginal: false,
-		Blocks: []internal.SlackBlock{
-			{
-				Type: "header",
-				Text: &internal.SlackTextObject{
-					Type: "plain_text",
-					Text: "Docs Answer",
-				},
-			},
-			{
-				Type: "divider",
-			},
-			{
-				Type: "section",
-				Text: &internal.SlackTextObject{
-					Type: "mrkdwn",
-					Text: responseMessage,
-				},
-			},
-		},
-	}
-
-	payloadBytes, err := json.Marshal(payload)
+	payloadBytes, err := payload.JSON()
 	if err != nil {
 		return []byte{}, err
 	}
@@ -172,89 +212,59 @@ func replyWithEmptyMessage(isPrivate bool, rating internal.MendableRatingScore)
 	return payloadBytes, nil
 }
 
-func rateFeedbackMarkdownPayload(content, question, links string, isPrivate bool, rating internal.MendableRatingScore) ([]byte, error) {
-	log.Debug().Msgf("Incoming Message: %v", content)
+// ratingView is the rating displayed on a rated answer.
+// Private answers show the rating of the user and the response to it, public answers show the rating counts.
+type ratingView struct {
+	Rating  internal.MendableRatingScore
+	Counts  internal.RatingCounts
+	Message string
+}
+
+// rateFeedbackMarkdownPayload returns the rated answer. It replaces the original answer.
+// The rating buttons and answer actions are kept so users can change or remove their rating.
+// The byline of the answer is kept if it has one.
+func rateFeedbackMarkdownPayload(state internal.AnswerState, byline string, view ratingView) ([]byte, error) {
+	log.Debug().Msgf("Incoming Message: %v", state.Answer)
 
-	var (
-		responseType    string
-		responseMessage string
+	payload := blockkit.NewMessage(state.Private,
+		blockkit.Header(internal.TruncateText(state.Title, blockkit.MaxHeaderTextLength)).WithBlockID(internal.AnswerBlockIDHeader),
+		blockkit.Divider(),
+		blockkit.Section(blockkit.Markdown(internal.TruncateText(state.Question, blockkit.MaxSectionTextLength))).WithBlockID(internal.AnswerBlockIDQuestion),
 	)
-	if isPrivate {
-		responseType = "ephemeral"
-	} else {
-		responseType = "in_channel"
+	if byline != "" {
+		payload.Add(internal.AnswerByline(byline))
 	}
-
-	if rating == internal.PositiveFeedbackScore {
-		responseMessage = internal.DefaultPositiveRatingMessage
+	if state.LowConfidence != "" {
+		payload.Add(internal.LowConfidenceNotice(state))
 	}
 
-	if rating == internal.NegativeFeedbackScore {
-		responseMessage = internal.DefaultNegativeRatingMessage
+	// The answer is split into sections again, because it can be longer than a single section.
+	// Withheld answers only keep their sources.
+	if state.LowConfidence != internal.LowConfidenceWithhold {
+		payload.Add(blockkit.Divider())
+		payload.Add(internal.AnswerSections(state.Answer)...)
 	}
 
-	payload := internal.SlackPayload{
-		ResponseType: responseType,
-		Blocks: []internal.SlackBlock{
-			{
-				Type: "header",
-				Text: &internal.SlackTextObject{
-					Type: "plain_text",
-					Text: "Docs Answer",
-				},
-			},
-			{
-				Type: "divider",
-			},
-			{
-				Type: "section",
-				Text: &internal.SlackTextObject{
-					Type: "mrkdwn",
-					Text: question,
-				},
-			},
-			{
-				Type: "divider",
-			},
-			{
-				Type: "section",
-				Text: &internal.SlackTextObject{
-					Type: "mrkdwn",
-					Text: content,
-				},
-			},
-			{
-				Type: "divider",
-			},
-			{
-				Type: "section",
-				Text: &internal.SlackTextObject{
-					Type: "mrkdwn",
-					Text: links,
-				},
-			},
-			{
-				Type: "divider",
-			},
-			{
-				Type: "section",
-				Fields: []internal.SlackTextObject{
-					{
-						Type: "mrkdwn",
-						Text: "*Rate Answer:*",
-					},
-				},
-			}, {
-				Type: "section",
-				Text: &internal.SlackTextObject{
-					Type: "mrkdwn",
-					Text: responseMessage,
-				},
-			},
-		},
+	payload.Add(
+		blockkit.Divider(),
+		blockkit.Section(blockkit.Markdown(internal.TruncateText(state.Links, blockkit.MaxSectionTextLength))).WithBlockID(internal.AnswerBlockIDSources),
+		blockkit.Divider(),
+		blockkit.Fields(blockkit.Markdown(ratingLabel(view.Rating))).WithBlockID(internal.AnswerBlockIDRating),
+	)
+	payload.WithReplaceOriginal()
+
+	if view.Counts.Total() > 0 {
+		payload.Add(blockkit.Context(blockkit.Markdown(view.Counts.String())).WithBlockID(internal.AnswerBlockIDRatingCounts))
 	}
+	if view.Message != "" {
+		payload.Add(blockkit.Section(blockkit.Markdown(view.Message)).WithBlockID(internal.AnswerBlockIDNote))
+	}
+	payload.Add(
+		ratingActions(state.MessageID, view.Rating, view.Rating != internal.NoFeedbackScore || view.Counts.Total() > 0),
+		internal.AnswerMoreActions(state),
+	)
 
-	payloadBytes, err := json.Marshal(payload)
+	payloadBytes, err := payload.JSON()
 	if err != nil {
 		return []byte{}, err
 	}
@@ -263,9 +273,58 @@ func rateFeedbackMarkdownPayload(content, question, links string, isPrivate bool
 	return payloadBytes, nil
 }
 
+// ratingMessage returns the response to a rating, given the previous rating of the user.
+func ratingMessage(previous, current internal.MendableRatingScore) string {
+	switch {
+	case current == internal.NoFeedbackScore:
+		return internal.DefaultRemovedRatingMessage
+	case previous == current:
+		return fmt.Sprintf(internal.DefaultUnchangedRatingMessage, internal.RatingEmoji(current))
+	case previous != internal.NoFeedbackScore:
+		return fmt.Sprintf(internal.DefaultChangedRatingMessage, internal.RatingEmoji(current))
+	case current == internal.PositiveFeedbackScore:
+		return internal.DefaultPositiveRatingMessage
+	default:
+		return internal.DefaultNegativeRatingMessage
+	}
+}
+
+// ratingLabel returns the label of the rating buttons with the current rating, if any.
+func ratingLabel(rating internal.MendableRatingScore) string {
+	if rating == internal.NoFeedbackScore {
+		return "*Rate Answer:*"
+	}
+	return "*Your Rating:* " + internal.RatingEmoji(rating)
+}
+
+// ratingActions returns the rating buttons. The button of the current rating is highlighted, if any.
+// The button removing the rating of the user is added once the answer is rated.
+func ratingActions(messageID string, rating internal.MendableRatingScore, removable bool) blockkit.Block {
+	positive := blockkit.Button(":thumbsup:", internal.ActionsAskModelPositiveFeedbackID, messageID)
+	negative := blockkit.Button(":thumbsdown:", internal.ActionsAskModelNegativeFeedbackID, messageID)
+
+	switch rating {
+	case internal.PositiveFeedbackScore:
+		positive.WithStyle(blockkit.StylePrimary)
+	case internal.NegativeFeedbackScore:
+		negative.WithStyle(blockkit.StyleDanger)
+	default:
+		positive.WithStyle(blockkit.StylePrimary)
+		negative.WithStyle(blockkit.StyleDanger)
+	}
+
+	actions := blockkit.Actions(positive, negative).WithBlockID(internal.AnswerBlockIDActions)
+	if removable {
+		actions.Elements = append(actions.Elements, blockkit.Button("Remove my rating", internal.ActionsAskModelRemoveFeedbackID, messageID))
+	}
+	return actions
+}
+
 func errorEval(ctx context.Context, e *error, a *SlackActionFeedback, isPrivate bool) {
 	if e != nil {
-		err := internal.ReplyWithErrorMessage(a.action.ResponseURL, isPrivate)
+		internal.LogErrorFields(log.Warn(), *e).Msg("The model feedback action failed.")
+
+		err := internal.ReplyWithMessage(ctx, a.action.ResponseURL, internal.UserErrorMessage(*e), isPrivate)
 		if err != nil {
 			log.Error().Err(err).Msg("Error when attempting to return the model rating feedback answer back to Slack.")
 			internal.LogError(err)
@@ -0,0 +1,306 @@
+// Copyright (c) Spectro Cloud
+// SPDX-License-Identifier: Apache-2.0
+
+package slackActions
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"spectrocloud.com/spectromate/internal"
+	"spectrocloud.com/spectromate/mock"
+)
+
+// recorder is a test server recording the JSON bodies of the requests it receives.
+type recorder struct {
+	*httptest.Server
+	mu       sync.Mutex
+	bodies   []map[string]interface{}
+	status   int
+	response string
+}
+
+func newRecorder(t *testing.T) *recorder {
+	t.Helper()
+	r := &recorder{status: http.StatusOK, response: `{}`}
+	r.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		raw, _ := io.ReadAll(req.Body)
+		body := map[string]interface{}{}
+		assert.NoError(t, json.Unmarshal(raw, &body))
+		r.mu.Lock()
+		r.bodies = append(r.bodies, body)
+		status, response := r.status, r.response
+		r.mu.Unlock()
+		w.WriteHeader(status)
+		_, _ = w.Write([]byte(response))
+	}))
+	t.Cleanup(r.Close)
+	return r
+}
+
+// requests returns the JSON bodies received by the server.
+func (r *recorder) requests() []map[string]interface{} {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]map[string]interface{}{}, r.bodies...)
+}
+
+// testAnswer is the state of the answer rated in the tests.
+func testAnswer() internal.AnswerState {
+	return internal.AnswerState{
+		MessageID: "123",
+		Title:     "Docs Answer",
+		Question:  ":question: How do I install Palette?",
+		Answer:    "Use the *Palette CLI*.",
+		Links:     "<https://docs.spectrocloud.com/cli|CLI>",
+		UserID:    "U123",
+		ChannelID: "C123",
+		Backend:   internal.DefaultAnswerBackend,
+	}
+}
+
+// answerValues returns the cached values of the answer state.
+func answerValues(t *testing.T, state internal.AnswerState) map[string]string {
+	t.Helper()
+	ctrl := gomock.NewController(t)
+	cache := mock.NewMockCache(ctrl)
+
+	values := map[string]string{}
+	cache.EXPECT().StoreHashMap(gomock.Any(), gomock.Any(), gomock.Any()).DoAndReturn(
+		func(_ context.Context, _ string, item map[string]interface{}) error {
+			for k, v := range item {
+				values[k] = v.(string)
+			}
+			return nil
+		})
+	cache.EXPECT().ExpireKey(gomock.Any(), gomock.Any(), gomock.Any()).Return(nil)
+	require.NoError(t, internal.StoreAnswerState(context.Background(), cache, state, internal.DefaultAnswerStateExpirationPeriod))
+	return values
+}
+
+// newTestFeedback returns the action clicking the button with the action ID on the answer with the message ID 123.
+func newTestFeedback(cache internal.Cache, responseURL, ratingURL, actionID string, private bool, channel internal.ChannelConfig) *SlackActionFeedback {
+	action := &internal.SlackActionEvent{
+		Type:        "block_actions",
+		User:        internal.SlackUser{ID: "U456"},
+		Container:   internal.Container{IsEphemeral: private},
+		Team:        internal.Team{ID: "T123"},
+		Channel:     internal.Channel{ID: "C123"},
+		ResponseURL: responseURL,
+		Actions:     []internal.Action{{ActionID: actionID, Value: "123"}},
+	}
+	feedback := NewSlackActionFeedback(context.Background(), action, channel, ActionDependencies{
+		MendableAPIKey: "default-key",
+		Backends:       internal.AnswerBackends{"edge": "edge-key"},
+		Version:        "1.0.0",
+		Cache:          cache,
+	})
+	feedback.ratingURL = ratingURL
+	return feedback
+}
+
+// expectRating expects the rating of the user to be stored, and returns the previous rating and the counts.
+func expectRating(cache *mock.MockCache, score internal.MendableRatingScore, previous string, positive, negative int64) *gomock.Call {
+	return cache.EXPECT().EvalScript(gomock.Any(), gomock.Any(), []string{"docs_bot:rating:123"}, "U456", int(score), gomock.Any()).
+		Return([]interface{}{previous, positive, negative}, nil)
+}
+
+func TestModelFeedbackHandlerBackend(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	slack := newRecorder(t)
+	mendable := newRecorder(t)
+	cache := mock.NewMockCache(ctrl)
+
+	// The rating is sent to the backend that answered the question, even if the channel uses another backend.
+	state := testAnswer()
+	state.Backend = "edge"
+	cache.EXPECT().GetHashMap(gomock.Any(), "docs_bot:answer:123").Return(true, answerValues(t, state), nil)
+	expectRating(cache, internal.PositiveFeedbackScore, "", 1, 0)
+	feedback := newTestFeedback(cache, slack.URL, mendable.URL, internal.ActionsAskModelPositiveFeedbackID, true, internal.ChannelConfig{})
+	require.NoError(t, ModelFeedbackHandler(feedback, internal.PositiveFeedbackScore))
+
+	// The backend of the channel is used if the answer didn't record its backend.
+	cache.EXPECT().GetHashMap(gomock.Any(), "docs_bot:answer:123").Return(false, nil, nil)
+	expectRating(cache, internal.PositiveFeedbackScore, "", 1, 0)
+	feedback = newTestFeedback(cache, slack.URL, mendable.URL, internal.ActionsAskModelPositiveFeedbackID, true, internal.ChannelConfig{Backend: "edge"})
+	require.NoError(t, ModelFeedbackHandler(feedback, internal.PositiveFeedbackScore))
+
+	// Answers of the default backend are rated with the default key.
+	cache.EXPECT().GetHashMap(gomock.Any(), "docs_bot:answer:123").Return(true, answerValues(t, testAnswer()), nil)
+	expectRating(cache, internal.PositiveFeedbackScore, "", 1, 0)
+	feedback = newTestFeedback(cache, slack.URL, mendable.URL, internal.ActionsAskModelPositiveFeedbackID, true, internal.ChannelConfig{Backend: "edge"})
+	require.NoError(t, ModelFeedbackHandler(feedback, internal.PositiveFeedbackScore))
+
+	ratings := mendable.requests()
+	require.Len(t, ratings, 3)
+	assert.Equal(t, "edge-key", ratings[0]["api_key"])
+	assert.Equal(t, "edge-key", ratings[1]["api_key"])
+	assert.Equal(t, "default-key", ratings[2]["api_key"])
+	assert.Equal(t, float64(123), ratings[0]["message_id"])
+	assert.Equal(t, float64(1), ratings[0]["rating_value"])
+	assert.Len(t, slack.requests(), 3)
+}
+
+// blockByID returns the block of the reply with the block ID, or nil if the reply has none.
+func blockByID(reply map[string]interface{}, id string) map[string]interface{} {
+	blocks, _ := reply["blocks"].([]interface{})
+	for _, raw := range blocks {
+		if block, ok := raw.(map[string]interface{}); ok && block["block_id"] == id {
+			return block
+		}
+	}
+	return nil
+}
+
+// blockText returns the text of the section block, or of the first field or element of the block.
+func blockText(block map[string]interface{}) string {
+	if text, ok := block["text"].(map[string]interface{}); ok {
+		return text["text"].(string)
+	}
+	for _, key := range []string{"fields", "elements"} {
+		if list, ok := block[key].([]interface{}); ok && len(list) > 0 {
+			return list[0].(map[string]interface{})["text"].(string)
+		}
+	}
+	return ""
+}
+
+// actionIDs returns the action IDs of the buttons of the block.
+func actionIDs(block map[string]interface{}) []string {
+	var ids []string
+	elements, _ := block["elements"].([]interface{})
+	for _, raw := range elements {
+		ids = append(ids, raw.(map[string]interface{})["action_id"].(string))
+	}
+	return ids
+}
+
+func TestModelFeedbackHandlerChangeRating(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	slack := newRecorder(t)
+	mendable := newRecorder(t)
+	cache := mock.NewMockCache(ctrl)
+	values := answerValues(t, testAnswer())
+	cache.EXPECT().GetHashMap(gomock.Any(), "docs_bot:answer:123").Return(true, values, nil).AnyTimes()
+
+	rate := func(score internal.MendableRatingScore, previous string, positive, negative int64) map[string]interface{} {
+		t.Helper()
+		expectRating(cache, score, previous, positive, negative)
+		require.NoError(t, ModelFeedbackHandler(newTestFeedback(cache, slack.URL, mendable.URL, internal.ActionsAskModelPositiveFeedbackID, true, internal.ChannelConfig{}), score))
+		replies := slack.requests()
+		return replies[len(replies)-1]
+	}
+
+	// The first rating is sent to the backend, and the rating buttons stay on the answer.
+	reply := rate(internal.PositiveFeedbackScore, "", 1, 0)
+	assert.Equal(t, true, reply["replace_original"])
+	assert.Equal(t, "*Your Rating:* :thumbsup:", blockText(blockByID(reply, internal.AnswerBlockIDRating)))
+	assert.Equal(t, internal.DefaultPositiveRatingMessage, blockText(blockByID(reply, internal.AnswerBlockIDNote)))
+	assert.Equal(t, []string{internal.ActionsAskModelPositiveFeedbackID, internal.ActionsAskModelNegativeFeedbackID, internal.ActionsAskModelRemoveFeedbackID},
+		actionIDs(blockByID(reply, internal.AnswerBlockIDActions)))
+
+	// Changing the rating sends the new rating.
+	reply = rate(internal.NegativeFeedbackScore, "1", 0, 1)
+	assert.Equal(t, "*Your Rating:* :thumbsdown:", blockText(blockByID(reply, internal.AnswerBlockIDRating)))
+	assert.Equal(t, fmt.Sprintf(internal.DefaultChangedRatingMessage, ":thumbsdown:"), blockText(blockByID(reply, internal.AnswerBlockIDNote)))
+
+	// Rating the answer again with the same rating doesn't send it twice.
+	reply = rate(internal.NegativeFeedbackScore, "-1", 0, 1)
+	assert.Equal(t, fmt.Sprintf(internal.DefaultUnchangedRatingMessage, ":thumbsdown:"), blockText(blockByID(reply, internal.AnswerBlockIDNote)))
+
+	// Removing the rating sends the absence of rating, and removes the button removing the rating.
+	reply = rate(internal.NoFeedbackScore, "-1", 0, 0)
+	assert.Equal(t, "*Rate Answer:*", blockText(blockByID(reply, internal.AnswerBlockIDRating)))
+	assert.Equal(t, internal.DefaultRemovedRatingMessage, blockText(blockByID(reply, internal.AnswerBlockIDNote)))
+	assert.Equal(t, []string{internal.ActionsAskModelPositiveFeedbackID, internal.ActionsAskModelNegativeFeedbackID},
+		actionIDs(blockByID(reply, internal.AnswerBlockIDActions)))
+
+	var ratings []float64
+	for _, rating := range mendable.requests() {
+		ratings = append(ratings, rating["rating_value"].(float64))
+	}
+	assert.Equal(t, []float64{1, -1, 0}, ratings)
+}
+
+func TestModelFeedbackHandlerRollback(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	slack := newRecorder(t)
+	mendable := newRecorder(t)
+	mendable.status = http.StatusUnauthorized
+	cache := mock.NewMockCache(ctrl)
+
+	// The previous rating of the user is restored if the backend rejects the new rating, so the user can try again.
+	cache.EXPECT().GetHashMap(gomock.Any(), "docs_bot:answer:123").Return(true, answerValues(t, testAnswer()), nil)
+	gomock.InOrder(
+		expectRating(cache, internal.NegativeFeedbackScore, "1", 0, 1),
+		expectRating(cache, internal.PositiveFeedbackScore, "-1", 1, 0),
+	)
+	feedback := newTestFeedback(cache, slack.URL, mendable.URL, internal.ActionsAskModelNegativeFeedbackID, true, internal.ChannelConfig{})
+	err := ModelFeedbackHandler(feedback, internal.NegativeFeedbackScore)
+	assert.ErrorIs(t, err, internal.ErrMendableUnauthorized)
+
+	// Only the error is sent to the user, the answer isn't changed.
+	replies := slack.requests()
+	require.Len(t, replies, 1)
+	assert.Nil(t, replies[0]["replace_original"])
+	assert.Equal(t, internal.DefaultMendableUnauthorizedMessage, blockText(replies[0]["blocks"].([]interface{})[0].(map[string]interface{})))
+}
+
+func TestModelFeedbackHandlerViews(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	slack := newRecorder(t)
+	mendable := newRecorder(t)
+	cache := mock.NewMockCache(ctrl)
+	cache.EXPECT().GetHashMap(gomock.Any(), "docs_bot:answer:123").Return(true, answerValues(t, testAnswer()), nil).AnyTimes()
+
+	// Public answers show the rating counts of the channel, and the response is only sent to the user.
+	expectRating(cache, internal.PositiveFeedbackScore, "", 2, 1)
+	require.NoError(t, ModelFeedbackHandler(newTestFeedback(cache, slack.URL, mendable.URL, internal.ActionsAskModelPositiveFeedbackID, false, internal.ChannelConfig{}), internal.PositiveFeedbackScore))
+
+	replies := slack.requests()
+	require.Len(t, replies, 2)
+	answer, response := replies[0], replies[1]
+	assert.Equal(t, "in_channel", answer["response_type"])
+	assert.Equal(t, "*Rate Answer:*", blockText(blockByID(answer, internal.AnswerBlockIDRating)))
+	assert.Equal(t, ":thumbsup: 2 / :thumbsdown: 1", blockText(blockByID(answer, internal.AnswerBlockIDRatingCounts)))
+	assert.Nil(t, blockByID(answer, internal.AnswerBlockIDNote), "Expected the response to the rating to be private")
+	assert.Contains(t, actionIDs(blockByID(answer, internal.AnswerBlockIDActions)), internal.ActionsAskModelRemoveFeedbackID)
+	assert.Equal(t, "ephemeral", response["response_type"])
+	assert.Equal(t, internal.DefaultPositiveRatingMessage, blockText(response["blocks"].([]interface{})[0].(map[string]interface{})))
+
+	// Private answers show the rating of the user and the response to it.
+	expectRating(cache, internal.PositiveFeedbackScore, "", 1, 0)
+	require.NoError(t, ModelFeedbackHandler(newTestFeedback(cache, slack.URL, mendable.URL, internal.ActionsAskModelPositiveFeedbackID, true, internal.ChannelConfig{}), internal.PositiveFeedbackScore))
+
+	replies = slack.requests()
+	require.Len(t, replies, 3)
+	answer = replies[2]
+	assert.Equal(t, "ephemeral", answer["response_type"])
+	assert.Equal(t, "*Your Rating:* :thumbsup:", blockText(blockByID(answer, internal.AnswerBlockIDRating)))
+	assert.Nil(t, blockByID(answer, internal.AnswerBlockIDRatingCounts))
+	assert.Equal(t, internal.DefaultPositiveRatingMessage, blockText(blockByID(answer, internal.AnswerBlockIDNote)))
+
+	// Public answers aggregate the ratings, so the backend receives the rating of the channel.
+	var ratings []float64
+	for _, rating := range mendable.requests() {
+		ratings = append(ratings, rating["rating_value"].(float64))
+	}
+	assert.Equal(t, []float64{1, 1}, ratings)
+}
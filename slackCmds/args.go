@@ -0,0 +1,223 @@
+// Copyright (c) Spectro Cloud
+// SPDX-License-Identifier: Apache-2.0
+
+package slackCmds
+
+import (
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// Flag is an option a command accepts, such as `--private` or `--product=palette`.
+type Flag struct {
+	// Name is the flag name without the leading dashes.
+	Name string
+	// HasValue flags expect a value in the format --name=value. Other flags are booleans.
+	HasValue bool
+	// Description is displayed in the help message.
+	Description string
+}
+
+// CommandArgs are the arguments typed after a command.
+type CommandArgs struct {
+	// Positional contains the arguments that are not flags, in the order they were typed.
+	// Quoted arguments keep their quotes.
+	Positional []string
+	// Flags contains the value of each flag set by the user. Boolean flags have the value "true".
+	Flags map[string]string
+}
+
+// Text returns the positional arguments joined by a single space.
+func (a CommandArgs) Text() string {
+	return strings.Join(a.Positional, " ")
+}
+
+// Bool returns true if the boolean flag is set.
+func (a CommandArgs) Bool(name string) bool {
+	value, ok := a.Flags[name]
+	if !ok {
+		return false
+	}
+	b, err := strconv.ParseBool(value)
+	return err == nil && b
+}
+
+// Value returns the value of the flag, or an empty string if the flag is not set.
+func (a CommandArgs) Value(name string) string {
+	return a.Flags[name]
+}
+
+// Tokenize splits the text of a slash command into tokens.
+// Tokens are separated by any whitespace, including tabs and new lines.
+// Text within double, single or smart quotes is kept in a single token. The quotes are kept
+// in the token and smart quotes are converted to straight quotes. Single quotes only start a
+// quoted string at the beginning of a token, so apostrophes such as in "what's" are not quotes.
+// An unterminated quote continues until the end of the text.
+func Tokenize(text string) []string {
+	var (
+		tokens  []string
+		current strings.Builder
+		inToken bool
+		quote   rune
+	)
+
+	flush := func() {
+		if inToken {
+			tokens = append(tokens, current.String())
+		}
+		current.Reset()
+		inToken = false
+	}
+
+	for _, r := range text {
+		switch {
+		case quote != 0:
+			if closesQuote(quote, r) {
+				current.WriteRune(quote)
+				quote = 0
+				continue
+			}
+			current.WriteRune(r)
+		case unicode.IsSpace(r):
+			flush()
+		case opensQuote(r) == '"' || (opensQuote(r) == '\'' && !inToken):
+			// Single quotes only open a quote at the start of a token so apostrophes are left alone.
+			quote = opensQuote(r)
+			inToken = true
+			current.WriteRune(quote)
+		default:
+			inToken = true
+			current.WriteRune(r)
+		}
+	}
+
+	flush()
+
+	return tokens
+}
+
+// opensQuote returns the straight quote matching the opening quote, or 0 if the rune is not a quote.
+func opensQuote(r rune) rune {
+	switch r {
+	case '"', '“', '”':
+		return '"'
+	case '\'', '‘':
+		return '\''
+	default:
+		return 0
+	}
+}
+
+// closesQuote returns true if the rune closes the quote.
+// The right single quote is not accepted as an opening quote because Slack uses it for apostrophes.
+func closesQuote(quote, r rune) bool {
+	switch quote {
+	case '"':
+		return r == '"' || r == '“' || r == '”'
+	case '\'':
+		return r == '\'' || r == '‘' || r == '’'
+	default:
+		return false
+	}
+}
+
+// unquote removes the quotes surrounding a token.
+func unquote(token string) string {
+	if len(token) >= 2 && (token[0] == '"' || token[0] == '\'') && token[len(token)-1] == token[0] {
+		return token[1 : len(token)-1]
+	}
+	return token
+}
+
+// ParseArgs separates the flags accepted by a command from the positional arguments.
+// Unknown flags, and flags expecting a value typed without one, are kept as positional arguments
+// so they remain part of the question. Flags are recognized anywhere in the arguments until a `--`
+// or `—` argument, after which everything is positional.
+func ParseArgs(tokens []string, flags []Flag) CommandArgs {
+	args := CommandArgs{Flags: map[string]string{}}
+
+	known := make(map[string]Flag, len(flags))
+	for _, f := range flags {
+		known[f.Name] = f
+	}
+
+	for i, token := range tokens {
+		if token == "--" || token == "—" {
+			args.Positional = append(args.Positional, tokens[i+1:]...)
+			break
+		}
+
+		name, value, isFlag := parseFlag(token)
+		flag, ok := known[name]
+		if !isFlag || !ok || (flag.HasValue && value == "") {
+			args.Positional = append(args.Positional, token)
+			continue
+		}
+
+		switch {
+		case flag.HasValue:
+			args.Flags[flag.Name] = unquote(value)
+		case value == "":
+			args.Flags[flag.Name] = "true"
+		default:
+			args.Flags[flag.Name] = value
+		}
+	}
+
+	return args
+}
+
+// parseFlag splits a token in the format --name or --name=value.
+// Long dashes are accepted because Slack clients may replace -- with an em dash.
+func parseFlag(token string) (string, string, bool) {
+	var trimmed string
+	switch {
+	case strings.HasPrefix(token, "--"):
+		trimmed = strings.TrimPrefix(token, "--")
+	case strings.HasPrefix(token, "—"):
+		trimmed = strings.TrimPrefix(token, "—")
+	default:
+		return "", "", false
+	}
+
+	if trimmed == "" {
+		return "", "", false
+	}
+
+	name, value, _ := strings.Cut(trimmed, "=")
+	return strings.ToLower(name), value, true
+}
+
+// AskOptions are the options of the ask commands.
+type AskOptions struct {
+	// Query is the question asked by the user.
+	Query string
+	// Private replies are only visible to the user.
+	Private bool
+	// Product limits the answer to the documentation of a product.
+	Product string
+	// Version limits the answer to the documentation of a product version.
+	Version string
+	// NewConversation starts a new conversation instead of continuing the existing one.
+	NewConversation bool
+}
+
+// askFlags are the flags accepted by the ask commands.
+var askFlags = []Flag{
+	{Name: "private", Description: "Reply privately."},
+	{Name: "new", Description: "Start a new conversation."},
+	{Name: "product", HasValue: true, Description: "Limit the answer to a product."},
+	{Name: "version", HasValue: true, Description: "Limit the answer to a product version."},
+}
+
+// NewAskOptions returns the ask options from the command arguments.
+func NewAskOptions(args CommandArgs) AskOptions {
+	return AskOptions{
+		Query:           args.Text(),
+		Private:         args.Bool("private"),
+		Product:         strings.TrimSpace(args.Value("product")),
+		Version:         strings.TrimSpace(args.Value("version")),
+		NewConversation: args.Bool("new"),
+	}
+}
@@ -0,0 +1,111 @@
+// Copyright (c) Spectro Cloud
+// SPDX-License-Identifier: Apache-2.0
+
+package slackCmds
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTokenize(t *testing.T) {
+	tests := []struct {
+		name     string
+		text     string
+		expected []string
+	}{
+		{"empty", "", nil},
+		{"only whitespace", " \t\r\n ", nil},
+		{"single spaces", "ask how do I", []string{"ask", "how", "do", "I"}},
+		{"multiple whitespace", "ask \t how\n\ndo  I\r\n", []string{"ask", "how", "do", "I"}},
+		{"double quotes", `ask what is "edge host" mode`, []string{"ask", "what", "is", `"edge host"`, "mode"}},
+		{"single quotes", `ask what is 'edge host' mode`, []string{"ask", "what", "is", `'edge host'`, "mode"}},
+		{"smart quotes", "ask what is “edge host” mode", []string{"ask", "what", "is", `"edge host"`, "mode"}},
+		{"apostrophes", "ask what's the cluster’s status", []string{"ask", "what's", "the", "cluster’s", "status"}},
+		{"quoted flag value", `ask --product="palette vertex" why`, []string{"ask", `--product="palette vertex"`, "why"}},
+		{"unterminated quote", `ask what is "edge host`, []string{"ask", "what", "is", `"edge host`}},
+		{"empty quotes", `ask ""`, []string{"ask", `""`}},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.expected, Tokenize(tc.text))
+		})
+	}
+}
+
+func TestParseArgs(t *testing.T) {
+	tests := []struct {
+		name     string
+		tokens   []string
+		expected AskOptions
+	}{
+		{
+			name:     "query only",
+			tokens:   []string{"how", "do", "I"},
+			expected: AskOptions{Query: "how do I"},
+		},
+		{
+			name:     "boolean flags anywhere",
+			tokens:   []string{"--private", "how", "do", "I", "--new"},
+			expected: AskOptions{Query: "how do I", Private: true, NewConversation: true},
+		},
+		{
+			name:     "value flags",
+			tokens:   []string{"--product=palette", "--version=4.2", "how", "do", "I"},
+			expected: AskOptions{Query: "how do I", Product: "palette", Version: "4.2"},
+		},
+		{
+			name:     "quoted value flag",
+			tokens:   []string{`--product="palette vertex"`, "why"},
+			expected: AskOptions{Query: "why", Product: "palette vertex"},
+		},
+		{
+			name:     "case insensitive flag names",
+			tokens:   []string{"--PRIVATE", "why"},
+			expected: AskOptions{Query: "why", Private: true},
+		},
+		{
+			name:     "em dash flags",
+			tokens:   []string{"—private", "why"},
+			expected: AskOptions{Query: "why", Private: true},
+		},
+		{
+			name:     "disabled boolean flag",
+			tokens:   []string{"--private=false", "why"},
+			expected: AskOptions{Query: "why"},
+		},
+		{
+			name:     "unknown flags are part of the query",
+			tokens:   []string{"what", "does", "--force", "do"},
+			expected: AskOptions{Query: "what does --force do"},
+		},
+		{
+			name:     "double dash ends flags",
+			tokens:   []string{"--new", "--", "what", "does", "--private", "do"},
+			expected: AskOptions{Query: "what does --private do", NewConversation: true},
+		},
+		{
+			name:     "em dash ends flags",
+			tokens:   []string{"--private", "—", "what", "does", "--new", "do"},
+			expected: AskOptions{Query: "what does --new do", Private: true},
+		},
+		{
+			name:     "value flags without a value are part of the query",
+			tokens:   []string{"what", "is", "--product", "and", "--version="},
+			expected: AskOptions{Query: "what is --product and --version="},
+		},
+		{
+			name:     "flags only",
+			tokens:   []string{"--private"},
+			expected: AskOptions{Private: true},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.expected, NewAskOptions(ParseArgs(tc.tokens, askFlags)))
+		})
+	}
+}
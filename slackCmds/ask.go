@@ -9,62 +9,114 @@ import (
 	"fmt"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/rs/zerolog/log"
 	"spectrocloud.com/spectromate/internal"
+	"spectrocloud.com/spectromate/internal/blockkit"
 )
 
-type SlackAskRequest struct {
-	ctx            context.Context
-	slackEvent     *internal.SlackEvent
-	mendableAPIKey string
-	cache          internal.Cache
-	version        string
-}
-
-func NewSlackAskRequest(ctx context.Context, slackEvent *internal.SlackEvent, mendableAPIKey string, cache internal.Cache, version string) *SlackAskRequest {
-	return &SlackAskRequest{ctx, slackEvent, mendableAPIKey, cache, version}
-}
-
 // The ask command is used to ask a question about the docs.
 // The user will be prompted to enter their question.
 // Users can ask a question privately or publicly.
 // If the user asks a question privately, the bot will respond privately.
 // If the user asks a question publicly, the bot will respond publicly.
 // Set the isPrivate bool to true to ask a question privately.
-func AskCmd(s *SlackAskRequest, isPrivate bool) {
+// The returned error is the error reported to the user, if any.
+func AskCmd(s *SlackCommandRequest, isPrivate bool) (askErr error) {
 
 	var (
 		conversationId   int64
 		mendableResponse internal.MendableQueryResponse
 		requestCounter   int
 		globalErr        *error
+		options          = NewAskOptions(s.args)
 	)
+
+	// The --private flag turns any ask into a private ask.
+	// The channel settings can make every ask private.
+	isPrivate = isPrivate || options.Private || s.channel.Private
 	// This will run after the current function returns.
 	// This will check if an error occurred and send an error message to the user.
 	// This acts as a catch all for any errors that may occur and notifiy the user.
 	// A pointer value is used to workaround defer's default behavior of evaulating the arguments immediately.
 	defer func() {
 		errorEval(s.ctx, globalErr, s, isPrivate)
+		if globalErr != nil {
+			askErr = *globalErr
+		}
 		globalErr = nil
 	}()
 
-	// This will get the user's question.
-	// Split the string on spaces
+	// The ask command gets its own deadline so a slow dependency can't keep the Go routine around forever.
+	// The error message is sent with the parent context so the user is still notified after a timeout.
+	ctx, cancel := context.WithTimeout(s.ctx, internal.DefaultAskCommandTimeout)
+	defer cancel()
+
+	// Don't make the user wait for requests that are bound to fail while the docs service is down.
+	if internal.MendableCircuitOpen() {
+		log.Warn().Str("user_id", s.slackEvent.UserID).Msg("The Mendable circuit breaker is open. Replying with the degraded message.")
+		err := internal.ReplyWithMessage(ctx, s.slackEvent.ResponseURL, internal.DefaultDegradedMessage, isPrivate)
+		if err != nil {
+			log.Info().Err(err).Msg("Error when attempting to return the degraded message back to Slack.")
+			internal.LogError(err)
+		}
+		return
+	}
+
+	// The query is built from the positional arguments, flags are removed.
+	userQuery := options.Query
+	if userQuery == "" {
+		log.Debug().Str("user_id", s.slackEvent.UserID).Msg("No question was provided.")
+		err := internal.ReplyWithMessage(ctx, s.slackEvent.ResponseURL, internal.DefaultAskUsageMessage, true)
+		if err != nil {
+			log.Info().Err(err).Msg("Error when attempting to return the usage message back to Slack.")
+			internal.LogError(err)
+		}
+		return
+	}
 
-	words := strings.Split(s.slackEvent.Text, " ")[1:]
-	lastWord := words[len(words)-1]
-	userQuery := strings.Join(words[:len(words)-1], " ") + " " + strings.TrimRight(lastWord, "\r\n")
+	// Sensitive information is removed before the question leaves the cluster, and the user is told what was removed.
+	userQuery, redacted := s.redactor.Redact(userQuery)
+	if len(redacted) > 0 {
+		log.Info().Str("user_id", s.slackEvent.UserID).Strs("detectors", redacted).Msg("Removed sensitive information from the question.")
+		err := internal.ReplyWithMessage(ctx, s.slackEvent.ResponseURL, fmt.Sprintf(internal.DefaultRedactionNoticeMessage, strings.Join(redacted, ", ")), true)
+		if err != nil {
+			log.Info().Err(err).Msg("Error when attempting to return the redaction notice back to Slack.")
+			internal.LogError(err)
+		}
+	}
+
+	// The scope from the flags takes precedence over the channel settings, followed by the default scope of the channel.
+	scope, err := internal.NewDocsScope(options.Product, options.Version)
+	if err != nil {
+		log.Debug().Err(err).Str("user_id", s.slackEvent.UserID).Msg("Invalid docs scope.")
+		err = internal.ReplyWithMessage(ctx, s.slackEvent.ResponseURL, fmt.Sprintf(internal.DefaultInvalidScopeMessage, err), true)
+		if err != nil {
+			log.Info().Err(err).Msg("Error when attempting to return the invalid scope message back to Slack.")
+			internal.LogError(err)
+		}
+		return
+	}
+	scope = scope.Merge(s.channel.Scope).Merge(s.scopes.ChannelScope(s.slackEvent.ChannelID))
 
 	// Log the user query
-	log.Debug().Msgf("User query: %v", userQuery)
+	log.Debug().Str("product", scope.Product).Str("version", scope.Version).Bool("new_conversation", options.NewConversation).Msgf("User query: %v", userQuery)
 
 	// Check if a conversation already exists for this user.
-	isExistingConversation, cacheItem, err := getUserCache(s.ctx, s)
+	// If the cache can't be read, the question is answered in a new conversation without history.
+	// The --new flag skips the lookup so the question starts a new conversation.
+	var (
+		isExistingConversation bool
+		cacheItem              *internal.CacheItem
+	)
+	if !options.NewConversation {
+		isExistingConversation, cacheItem, err = getConversation(ctx, s)
+	}
 	if err != nil {
-		log.Debug().Err(err).Msgf("an error occured when checking for an exiting conversation: %+v", s.slackEvent)
-		globalErr = &err
-		return
+		log.Warn().Err(err).Str("user_id", s.slackEvent.UserID).Str("channel_id", s.slackEvent.ChannelID).Msg("Unable to read the conversation from the cache. Answering the question without the conversation history.")
+		internal.RecordDegradation(internal.DegradationCacheRead)
+		isExistingConversation, cacheItem = false, nil
 	}
 
 	// This catches any errors that may occur and returns an error message to the user.
@@ -73,7 +125,7 @@ func AskCmd(s *SlackAskRequest, isPrivate bool) {
 	case false:
 		// Create a new conversation.
 		log.Debug().Msgf("Creating a new conversation for user: %v", s.slackEvent.UserID)
-		id, err := internal.CreateNewConversation(s.ctx, s.mendableAPIKey, internal.MendandableNewConversationURL)
+		id, err := internal.CreateNewConversation(ctx, s.mendableAPIKey, internal.MendandableNewConversationURL)
 		if err != nil {
 			log.Debug().Err(err).Msgf("Error creating new conversation: %+v", s.slackEvent)
 			globalErr = &err
@@ -87,9 +139,10 @@ func AskCmd(s *SlackAskRequest, isPrivate bool) {
 			History:        []internal.HistoryItems{},
 			ConversationID: conversationId,
 			ShouldStream:   false,
+			Metadata:       scope.Metadata(),
 		}
 
-		mendableResponse, err = internal.SendDocsQuery(s.ctx, questionRequestItem, internal.MendableChatQueryURL, s.version)
+		mendableResponse, err = internal.SendDocsQuery(ctx, questionRequestItem, internal.MendableChatQueryURL, s.version)
 		if err != nil {
 			internal.LogError(err)
 			globalErr = &err
@@ -123,15 +176,33 @@ func AskCmd(s *SlackAskRequest, isPrivate bool) {
 		}
 		requestCounter = int(cNew)
 		conversationId = cID
+
+		// Stop the conversation from growing past the configured question limit.
+		if s.conversation.LimitReached(requestCounter) {
+			log.Debug().Msgf("Conversation question limit reached for user: %v", s.slackEvent.UserID)
+			limitMessage := fmt.Sprintf(internal.DefaultConversationLimitMessage, s.conversation.MaxQuestions, internal.FormatDuration(s.conversation.CacheTTL()))
+			err = internal.ReplyWithMessage(ctx, s.slackEvent.ResponseURL, limitMessage, isPrivate)
+			if err != nil {
+				log.Info().Err(err).Msg("Error when attempting to return the conversation limit message back to Slack.")
+				internal.LogError(err)
+				globalErr = &err
+			}
+			return
+		}
+
+		// The history is redacted too, in case it was stored before redaction was enabled.
+		history, _ := s.redactor.RedactHistory(internal.TruncateHistory(cacheItem.History, s.conversation.MaxHistoryItems, s.conversation.MaxHistoryChars))
+
 		questionRequestItem := internal.MendableRequestPayload{
 			ApiKey:         s.mendableAPIKey,
 			Question:       userQuery,
-			History:        cacheItem.History,
+			History:        history,
 			ConversationID: conversationId,
 			ShouldStream:   false,
+			Metadata:       scope.Metadata(),
 		}
 
-		mendableResponse, err = internal.SendDocsQuery(s.ctx, questionRequestItem, internal.MendableChatQueryURL, s.version)
+		mendableResponse, err = internal.SendDocsQuery(ctx, questionRequestItem, internal.MendableChatQueryURL, s.version)
 		if err != nil {
 			log.Debug().Err(err).Msgf("Error sending question to Mendable: %+v", s.slackEvent)
 			internal.LogError(err)
@@ -145,146 +216,227 @@ func AskCmd(s *SlackAskRequest, isPrivate bool) {
 
 	log.Debug().Msgf("ChacheItem: %v", cacheItem)
 
-	linksString := linksBuilderString(mendableResponse.Links)
-	markdownContent := fmt.Sprintf(`%v`, mendableResponse.Answer)
+	sources := s.scopes.RewriteSources(scope, mendableResponse.Sources)
+	// Mendable answers in GitHub-flavored Markdown, which Slack doesn't render.
+	markdownContent := internal.MarkdownToMrkdwn(mendableResponse.Answer)
 
-	err = storeUserEntry(s.ctx, s, mendableResponse, requestCounter, cacheItem)
+	// The answer is still delivered if it can't be stored. The next question starts a new conversation.
+	err = storeUserEntry(ctx, s, mendableResponse, requestCounter, cacheItem)
 	if err != nil {
-		log.Debug().Err(err).Msgf("Error storing user entry: %+v", s.slackEvent)
-		globalErr = &err
-		return
+		log.Warn().Err(err).Str("user_id", s.slackEvent.UserID).Str("channel_id", s.slackEvent.ChannelID).Msg("Unable to store the conversation in the cache. Delivering the answer without storing it.")
+		internal.RecordDegradation(internal.DegradationCacheWrite)
+	}
+
+	answer := internal.AnswerState{
+		MessageID:  mendableResponse.MessageID,
+		Title:      answerTitle(scope),
+		Question:   internal.FormatAnswerQuestion(mendableResponse.Question),
+		Answer:     markdownContent,
+		Links:      sourcesBuilderString(sources),
+		Confidence: mendableResponse.Confidence,
+		Private:    isPrivate,
+		UserID:     s.slackEvent.UserID,
+		ChannelID:  s.slackEvent.ChannelID,
+		CreatedAt:  time.Now(),
+		Product:    scope.Product,
+		Version:    scope.Version,
+		Backend:    s.channel.AnswerBackend(),
+
+		ConversationID: strconv.FormatInt(conversationId, 10),
+	}
+	// The most relevant source is opened by the "Open in docs" button.
+	if len(sources) > 0 {
+		answer.DocsURL = sources[0].URL
+	}
+	// Answers with a confidence level below the threshold of the channel are caveated or withheld.
+	answer.LowConfidence = s.confidence.Check(mendableResponse.Confidence)
+	if answer.LowConfidence != "" {
+		log.Info().Str("message_id", mendableResponse.MessageID).Str("confidence", mendableResponse.Confidence).Str("mode", string(answer.LowConfidence)).Msg("The answer confidence level is below the threshold.")
 	}
+	// Questions the backend couldn't answer, or answered with a low confidence level, can be escalated to the support team.
+	answer.Escalate = s.escalation.Enabled() && answer.Uncertain()
 
-	q := fmt.Sprintf(`:question: %v`, mendableResponse.Question)
+	// The content of the answer is stored so the answer actions can find it by message ID.
+	err = internal.StoreAnswerState(ctx, s.cache, answer, internal.DefaultAnswerStateExpirationPeriod)
+	if err != nil {
+		log.Warn().Err(err).Str("message_id", mendableResponse.MessageID).Msg("Unable to store the answer state. The answer actions fall back to the message content.")
+		internal.RecordDegradation(internal.DegradationCacheWrite)
+	}
 
-	slackReplyPayload, err := askMarkdownPayload(markdownContent, q, linksString, "Docs Answer", mendableResponse.MessageID, isPrivate, mendableResponse.Confidence)
+	slackReplyPayloads, err := AnswerPayloads(answer, "")
 	if err != nil {
 		log.Info().Err(err).Msg("Error creating markdown payload.")
 		globalErr = &err
 		return
 	}
 
-	err = internal.ReplyWithAnswer(s.slackEvent.ResponseURL, slackReplyPayload, isPrivate)
-	if err != nil {
-		log.Info().Err(err).Msg("Error when attempting to return the answer back to Slack.")
-		internal.LogError(err)
-		globalErr = &err
-		// Waiting 5 seconds before returning the error to Slack.
+	// Long answers are sent in several messages, in order.
+	for _, slackReplyPayload := range slackReplyPayloads {
+		err = internal.ReplyWithAnswer(ctx, s.slackEvent.ResponseURL, slackReplyPayload, isPrivate)
+		if err != nil {
+			log.Info().Err(err).Msg("Error when attempting to return the answer back to Slack.")
+			internal.LogError(err)
+			globalErr = &err
+			// Waiting 5 seconds before returning the error to Slack.
+			return
+		}
 	}
+
+	return
 }
 
-// // createMarkdownPayload creates a Slack payload with a markdown block
-func askMarkdownPayload(content, question, links, title, messageId string, isPrivate bool, confidence string) ([]byte, error) {
-	log.Debug().Msgf("Incoming Message: %v", content)
-
-	var responseType string
-	if isPrivate {
-		responseType = "ephemeral"
-	} else {
-		responseType = "in_channel"
-	}
-
-	payload := internal.SlackPayload{
-		ResponseType: responseType,
-		Blocks: []internal.SlackBlock{
-			{
-				Type: "header",
-				Text: &internal.SlackTextObject{
-					Type: "plain_text",
-					Text: title,
-				},
-			},
-			{
-				Type: "divider",
-			},
-			{
-				Type: "section",
-				Text: &internal.SlackTextObject{
-					Type: "mrkdwn",
-					Text: question,
-				},
-			},
-			{
-				Type: "divider",
-			},
-			{
-				Type: "section",
-				Text: &internal.SlackTextObject{
-					Type: "mrkdwn",
-					Text: content,
-				},
-			},
-			{
-				Type: "divider",
-			},
-			{
-				Type: "section",
-				Text: &internal.SlackTextObject{
-					Type: "mrkdwn",
-					Text: links,
-				},
-			},
-			{
-				Type: "divider",
-			},
-			{
-				Type: "section",
-				Fields: []internal.SlackTextObject{
-					{
-						Type: "mrkdwn",
-						Text: "*Answer Confidence Level:* " + confidence + "%",
-					},
-				},
-			},
-			{
-				Type: "divider",
-			},
-			{
-				Type: "section",
-				Fields: []internal.SlackTextObject{
-					{
-						Type: "mrkdwn",
-						Text: "*Rate Answer:*",
-					},
-				},
-			},
-			{
-				Type: "actions",
-				Elements: []internal.SlackElements{
-					{
-						Type:  "button",
-						Value: messageId,
-						Text: internal.SlackElementText{
-							Type:  "plain_text",
-							Emoji: true,
-							Text:  ":thumbsup:",
-						},
-						Style:    "primary",
-						ActionID: internal.ActionsAskModelPositiveFeedbackID,
-					},
-					{
-						Type:  "button",
-						Value: messageId,
-						Text: internal.SlackElementText{
-							Type:  "plain_text",
-							Emoji: true,
-							Text:  ":thumbsdown:",
-						},
-						Style:    "danger",
-						ActionID: internal.ActionsAskModelNegativeFeedbackID,
-					},
-				},
-			},
-		},
+// AnswerPayloads returns the answer message, followed by the messages with the rest of the answer
+// if it doesn't fit in a single message. The answer is split into sections at paragraph and code block
+// boundaries to stay within the Slack limits. Answers too long for the follow-up messages are truncated.
+// The byline is displayed below the question if it's not empty, such as the user who shared the answer.
+// Answers with a low confidence level are displayed below a warning, or only with their sources if they're withheld.
+// It's also used by the answer actions to post the answer again.
+func AnswerPayloads(state internal.AnswerState, byline string) ([][]byte, error) {
+	log.Debug().Msgf("Incoming Message: %v", state.Answer)
+
+	title, isPrivate := state.Title, state.Private
+
+	head := []blockkit.Block{
+		blockkit.Header(internal.TruncateText(title, blockkit.MaxHeaderTextLength)).WithBlockID(internal.AnswerBlockIDHeader),
+		blockkit.Divider(),
+		blockkit.Section(blockkit.Markdown(internal.TruncateText(state.Question, blockkit.MaxSectionTextLength))).WithBlockID(internal.AnswerBlockIDQuestion),
+	}
+	if byline != "" {
+		head = append(head, internal.AnswerByline(byline))
+	}
+	if state.LowConfidence != "" {
+		head = append(head, internal.LowConfidenceNotice(state))
 	}
 
-	payloadBytes, err := json.Marshal(payload)
-	if err != nil {
-		return []byte{}, err
+	// Withheld answers go straight to the sources, which are below a divider.
+	var sections []blockkit.Block
+	if state.LowConfidence != internal.LowConfidenceWithhold {
+		head = append(head, blockkit.Divider())
+		sections = internal.AnswerSections(state.Answer)
+	}
+
+	tail := []blockkit.Block{
+		blockkit.Divider(),
+		blockkit.Section(blockkit.Markdown(internal.TruncateText(state.Links, blockkit.MaxSectionTextLength))).WithBlockID(internal.AnswerBlockIDSources),
+		blockkit.Divider(),
+		blockkit.Fields(blockkit.Markdown("*Answer Confidence Level:* " + state.Confidence + "%")).WithBlockID(internal.AnswerBlockIDConfidence),
+		blockkit.Divider(),
+		blockkit.Fields(blockkit.Markdown("*Rate Answer:*")).WithBlockID(internal.AnswerBlockIDRating),
+		blockkit.Actions(
+			blockkit.Button(":thumbsup:", internal.ActionsAskModelPositiveFeedbackID, state.MessageID).WithStyle(blockkit.StylePrimary),
+			blockkit.Button(":thumbsdown:", internal.ActionsAskModelNegativeFeedbackID, state.MessageID).WithStyle(blockkit.StyleDanger),
+		).WithBlockID(internal.AnswerBlockIDActions),
+		internal.AnswerMoreActions(state),
+	}
+
+	// The first message keeps the question, sources and answer actions, so it has room for fewer sections.
+	total := len(sections)
+	messages, sections := paginateSections(sections, blockkit.MaxBlocks-len(head)-len(tail))
+
+	var payloads [][]byte
+	for i, answer := range messages {
+		last := i == len(messages)-1
+		switch {
+		case !last:
+			answer = append(answer, noteSection(internal.DefaultAnswerContinuedMessage))
+		case len(sections) > 0:
+			log.Warn().Int("sections", len(sections)).Msg("The answer is too long for Slack and was truncated.")
+			answer = append(answer, truncatedSection(state.MessageID, total-len(sections)))
+		}
+
+		message := blockkit.NewMessage(isPrivate)
+		if i == 0 {
+			message.Add(head...).Add(answer...).Add(tail...)
+		} else {
+			message.Add(blockkit.Header(internal.TruncateText(title+" (continued)", blockkit.MaxHeaderTextLength))).Add(answer...)
+		}
+
+		payloadBytes, err := message.JSON()
+		if err != nil {
+			return nil, err
+		}
+		log.Debug().Msgf("Slack Answer Payload: %v", string(payloadBytes))
+		payloads = append(payloads, payloadBytes)
+	}
+
+	if len(payloads) == 0 {
+		payloadBytes, err := blockkit.NewMessage(isPrivate, append(head, tail...)...).JSON()
+		if err != nil {
+			return nil, err
+		}
+		payloads = append(payloads, payloadBytes)
+	}
+
+	return payloads, nil
+}
+
+// AnswerRestPayloads returns the messages with the sections of the answer from the index, which didn't fit in the
+// answer messages. The messages are private, because only the user who asked for them reads them. If the rest of the
+// answer doesn't fit either, the last message has the button showing the sections that follow.
+// No message is returned if the answer has no section at the index.
+func AnswerRestPayloads(state internal.AnswerState, index int) ([][]byte, error) {
+	sections := internal.AnswerSections(state.Answer)
+	if index < 0 || index >= len(sections) {
+		return nil, nil
 	}
 
-	log.Debug().Msgf("Slack Answer Payload: %v", string(payloadBytes))
-	return payloadBytes, nil
+	total := len(sections)
+	messages, sections := paginateSections(sections[index:], blockkit.MaxBlocks-1)
+
+	var payloads [][]byte
+	for i, answer := range messages {
+		switch {
+		case i < len(messages)-1:
+			answer = append(answer, noteSection(internal.DefaultAnswerContinuedMessage))
+		case len(sections) > 0:
+			answer = append(answer, truncatedSection(state.MessageID, total-len(sections)))
+		}
+
+		message := blockkit.NewMessage(true, blockkit.Header(internal.TruncateText(state.Title+" (continued)", blockkit.MaxHeaderTextLength))).Add(answer...)
+		payloadBytes, err := message.JSON()
+		if err != nil {
+			return nil, err
+		}
+		payloads = append(payloads, payloadBytes)
+	}
+
+	return payloads, nil
+}
+
+// paginateSections splits the sections into the answer messages, up to DefaultMaxAnswerFollowUps follow-up messages.
+// The first message has room for the budget, and each follow-up message for a header followed by the next sections.
+// A block is kept for a note in every message followed by another one, and in the last one if sections don't fit.
+// The sections that don't fit in any message are returned.
+func paginateSections(sections []blockkit.Block, budget int) ([][]blockkit.Block, []blockkit.Block) {
+	var messages [][]blockkit.Block
+	for len(sections) > 0 {
+		if len(messages) == internal.DefaultMaxAnswerFollowUps+1 {
+			break
+		}
+		if len(sections) <= budget {
+			messages = append(messages, sections)
+			sections = nil
+			break
+		}
+		// The capacity is limited, so appending the note doesn't overwrite the first section of the next message.
+		messages = append(messages, sections[:budget-1:budget-1])
+		sections = sections[budget-1:]
+		budget = blockkit.MaxBlocks - 1
+	}
+	return messages, sections
+}
+
+// truncatedSection returns the note at the end of a truncated answer, with the button showing the rest of the answer
+// from the section with the index.
+func truncatedSection(messageID string, index int) blockkit.Block {
+	return blockkit.Section(blockkit.Markdown("_" + internal.DefaultAnswerTruncatedMessage + "_")).
+		WithBlockID(internal.AnswerBlockIDNote).
+		WithAccessory(blockkit.Button("Show the rest", internal.ActionsAskShowRestID, internal.ShowRestValue(messageID, index)))
+}
+
+// noteSection returns a section with an italic note about the answer.
+func noteSection(note string) blockkit.Block {
+	return blockkit.Section(blockkit.Markdown("_" + note + "_")).WithBlockID(internal.AnswerBlockIDNote)
 }
 
 // storeUserEntry stores the user entry in the cache.
@@ -296,7 +448,7 @@ func askMarkdownPayload(content, question, links, title, messageId string, isPri
 // - Channel ID
 // - Conversation IDSlackCommandsIntf
 // - Timestamp
-func storeUserEntry(ctx context.Context, s *SlackAskRequest, response internal.MendableQueryResponse, counter int, previousCacheItem *internal.CacheItem) error {
+func storeUserEntry(ctx context.Context, s *SlackCommandRequest, response internal.MendableQueryResponse, counter int, previousCacheItem *internal.CacheItem) error {
 
 	primaryKey := fmt.Sprintf("docs_bot:user_id:channel_id:%s:%s", s.slackEvent.UserID, s.slackEvent.ChannelID)
 
@@ -315,6 +467,8 @@ func storeUserEntry(ctx context.Context, s *SlackAskRequest, response internal.M
 	}
 
 	newHistory := append(previousHistory, newHistItem...)
+	// Drop the oldest entries so the cached history stays within the configured limits.
+	newHistory = internal.TruncateHistory(newHistory, s.conversation.MaxHistoryItems, s.conversation.MaxHistoryChars)
 
 	// convert newHistory to a string
 	newHistoryString, err := json.Marshal(newHistory)
@@ -341,7 +495,7 @@ func storeUserEntry(ctx context.Context, s *SlackAskRequest, response internal.M
 
 	// log.Debug().Msgf("Stored user entry in cache: %v", cacheItem)
 
-	err = s.cache.ExpireKey(ctx, primaryKey, internal.DefaultCacheExpirationPeriod)
+	err = s.cache.ExpireKey(ctx, primaryKey, s.conversation.CacheTTL())
 	if err != nil {
 		log.Error().Err(err).Msg("Error setting expiration on user entry in cache.")
 		return err
@@ -350,10 +504,25 @@ func storeUserEntry(ctx context.Context, s *SlackAskRequest, response internal.M
 	return err
 }
 
+// getConversation returns the conversation continued by the question.
+// Questions asked from an answer action continue the conversation of the answer. The history is only used if it's
+// the current conversation of the user in the channel, so the history of another user is never sent. Otherwise the
+// conversation of the answer is continued without history. Other questions continue the conversation of the user.
+func getConversation(ctx context.Context, s *SlackCommandRequest) (bool, *internal.CacheItem, error) {
+	found, cacheItem, err := getUserCache(ctx, s)
+	conversationID := s.slackEvent.ConversationID
+	if err != nil || conversationID == "" || (found && cacheItem.ConversationID == conversationID) {
+		return found, cacheItem, err
+	}
+
+	log.Debug().Str("conversation_id", conversationID).Str("user_id", s.slackEvent.UserID).Msg("The conversation of the answer isn't the conversation of the user. Continuing it without history.")
+	return true, &internal.CacheItem{ConversationID: conversationID, Counter: "0"}, nil
+}
+
 // getUserCache retrieves the entire cache item from the cache.
-// If the cache item is not found, it returns true and a nil cache item.
+// If the cache item is not found, it returns false and a nil cache item.
 // If an error occurs, it returns false and the error.
-func getUserCache(ctx context.Context, s *SlackAskRequest) (bool, *internal.CacheItem, error) {
+func getUserCache(ctx context.Context, s *SlackCommandRequest) (bool, *internal.CacheItem, error) {
 	primaryKey := fmt.Sprintf("docs_bot:user_id:channel_id:%s:%s", s.slackEvent.UserID, s.slackEvent.ChannelID)
 
 	ok, result, err := s.cache.GetHashMap(ctx, primaryKey)
@@ -385,24 +554,39 @@ func getUserCache(ctx context.Context, s *SlackAskRequest) (bool, *internal.Cach
 	return true, cacheItem, nil
 }
 
-// linksBuilderString builds a string of links.
-func linksBuilderString(urls []string) string {
+// sourcesBuilderString builds the list of sources, in order of relevance, with the title, section and snippet of each source.
+func sourcesBuilderString(sources []internal.Source) string {
 
-	if len(urls) == 0 {
+	if len(sources) == 0 {
 		return internal.DefaultNoSourcesIdentifiedMessage
 	}
 
 	var sb strings.Builder
 	sb.WriteString("*Sources*:\n")
-	for _, url := range urls {
-		sb.WriteString(fmt.Sprintf("- %s\n", url))
+	for _, source := range sources {
+		sb.WriteString(fmt.Sprintf("- %s\n", strings.ReplaceAll(source.Mrkdwn(), "\n", "\n   ")))
 	}
 	return sb.String()
 }
 
-func errorEval(ctx context.Context, e *error, s *SlackAskRequest, isPrivate bool) {
+// answerTitle returns the answer header, including the docs scope if there is one.
+func answerTitle(scope internal.DocsScope) string {
+	if scope.IsEmpty() {
+		return "Docs Answer"
+	}
+	return fmt.Sprintf("Docs Answer (%s)", scope.Label())
+}
+
+// errorEval replies to the user with an error message if an error occurred.
+// Timeouts and Mendable API errors are mapped to dedicated messages so the user knows whether to retry.
+func errorEval(ctx context.Context, e *error, s *SlackCommandRequest, isPrivate bool) {
 	if e != nil {
-		err := internal.ReplyWithErrorMessage(s.slackEvent.ResponseURL, isPrivate)
+		internal.LogErrorFields(log.Warn(), *e).
+			Str("user_id", s.slackEvent.UserID).
+			Str("channel_id", s.slackEvent.ChannelID).
+			Msg("The ask command failed.")
+
+		err := internal.ReplyWithMessage(ctx, s.slackEvent.ResponseURL, internal.UserErrorMessage(*e), isPrivate)
 		if err != nil {
 			log.Error().Err(err).Msg("Error when attempting to return the answer back to Slack.")
 			internal.LogError(err)
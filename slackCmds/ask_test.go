@@ -5,44 +5,46 @@ package slackCmds
 
 import (
 	"context"
+	"encoding/json"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/golang/mock/gomock"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 	"spectrocloud.com/spectromate/internal"
+	"spectrocloud.com/spectromate/internal/blockkit"
 	"spectrocloud.com/spectromate/mock"
 )
 
-func TestLinksBuilderString(t *testing.T) {
+func TestSourcesBuilderString(t *testing.T) {
 	// Test case 1: Empty slice
 
-	urls1 := []string{}
-	expected1 := internal.DefaultNoSourcesIdentifiedMessage
-
-	result1 := linksBuilderString(urls1)
-	if result1 != expected1 {
-		t.Errorf("Expected: %s, got: %s", expected1, result1)
+	result1 := sourcesBuilderString(nil)
+	if result1 != internal.DefaultNoSourcesIdentifiedMessage {
+		t.Errorf("Expected: %s, got: %s", internal.DefaultNoSourcesIdentifiedMessage, result1)
 	}
 
-	// Test case 2: Non-empty slice
-	urls2 := []string{"https://example.com/doc1", "https://example.com/doc2"}
+	// Test case 2: Sources are listed in order with their title, section and snippet
+	sources := []internal.Source{
+		{URL: "https://docs.spectrocloud.com/clusters#deploy", Title: "Clusters", Section: "Deploy", Snippet: "Deploy a cluster."},
+		{URL: "https://docs.spectrocloud.com/registries", Title: "Registries"},
+	}
 
-	result2 := linksBuilderString(urls2)
-	for _, url := range urls2 {
-		if !strings.Contains(result2, url) {
-			t.Errorf("Expected result2 to contain: %s, but it did not", url)
-		}
+	expected2 := "*Sources*:\n" +
+		"- <https://docs.spectrocloud.com/clusters#deploy|Clusters › Deploy>\n   Deploy a cluster.\n" +
+		"- <https://docs.spectrocloud.com/registries|Registries>\n"
+	if result2 := sourcesBuilderString(sources); result2 != expected2 {
+		t.Errorf("Expected: %s, got: %s", expected2, result2)
 	}
 
 	// Test case 3: URL with special characters
-	urls3 := []string{"https://example.com/doc1?query=value&another=value"}
+	sources3 := []internal.Source{{URL: "https://example.com/doc1?query=value&another=value", Title: "A | B <C>"}}
 
-	result3 := linksBuilderString(urls3)
-	for _, url := range urls3 {
-		if !strings.Contains(result3, url) {
-			t.Errorf("Expected result3 to contain: %s, but it did not", url)
-		}
+	result3 := sourcesBuilderString(sources3)
+	if !strings.Contains(result3, "<https://example.com/doc1?query=value&amp;another=value|A ¦ B &lt;C&gt;>") {
+		t.Errorf("Expected result3 to contain the escaped link, got: %s", result3)
 	}
 }
 func TestStoreUserEntry(t *testing.T) {
@@ -80,7 +82,7 @@ func TestStoreUserEntry(t *testing.T) {
 		ConversationID: 123456,
 		Question:       "What is the capital of France?",
 		Answer:         "The capital of France is Paris.",
-		Links:          []string{"https://example.com/doc1", "https://example.com/doc2"},
+		Sources:        []internal.Source{{URL: "https://example.com/doc1"}, {URL: "https://example.com/doc2"}},
 	}
 
 	cacheItem := &internal.CacheItem{
@@ -93,7 +95,7 @@ func TestStoreUserEntry(t *testing.T) {
 		Counter:        "1",
 	}
 
-	slackAskRequest := &SlackAskRequest{
+	slackAskRequest := &SlackCommandRequest{
 		ctx:        context.Background(),
 		slackEvent: slackEvent,
 		cache:      mockCache,
@@ -120,7 +122,7 @@ func TestStoreUserEntry(t *testing.T) {
 		Response: "The capital of France is Paris.",
 	})
 
-	slackAskRequest = &SlackAskRequest{
+	slackAskRequest = &SlackCommandRequest{
 		ctx:        context.Background(),
 		slackEvent: slackEvent,
 		cache:      mockCache,
@@ -176,10 +178,10 @@ func TestStoreUserEntryAppend(t *testing.T) {
 		ConversationID: 123456,
 		Question:       "What is the capital of Italy?",
 		Answer:         "The capital of Italy is Rome.",
-		Links:          []string{"https://example.com/doc1", "https://example.com/doc2"},
+		Sources:        []internal.Source{{URL: "https://example.com/doc1"}, {URL: "https://example.com/doc2"}},
 	}
 
-	slackAskRequest := &SlackAskRequest{
+	slackAskRequest := &SlackCommandRequest{
 		ctx:        context.Background(),
 		slackEvent: slackEvent,
 		cache:      mockCache,
@@ -188,3 +190,328 @@ func TestStoreUserEntryAppend(t *testing.T) {
 	err := storeUserEntry(context.Background(), slackAskRequest, *mendableQueryResponse, 2, cacheItem)
 	assert.NoError(t, err)
 }
+
+func TestStoreUserEntryConversationLimits(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockCache := mock.NewMockCache(ctrl)
+
+	slackEvent := &internal.SlackEvent{
+		UserID:    "U123456",
+		ChannelID: "C123456",
+	}
+
+	cacheItem := &internal.CacheItem{
+		History: []internal.HistoryItems{
+			{Prompt: "What is the capital of Germany?", Response: "The capital of Germany is Berlin."},
+			{Prompt: "What is the capital of Spain?", Response: "The capital of Spain is Madrid."},
+		},
+		Counter: "2",
+	}
+
+	// Only the two most recent history items are expected to be stored.
+	mockCache.EXPECT().StoreHashMap(
+		context.Background(),
+		"docs_bot:user_id:channel_id:U123456:C123456",
+		gomock.Any(),
+	).DoAndReturn(func(ctx context.Context, key string, item map[string]interface{}) error {
+		var history []internal.HistoryItems
+		err := json.Unmarshal(item["History"].([]byte), &history)
+		assert.NoError(t, err)
+		assert.Len(t, history, 2)
+		assert.Equal(t, "What is the capital of Spain?", history[0].Prompt)
+		assert.Equal(t, "What is the capital of Italy?", history[1].Prompt)
+		return nil
+	})
+
+	mockCache.EXPECT().ExpireKey(
+		context.Background(),
+		"docs_bot:user_id:channel_id:U123456:C123456",
+		30*time.Minute,
+	).Return(nil)
+
+	slackAskRequest := &SlackCommandRequest{
+		ctx:        context.Background(),
+		slackEvent: slackEvent,
+		cache:      mockCache,
+		conversation: internal.ConversationConfig{
+			TTL:             30 * time.Minute,
+			MaxHistoryItems: 2,
+		},
+	}
+
+	mendableQueryResponse := internal.MendableQueryResponse{
+		ConversationID: 123456,
+		Question:       "What is the capital of Italy?",
+		Answer:         "The capital of Italy is Rome.",
+	}
+
+	err := storeUserEntry(context.Background(), slackAskRequest, mendableQueryResponse, 3, cacheItem)
+	assert.NoError(t, err)
+}
+
+func TestStoreUserEntryCacheUnavailable(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockCache := mock.NewMockCache(ctrl)
+	mockCache.EXPECT().StoreHashMap(gomock.Any(), gomock.Any(), gomock.Any()).Return(internal.ErrCacheUnavailable)
+
+	s := &SlackCommandRequest{
+		ctx:        context.Background(),
+		slackEvent: &internal.SlackEvent{UserID: "U123456", ChannelID: "C123456"},
+		cache:      mockCache,
+	}
+
+	response := internal.MendableQueryResponse{ConversationID: 123456, Question: "What is Palette?", Answer: "A platform."}
+
+	err := storeUserEntry(context.Background(), s, response, 1, nil)
+	assert.ErrorIs(t, err, internal.ErrCacheUnavailable)
+}
+
+func TestGetConversation(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockCache := mock.NewMockCache(ctrl)
+	key := "docs_bot:user_id:channel_id:U123456:C123456"
+	cached := map[string]string{"ConversationID": "123", "Counter": "2", "History": `[{"prompt":"What is Palette?","response":"A platform."}]`}
+
+	s := &SlackCommandRequest{
+		ctx:        context.Background(),
+		slackEvent: &internal.SlackEvent{UserID: "U123456", ChannelID: "C123456"},
+		cache:      mockCache,
+	}
+
+	// Questions typed by the user continue their conversation.
+	mockCache.EXPECT().GetHashMap(gomock.Any(), key).Return(true, cached, nil)
+	found, item, err := getConversation(context.Background(), s)
+	require.NoError(t, err)
+	assert.True(t, found)
+	assert.Equal(t, "123", item.ConversationID)
+	assert.Len(t, item.History, 1)
+
+	// Answer actions of the current conversation of the user keep the history.
+	s.slackEvent.ConversationID = "123"
+	mockCache.EXPECT().GetHashMap(gomock.Any(), key).Return(true, cached, nil)
+	found, item, err = getConversation(context.Background(), s)
+	require.NoError(t, err)
+	assert.True(t, found)
+	assert.Equal(t, "2", item.Counter)
+	assert.Len(t, item.History, 1)
+
+	// Answer actions of another conversation continue it without the history of the user.
+	s.slackEvent.ConversationID = "456"
+	mockCache.EXPECT().GetHashMap(gomock.Any(), key).Return(true, cached, nil)
+	found, item, err = getConversation(context.Background(), s)
+	require.NoError(t, err)
+	assert.True(t, found)
+	assert.Equal(t, &internal.CacheItem{ConversationID: "456", Counter: "0"}, item)
+
+	mockCache.EXPECT().GetHashMap(gomock.Any(), key).Return(false, nil, nil)
+	found, item, err = getConversation(context.Background(), s)
+	require.NoError(t, err)
+	assert.True(t, found)
+	assert.Equal(t, "456", item.ConversationID)
+
+	// The cache error is returned, so the question is answered in a new conversation.
+	mockCache.EXPECT().GetHashMap(gomock.Any(), key).Return(false, nil, internal.ErrCacheUnavailable)
+	_, _, err = getConversation(context.Background(), s)
+	assert.ErrorIs(t, err, internal.ErrCacheUnavailable)
+}
+
+func TestAnswerTitle(t *testing.T) {
+	assert.Equal(t, "Docs Answer", answerTitle(internal.DocsScope{}))
+	assert.Equal(t, "Docs Answer (palette v4.2)", answerTitle(internal.DocsScope{Product: "palette", Version: "4.2"}))
+}
+
+func TestAnswerPayloads(t *testing.T) {
+	paragraph := strings.Repeat("word ", 100) + "\n\n"
+
+	tests := []struct {
+		name      string
+		answer    string
+		payloads  int
+		continued bool
+		truncated bool
+	}{
+		{"short answer", "Use the `palette` CLI.", 1, false, false},
+		{"long answer in one message", strings.Repeat(paragraph, 100), 1, false, false},
+		{"answer with follow-up messages", strings.Repeat(paragraph, 300), 2, true, false},
+		{"truncated answer", strings.Repeat(paragraph, 5000), internal.DefaultMaxAnswerFollowUps + 1, true, true},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			payloads, err := AnswerPayloads(internal.AnswerState{
+				MessageID:  "123",
+				Title:      "Docs Answer",
+				Question:   ":question: How?",
+				Answer:     tc.answer,
+				Links:      strings.Repeat("https://docs.spectrocloud.com\n", 200),
+				Confidence: "90",
+			}, "")
+			assert.NoError(t, err)
+			assert.Len(t, payloads, tc.payloads)
+
+			var text strings.Builder
+			for i, raw := range payloads {
+				var payload internal.SlackPayload
+				assert.NoError(t, json.Unmarshal(raw, &payload))
+				assert.LessOrEqual(t, len(payload.Blocks), blockkit.MaxBlocks, "payload %d exceeds the Slack block limit", i)
+				assert.Equal(t, "in_channel", payload.ResponseType)
+				for _, block := range payload.Blocks {
+					if block.Text != nil {
+						text.WriteString(block.Text.Text)
+					}
+				}
+			}
+
+			// Every section of an answer that isn't truncated is displayed once, in order.
+			if !tc.truncated {
+				var ids []string
+				for _, raw := range payloads {
+					var message internal.SlackMessage
+					assert.NoError(t, json.Unmarshal(raw, &message))
+					for _, block := range message.Blocks {
+						if strings.HasPrefix(block.BlockID, "answer_text:") {
+							ids = append(ids, block.BlockID)
+						}
+					}
+				}
+				sections := internal.AnswerSections(tc.answer)
+				assert.Len(t, ids, len(sections))
+				for i := range ids {
+					assert.Equal(t, internal.AnswerTextBlockID(i), ids[i])
+				}
+			}
+
+			// The rating buttons and answer actions are part of the first message.
+			var first internal.SlackPayload
+			assert.NoError(t, json.Unmarshal(payloads[0], &first))
+			assert.Equal(t, "actions", first.Blocks[len(first.Blocks)-1].Type)
+
+			// The answer actions read the first message by block ID.
+			var message internal.SlackMessage
+			assert.NoError(t, json.Unmarshal(payloads[0], &message))
+			state, found := internal.AnswerStateFromBlocks("123", message.Blocks)
+			assert.True(t, found)
+			assert.Equal(t, ":question: How?", state.Question)
+			assert.Equal(t, "Docs Answer", state.Title)
+
+			assert.Equal(t, tc.continued, strings.Contains(text.String(), internal.DefaultAnswerContinuedMessage))
+			assert.Equal(t, tc.truncated, strings.Contains(text.String(), internal.DefaultAnswerTruncatedMessage))
+			assert.Equal(t, tc.truncated, strings.Contains(string(payloads[len(payloads)-1]), internal.ActionsAskShowRestID))
+		})
+	}
+}
+
+func TestAnswerPayloadsByline(t *testing.T) {
+	payloads, err := AnswerPayloads(internal.AnswerState{MessageID: "123", Title: "Docs Answer", Question: ":question: How?", Answer: "Like this.", Links: internal.DefaultNoSourcesIdentifiedMessage, Private: true}, "Shared by <@U123>.")
+	assert.NoError(t, err)
+	assert.Len(t, payloads, 1)
+
+	var message internal.SlackMessage
+	assert.NoError(t, json.Unmarshal(payloads[0], &message))
+	assert.Equal(t, "Shared by <@U123>.", internal.AnswerBylineFromBlocks(message.Blocks))
+
+	// Private answers can be shared in the channel.
+	state, found := internal.AnswerStateFromBlocks("123", message.Blocks)
+	assert.True(t, found)
+	assert.True(t, state.Private)
+}
+
+func TestAnswerRestPayloads(t *testing.T) {
+	paragraph := strings.Repeat("word ", 100) + "\n\n"
+	state := internal.AnswerState{MessageID: "123", Title: "Docs Answer", Question: ":question: How?", Answer: strings.Repeat(paragraph, 5000), Links: "https://docs.spectrocloud.com", Confidence: "90"}
+	total := len(internal.AnswerSections(state.Answer))
+
+	// The button of the truncated answer shows the first section that wasn't displayed.
+	payloads, err := AnswerPayloads(state, "")
+	require.NoError(t, err)
+	index := showRestIndex(t, payloads[len(payloads)-1])
+	assert.Greater(t, index, 0)
+
+	// The rest of the answer is private, and continues where the answer stopped.
+	rest, err := AnswerRestPayloads(state, index)
+	require.NoError(t, err)
+	assert.Len(t, rest, internal.DefaultMaxAnswerFollowUps+1)
+
+	shown := 0
+	for i, raw := range rest {
+		var payload internal.SlackPayload
+		require.NoError(t, json.Unmarshal(raw, &payload))
+		assert.LessOrEqual(t, len(payload.Blocks), blockkit.MaxBlocks, "payload %d exceeds the Slack block limit", i)
+		assert.Equal(t, "ephemeral", payload.ResponseType)
+		assert.Equal(t, "Docs Answer (continued)", payload.Blocks[0].Text.Text)
+
+		var message internal.SlackMessage
+		require.NoError(t, json.Unmarshal(raw, &message))
+		for _, block := range message.Blocks {
+			if strings.HasPrefix(block.BlockID, "answer_text:") {
+				if shown == 0 {
+					assert.Equal(t, internal.AnswerTextBlockID(index), block.BlockID)
+				}
+				shown++
+			}
+		}
+	}
+
+	// The answer is still too long, so the last message shows the sections that follow.
+	assert.Equal(t, index+shown, showRestIndex(t, rest[len(rest)-1]))
+
+	// The end of the answer fits in a single message without a button.
+	rest, err = AnswerRestPayloads(state, total-2)
+	require.NoError(t, err)
+	assert.Len(t, rest, 1)
+	assert.NotContains(t, string(rest[0]), internal.ActionsAskShowRestID)
+
+	rest, err = AnswerRestPayloads(state, total)
+	require.NoError(t, err)
+	assert.Empty(t, rest)
+}
+
+// showRestIndex returns the section index of the button showing the rest of the answer in the payload.
+func showRestIndex(t *testing.T, raw []byte) int {
+	t.Helper()
+	var payload internal.SlackPayload
+	require.NoError(t, json.Unmarshal(raw, &payload))
+	for _, block := range payload.Blocks {
+		if block.Accessory != nil && block.Accessory.ActionID == internal.ActionsAskShowRestID {
+			messageID, index, err := internal.ParseShowRestValue(block.Accessory.Value)
+			require.NoError(t, err)
+			assert.Equal(t, "123", messageID)
+			return index
+		}
+	}
+	t.Fatal("The payload has no button showing the rest of the answer.")
+	return 0
+}
+
+func TestAnswerPayloadsLowConfidence(t *testing.T) {
+	state := internal.AnswerState{MessageID: "123", Title: "Docs Answer", Question: ":question: How?", Answer: "Maybe like this.", Links: "https://docs.spectrocloud.com", Confidence: "12.00", LowConfidence: internal.LowConfidenceCaveat, Escalate: true}
+
+	// Caveated answers are displayed below the warning.
+	payloads, err := AnswerPayloads(state, "")
+	assert.NoError(t, err)
+	assert.Len(t, payloads, 1)
+	assert.Contains(t, string(payloads[0]), "answer_low_confidence:caveat")
+	assert.Contains(t, string(payloads[0]), "Maybe like this.")
+	assert.Contains(t, string(payloads[0]), internal.ActionsAskEscalateID)
+
+	// Withheld answers only display the sources, and are still found by the answer actions.
+	state.LowConfidence = internal.LowConfidenceWithhold
+	payloads, err = AnswerPayloads(state, "")
+	assert.NoError(t, err)
+	assert.Len(t, payloads, 1)
+	assert.NotContains(t, string(payloads[0]), "Maybe like this.")
+	assert.Contains(t, string(payloads[0]), "https://docs.spectrocloud.com")
+
+	var message internal.SlackMessage
+	assert.NoError(t, json.Unmarshal(payloads[0], &message))
+	fromBlocks, found := internal.AnswerStateFromBlocks("123", message.Blocks)
+	assert.True(t, found)
+	assert.Equal(t, internal.LowConfidenceWithhold, fromBlocks.LowConfidence)
+	assert.Empty(t, fromBlocks.Answer)
+}
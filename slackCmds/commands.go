@@ -0,0 +1,215 @@
+// Copyright (c) Spectro Cloud
+// SPDX-License-Identifier: Apache-2.0
+
+package slackCmds
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"spectrocloud.com/spectromate/internal"
+)
+
+// SlackCommandRequest contains the Slack event and the dependencies available to a command.
+type SlackCommandRequest struct {
+	ctx            context.Context
+	slackEvent     *internal.SlackEvent
+	mendableAPIKey string
+	cache          internal.Cache
+	version        string
+	conversation   internal.ConversationConfig
+	scopes         internal.DocsScopeConfig
+	channel        internal.ChannelConfig
+	args           CommandArgs
+	redactor       *internal.Redactor
+	escalation     internal.EscalationConfig
+	confidence     internal.ConfidenceGate
+}
+
+// CommandDependencies are the settings and services shared by the commands.
+type CommandDependencies struct {
+	// MendableAPIKey is the API key of the default answer backend.
+	MendableAPIKey string
+	Backends       internal.AnswerBackends
+	Cache          internal.Cache
+	Version        string
+	Conversation   internal.ConversationConfig
+	Scopes         internal.DocsScopeConfig
+	Redactor       *internal.Redactor
+	Escalation     internal.EscalationConfig
+	// Confidence is the confidence gate before the overrides of the channel.
+	Confidence internal.ConfidenceGate
+}
+
+// NewSlackCommandRequest returns the request of a command run in the channel.
+// The answer backend and confidence gate of the channel settings are resolved from the dependencies.
+func NewSlackCommandRequest(ctx context.Context, slackEvent *internal.SlackEvent, channel internal.ChannelConfig, args CommandArgs, deps CommandDependencies) *SlackCommandRequest {
+	return &SlackCommandRequest{
+		ctx:            ctx,
+		slackEvent:     slackEvent,
+		mendableAPIKey: deps.Backends.APIKey(channel.Backend, deps.MendableAPIKey),
+		cache:          deps.Cache,
+		version:        deps.Version,
+		conversation:   deps.Conversation,
+		scopes:         deps.Scopes,
+		channel:        channel,
+		args:           args,
+		redactor:       deps.Redactor,
+		escalation:     deps.Escalation,
+		confidence:     deps.Confidence.ForChannel(channel),
+	}
+}
+
+// CommandHandler contains the logic of a command.
+// Synchronous handlers return the payload sent back to Slack in the HTTP response.
+// Asynchronous handlers reply through the response URL, so the returned payload is ignored.
+type CommandHandler func(s *SlackCommandRequest) ([]byte, error)
+
+// Command describes a Slack sub-command, such as `/docs ask`.
+type Command struct {
+	// Name is the sub-command typed by the user.
+	Name string
+	// Aliases are alternative names for the command.
+	Aliases []string
+	// Description is a one line summary displayed in the help message.
+	Description string
+	// Usage is an example displayed in the help message.
+	Usage string
+	// Flags are the options accepted by the command. Unknown flags are passed as positional arguments.
+	Flags []Flag
+	// Async commands are acknowledged immediately and run in a Go routine to avoid the Slack 3 second timeout.
+	Async bool
+	// Private commands acknowledge the request with an ephemeral message.
+	// Commands accepting the private flag are also acknowledged privately when the flag is set.
+	Private bool
+	// RequiresInput commands display the help message if nothing is typed after the command.
+	RequiresInput bool
+	// Unrestricted commands can't be disabled in a channel.
+	Unrestricted bool
+	// RateLimited commands are subject to the user, channel and workspace rate limits.
+	RateLimited bool
+	// Handler contains the logic of the command.
+	Handler CommandHandler
+}
+
+// Registry contains the available commands in the order they were registered.
+type Registry struct {
+	commands []*Command
+	lookup   map[string]*Command
+}
+
+// NewRegistry returns an empty command registry.
+func NewRegistry() *Registry {
+	return &Registry{lookup: map[string]*Command{}}
+}
+
+// Register adds a command to the registry.
+// An error is returned if the name or an alias is already used by another command.
+func (r *Registry) Register(cmd *Command) error {
+	if cmd == nil || strings.TrimSpace(cmd.Name) == "" {
+		return fmt.Errorf("a command requires a name")
+	}
+	if cmd.Handler == nil {
+		return fmt.Errorf("command %s requires a handler", cmd.Name)
+	}
+
+	names := append([]string{cmd.Name}, cmd.Aliases...)
+	for _, name := range names {
+		if existing, ok := r.lookup[strings.ToLower(name)]; ok {
+			return fmt.Errorf("command %s conflicts with the command %s", name, existing.Name)
+		}
+	}
+
+	for _, name := range names {
+		r.lookup[strings.ToLower(name)] = cmd
+	}
+	r.commands = append(r.commands, cmd)
+
+	return nil
+}
+
+// Lookup returns the command matching the name or alias. The lookup is case insensitive.
+func (r *Registry) Lookup(name string) (*Command, bool) {
+	cmd, ok := r.lookup[strings.ToLower(strings.TrimSpace(name))]
+	return cmd, ok
+}
+
+// IsPrivate returns true if the command replies privately for the arguments and channel settings.
+func (c *Command) IsPrivate(args CommandArgs, channel internal.ChannelConfig) bool {
+	return c.Private || args.Bool("private") || channel.Private
+}
+
+// Commands returns all registered commands in the order they were registered.
+func (r *Registry) Commands() []*Command {
+	return r.commands
+}
+
+// Names returns the names of all registered commands.
+func (r *Registry) Names() []string {
+	names := make([]string, 0, len(r.commands))
+	for _, cmd := range r.commands {
+		names = append(names, cmd.Name)
+	}
+	return names
+}
+
+// NewDefaultRegistry returns a registry with all the commands supported by SpectroMate.
+// Add new commands here to make them available in Slack and in the help message.
+// The authorizer and backends are used by the config command.
+func NewDefaultRegistry(authorizer *internal.ConfigAuthorizer, backends internal.AnswerBackends) *Registry {
+	r := NewRegistry()
+
+	commands := []*Command{
+		{
+			Name:         "help",
+			Description:  "A summary of all available commands.",
+			Unrestricted: true,
+			Handler: func(s *SlackCommandRequest) ([]byte, error) {
+				return HelpCmd(r)
+			},
+		},
+		{
+			Name:          "ask",
+			Description:   "Ask a docs related question.",
+			Usage:         "/docs ask how do I enable Prometheus?",
+			Flags:         askFlags,
+			Async:         true,
+			RequiresInput: true,
+			RateLimited:   true,
+			Handler: func(s *SlackCommandRequest) ([]byte, error) {
+				return nil, AskCmd(s, false)
+			},
+		},
+		{
+			Name:          "pask",
+			Description:   "Same as `ask` but with private replies.",
+			Usage:         "/docs pask how do I enable Prometheus?",
+			Flags:         askFlags,
+			Async:         true,
+			Private:       true,
+			RequiresInput: true,
+			RateLimited:   true,
+			Handler: func(s *SlackCommandRequest) ([]byte, error) {
+				return nil, AskCmd(s, true)
+			},
+		},
+		{
+			Name:         "config",
+			Description:  "Show or change the settings of the channel. Only workspace admins and the creator of the channel can change the settings.",
+			Usage:        "/docs config set private=true product=palette version=4.2 commands=ask,help",
+			Unrestricted: true,
+			Handler: func(s *SlackCommandRequest) ([]byte, error) {
+				return ConfigCmd(s, authorizer, backends, r.Names())
+			},
+		},
+	}
+
+	for _, cmd := range commands {
+		if err := r.Register(cmd); err != nil {
+			panic(err)
+		}
+	}
+
+	return r
+}
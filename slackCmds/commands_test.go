@@ -0,0 +1,110 @@
+// Copyright (c) Spectro Cloud
+// SPDX-License-Identifier: Apache-2.0
+
+package slackCmds
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"spectrocloud.com/spectromate/internal"
+)
+
+func noopHandler(s *SlackCommandRequest) ([]byte, error) {
+	return nil, nil
+}
+
+func TestRegistryRegister(t *testing.T) {
+	r := NewRegistry()
+
+	require.NoError(t, r.Register(&Command{Name: "coffee", Aliases: []string{"brew"}, Handler: noopHandler}))
+
+	cmd, ok := r.Lookup("coffee")
+	require.True(t, ok)
+	assert.Equal(t, "coffee", cmd.Name)
+
+	cmd, ok = r.Lookup("BREW")
+	require.True(t, ok)
+	assert.Equal(t, "coffee", cmd.Name)
+
+	_, ok = r.Lookup("tea")
+	assert.False(t, ok)
+
+	assert.Error(t, r.Register(&Command{Name: "coffee", Handler: noopHandler}), "Expected an error for a duplicate name")
+	assert.Error(t, r.Register(&Command{Name: "espresso", Aliases: []string{"brew"}, Handler: noopHandler}), "Expected an error for a duplicate alias")
+	assert.Error(t, r.Register(&Command{Name: "", Handler: noopHandler}), "Expected an error for a missing name")
+	assert.Error(t, r.Register(&Command{Name: "tea"}), "Expected an error for a missing handler")
+
+	// Failed registrations must not leave partial entries behind.
+	_, ok = r.Lookup("espresso")
+	assert.False(t, ok)
+	assert.Len(t, r.Commands(), 1)
+}
+
+func TestDefaultRegistryCommands(t *testing.T) {
+	r := NewDefaultRegistry(nil, nil)
+
+	tests := []struct {
+		name          string
+		async         bool
+		private       bool
+		requiresInput bool
+		rateLimited   bool
+		unrestricted  bool
+	}{
+		{name: "help", unrestricted: true},
+		{name: "config", unrestricted: true},
+		{name: "ask", async: true, requiresInput: true, rateLimited: true},
+		{name: "pask", async: true, private: true, requiresInput: true, rateLimited: true},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			cmd, ok := r.Lookup(tc.name)
+			require.True(t, ok)
+			assert.Equal(t, tc.async, cmd.Async)
+			assert.Equal(t, tc.private, cmd.Private)
+			assert.Equal(t, tc.requiresInput, cmd.RequiresInput)
+			assert.Equal(t, tc.rateLimited, cmd.RateLimited)
+			assert.Equal(t, tc.unrestricted, cmd.Unrestricted)
+			assert.NotEmpty(t, cmd.Description)
+			assert.NotNil(t, cmd.Handler)
+		})
+	}
+}
+
+func TestHelpCmd(t *testing.T) {
+	r := NewDefaultRegistry(nil, nil)
+	require.NoError(t, r.Register(&Command{
+		Name:        "coffee",
+		Aliases:     []string{"brew"},
+		Description: "Order a coffee.",
+		Usage:       "/docs coffee latte",
+		Handler:     noopHandler,
+	}))
+
+	help, ok := r.Lookup("help")
+	require.True(t, ok)
+
+	payload, err := help.Handler(&SlackCommandRequest{})
+	require.NoError(t, err)
+
+	var message internal.SlackPayload
+	require.NoError(t, json.Unmarshal(payload, &message))
+	assert.Equal(t, "ephemeral", message.ResponseType)
+
+	content := message.Blocks[len(message.Blocks)-1].Text.Text
+	for _, cmd := range r.Commands() {
+		assert.Contains(t, content, "`"+cmd.Name+"` - "+cmd.Description)
+	}
+	assert.Contains(t, content, "Example: `/docs ask how do I enable Prometheus?`")
+	assert.Contains(t, content, "Aliases: `brew`.")
+	for _, f := range askFlags {
+		assert.Contains(t, content, "` - "+f.Description)
+	}
+	assert.Contains(t, content, "`--product=<value>` - Limit the answer to a product.")
+	assert.True(t, strings.Index(content, "`help`") < strings.Index(content, "`coffee`"), "Expected commands in registration order")
+}
@@ -0,0 +1,101 @@
+// Copyright (c) Spectro Cloud
+// SPDX-License-Identifier: Apache-2.0
+
+package slackCmds
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/rs/zerolog/log"
+	"spectrocloud.com/spectromate/internal"
+)
+
+// ConfigCmd shows or changes the settings of the channel.
+// Anyone can view the settings. Only users allowed by the authorizer can change them.
+// The reply is always private.
+func ConfigCmd(s *SlackCommandRequest, authorizer *internal.ConfigAuthorizer, backends internal.AnswerBackends, commands []string) ([]byte, error) {
+	ctx, cancel := context.WithTimeout(s.ctx, internal.DefaultSyncCommandTimeout)
+	defer cancel()
+
+	action := "show"
+	settings := s.args.Positional
+	if len(settings) > 0 {
+		action = strings.ToLower(settings[0])
+		settings = settings[1:]
+	}
+
+	teamID, channelID := s.slackEvent.TeamID, s.slackEvent.ChannelID
+
+	switch action {
+	case "show":
+		config, err := internal.GetChannelConfig(ctx, s.cache, teamID, channelID)
+		if err != nil {
+			log.Error().Err(err).Str("channel_id", channelID).Msg("Error reading the channel settings.")
+			return internal.MessagePayload(internal.DefaultConfigErrorMessage, true)
+		}
+		return internal.MessagePayload(config.Summary(), true)
+
+	case "set", "reset":
+		if action == "set" && !validSettings(settings) {
+			return internal.MessagePayload(internal.DefaultConfigUsageMessage, true)
+		}
+
+		allowed, err := authorizer.CanConfigure(ctx, s.slackEvent.UserID, channelID)
+		if err != nil {
+			log.Warn().Err(err).Str("user_id", s.slackEvent.UserID).Msg("Unable to check if the user can change the channel settings.")
+		}
+		if !allowed {
+			log.Info().Str("user_id", s.slackEvent.UserID).Str("channel_id", channelID).Msg("User is not allowed to change the channel settings.")
+			return internal.MessagePayload(internal.DefaultConfigForbiddenMessage, true)
+		}
+
+		if action == "reset" {
+			if err := internal.ResetChannelConfig(ctx, s.cache, teamID, channelID); err != nil {
+				log.Error().Err(err).Str("channel_id", channelID).Msg("Error resetting the channel settings.")
+				return internal.MessagePayload(internal.DefaultConfigErrorMessage, true)
+			}
+			log.Info().Str("user_id", s.slackEvent.UserID).Str("channel_id", channelID).Msg("Channel settings reset.")
+			return internal.MessagePayload(":white_check_mark: The channel settings are reset.\n\n"+internal.ChannelConfig{}.Summary(), true)
+		}
+
+		config, err := internal.GetChannelConfig(ctx, s.cache, teamID, channelID)
+		if err != nil {
+			log.Error().Err(err).Str("channel_id", channelID).Msg("Error reading the channel settings.")
+			return internal.MessagePayload(internal.DefaultConfigErrorMessage, true)
+		}
+
+		for _, setting := range settings {
+			key, value, _ := strings.Cut(setting, "=")
+			if err := config.ApplySetting(key, unquote(value), commands, backends); err != nil {
+				return internal.MessagePayload(fmt.Sprintf(":warning: %s.", strings.TrimSuffix(err.Error(), ".")), true)
+			}
+		}
+
+		config.UpdatedBy = s.slackEvent.UserID
+		config.UpdatedAt = time.Now()
+
+		if err := internal.StoreChannelConfig(ctx, s.cache, teamID, channelID, config); err != nil {
+			log.Error().Err(err).Str("channel_id", channelID).Msg("Error storing the channel settings.")
+			return internal.MessagePayload(internal.DefaultConfigErrorMessage, true)
+		}
+
+		log.Info().Str("user_id", s.slackEvent.UserID).Str("channel_id", channelID).Strs("settings", settings).Msg("Channel settings updated.")
+		return internal.MessagePayload(":white_check_mark: The channel settings are saved.\n\n"+config.Summary(), true)
+
+	default:
+		return internal.MessagePayload(internal.DefaultConfigUsageMessage, true)
+	}
+}
+
+// validSettings returns true if there is at least one setting and every setting is in the format key=value.
+func validSettings(settings []string) bool {
+	for _, setting := range settings {
+		if !strings.Contains(setting, "=") {
+			return false
+		}
+	}
+	return len(settings) > 0
+}
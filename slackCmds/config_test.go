@@ -0,0 +1,100 @@
+// Copyright (c) Spectro Cloud
+// SPDX-License-Identifier: Apache-2.0
+
+package slackCmds
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"spectrocloud.com/spectromate/internal"
+	"spectrocloud.com/spectromate/mock"
+)
+
+// configReply runs the config command and returns the text of the reply.
+func configReply(t *testing.T, s *SlackCommandRequest, authorizer *internal.ConfigAuthorizer, backends internal.AnswerBackends, commands []string) string {
+	t.Helper()
+	payload, err := ConfigCmd(s, authorizer, backends, commands)
+	require.NoError(t, err)
+
+	var message internal.SlackPayload
+	require.NoError(t, json.Unmarshal(payload, &message))
+	assert.Equal(t, "ephemeral", message.ResponseType)
+	return message.Blocks[0].Text.Text
+}
+
+func TestConfigCmd(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	cache := mock.NewMockCache(ctrl)
+	authorizer := internal.NewConfigAuthorizer([]string{"UADMIN"}, nil)
+	backends := internal.AnswerBackends{"edge": "edge-key"}
+	commands := []string{"help", "ask", "pask", "config"}
+	key := "docs_bot:channel_config:T123:C123"
+
+	request := func(user, text string) *SlackCommandRequest {
+		return &SlackCommandRequest{
+			ctx:        context.Background(),
+			slackEvent: &internal.SlackEvent{TeamID: "T123", ChannelID: "C123", UserID: user},
+			cache:      cache,
+			args:       ParseArgs(Tokenize(text), nil),
+		}
+	}
+
+	t.Run("show", func(t *testing.T) {
+		cache.EXPECT().GetHashMap(gomock.Any(), key).Return(true, map[string]string{"private": "true", "product": "palette"}, nil)
+		reply := configReply(t, request("UMEMBER", ""), authorizer, backends, commands)
+		assert.Contains(t, reply, "`private`: true")
+		assert.Contains(t, reply, "`product`: palette")
+	})
+
+	t.Run("set is restricted", func(t *testing.T) {
+		reply := configReply(t, request("UMEMBER", "set private=true"), authorizer, backends, commands)
+		assert.Equal(t, internal.DefaultConfigForbiddenMessage, reply)
+	})
+
+	t.Run("set", func(t *testing.T) {
+		cache.EXPECT().GetHashMap(gomock.Any(), key).Return(false, nil, nil)
+		cache.EXPECT().StoreHashMap(gomock.Any(), key, gomock.Any()).DoAndReturn(
+			func(_ context.Context, _ string, item map[string]interface{}) error {
+				assert.Equal(t, "true", item["private"])
+				assert.Equal(t, "palette", item["product"])
+				assert.Equal(t, "ask,help", item["commands"])
+				assert.Equal(t, "edge", item["backend"])
+				assert.Equal(t, "UADMIN", item["updated_by"])
+				return nil
+			})
+		reply := configReply(t, request("UADMIN", `set private=true product="palette" commands=ask,help backend=edge`), authorizer, backends, commands)
+		assert.Contains(t, reply, "saved")
+	})
+
+	t.Run("set invalid value", func(t *testing.T) {
+		cache.EXPECT().GetHashMap(gomock.Any(), key).Return(false, nil, nil)
+		reply := configReply(t, request("UADMIN", "set commands=coffee"), authorizer, backends, commands)
+		assert.Contains(t, reply, "unknown command `coffee`")
+	})
+
+	t.Run("reset", func(t *testing.T) {
+		cache.EXPECT().DeleteKey(gomock.Any(), key).Return(nil)
+		reply := configReply(t, request("UADMIN", "reset"), authorizer, backends, commands)
+		assert.Contains(t, reply, "reset")
+	})
+
+	t.Run("cache unavailable", func(t *testing.T) {
+		cache.EXPECT().GetHashMap(gomock.Any(), key).Return(false, nil, internal.ErrCacheUnavailable)
+		reply := configReply(t, request("UMEMBER", "show"), authorizer, backends, commands)
+		assert.Equal(t, internal.DefaultConfigErrorMessage, reply)
+	})
+
+	t.Run("usage", func(t *testing.T) {
+		for _, text := range []string{"set", "set private", "unknown"} {
+			reply := configReply(t, request("UADMIN", text), authorizer, backends, commands)
+			assert.Equal(t, internal.DefaultConfigUsageMessage, reply, text)
+		}
+	})
+}
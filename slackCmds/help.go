@@ -4,16 +4,19 @@
 package slackCmds
 
 import (
-	"encoding/json"
+	"fmt"
+	"strings"
 
 	"github.com/rs/zerolog/log"
 	"spectrocloud.com/spectromate/internal"
+	"spectrocloud.com/spectromate/internal/blockkit"
 )
 
 // HelpCmd returns the help Slack command logic and payload.
-func HelpCmd() ([]byte, error) {
+// The help message is generated from the commands in the registry.
+func HelpCmd(r *Registry) ([]byte, error) {
 
-	markdownContent := "*Commands*\n\nThe following commands are available:\n\n\n- `help` - A summary of all available commands.\n\n- `ask` - Ask a docs related question. Example: `/docs ask how do I enable Prometheus?`\n\n- `pask` - Same as `ask` but with private replies."
+	markdownContent := helpMarkdownContent(r)
 	returnPayload, err := helpMarkdownPayload(markdownContent, "Docs Answer")
 	if err != nil {
 		log.Info().Err(err).Msg("Error creating markdown payload.")
@@ -23,34 +26,44 @@ func HelpCmd() ([]byte, error) {
 	return returnPayload, nil
 }
 
+// helpMarkdownContent returns the list of commands as markdown.
+func helpMarkdownContent(r *Registry) string {
+	var sb strings.Builder
+
+	sb.WriteString("*Commands*\n\nThe following commands are available:\n\n")
+	for _, cmd := range r.Commands() {
+		sb.WriteString(fmt.Sprintf("\n- `%s` - %s", cmd.Name, cmd.Description))
+		if len(cmd.Aliases) > 0 {
+			sb.WriteString(fmt.Sprintf(" Aliases: `%s`.", strings.Join(cmd.Aliases, "`, `")))
+		}
+		if cmd.Usage != "" {
+			sb.WriteString(fmt.Sprintf(" Example: `%s`", cmd.Usage))
+		}
+		// Each option is listed below the command with its description.
+		if len(cmd.Flags) > 0 {
+			sb.WriteString(" Options:")
+			for _, f := range cmd.Flags {
+				name := "--" + f.Name
+				if f.HasValue {
+					name += "=<value>"
+				}
+				sb.WriteString(fmt.Sprintf("\n    - `%s` - %s", name, f.Description))
+			}
+		}
+		sb.WriteString("\n")
+	}
+
+	return strings.TrimRight(sb.String(), "\n")
+}
+
 // // createMarkdownPayload creates a Slack payload with a markdown block
 func helpMarkdownPayload(content, title string) ([]byte, error) {
 	log.Debug().Msgf("Incoming Message: %v", content)
 
-	payload := internal.SlackPayload{
-		ResponseType: "ephemeral",
-		Blocks: []internal.SlackBlock{
-			{
-				Type: "header",
-				Text: &internal.SlackTextObject{
-					Type: "plain_text",
-					Text: title,
-				},
-			},
-			{
-				Type: "divider",
-			},
-			{
-				Type: "section",
-				Text: &internal.SlackTextObject{
-					Type: "mrkdwn",
-					Text: content,
-				},
-			},
-		},
-	}
+	payload := blockkit.NewMessage(true, blockkit.Header(title), blockkit.Divider()).
+		Add(internal.MarkdownSections(content)...)
 
-	payloadBytes, err := json.Marshal(payload)
+	payloadBytes, err := payload.JSON()
 	if err != nil {
 		return []byte{}, err
 	}